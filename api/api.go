@@ -0,0 +1,16 @@
+// Package api holds the OpenAPI description of the HTTP API and the page
+// that renders it, embedded so the binary serves them without extra files.
+package api
+
+import _ "embed"
+
+// Spec is the OpenAPI 3.1 document, in YAML. It is maintained by hand next to
+// internal/routes; tests/routes fails when a registered route is missing.
+//
+//go:embed swagger.yaml
+var Spec []byte
+
+// DocsPage is the html/template the /docs reference is rendered with.
+//
+//go:embed docs.html
+var DocsPage []byte
@@ -1,21 +1,33 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"net/http"
 	"project/internal/config"
 	"project/internal/database"
+	"project/internal/repositories"
+	"project/internal/services"
 
 	// "project/internal/handlers"
-	// "project/internal/middleware"
+	"project/internal/logging"
+	"project/internal/middleware"
 	"project/internal/routes"
 
 	"github.com/gorilla/mux"
 )
 
 func main() {
-	// Load configuration
-	cfg := config.LoadConfig()
+	// Load configuration once: defaults, config file, profile, env, flags
+	configFlags := config.BindFlags(flag.CommandLine)
+	flag.Parse()
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logging.Setup()
+	log.Printf("Configuration loaded (profile %s)", cfg.Env)
     // Initialize MongoDB
     if err := database.InitializeMongo(cfg.Mongo); err != nil {
         log.Fatal("Mongo connection failed: ", err)
@@ -23,38 +35,41 @@ func main() {
     if err := database.EnsureIndexes(); err != nil {
         log.Fatal("Mongo index ensure failed: ", err)
     }
+    if n, err := repositories.NewUserRepositoryMongo().BackfillSearchNames(); err != nil {
+        log.Fatal("search backfill failed: ", err)
+    } else if n > 0 {
+        log.Printf("Backfilled search names for %d users", n)
+    }
     defer database.CloseMongo()
+    if email := cfg.Admin.BootstrapEmail; email != "" {
+        users := services.NewUserService(repositories.NewUserRepositoryMongo(), services.NewAuditService(repositories.NewAuditRepositoryMongo()))
+        if promoted, err := users.BootstrapAdmin(context.Background(), email); err != nil {
+            log.Printf("admin bootstrap: %v", err)
+        } else if promoted {
+            log.Printf("admin bootstrap: %s is now an admin", email)
+        }
+    }
+    watchSecrets(context.Background(), cfg)
 	// Create router
 	router := mux.NewRouter()
-	routes.RegisterAPIRoutes(router) 
-	// Global middleware
-	// router.Use(middleware.Logging)
-	// router.Use(middleware.CORS)
-	
-	// API routes
-	// api := router.PathPrefix("/api/v1").Subrouter()
-	// api.Use(middleware.Auth)
-	
-	// Register handlers
-	// userHandler := handlers.NewUserHandler(db)
-	// productHandler := handlers.NewProductHandler(db)
-	
-	// User routes
-	// api.HandleFunc("/users", userHandler.GetUsers).Methods("GET")
-	// api.HandleFunc("/users/{id}", userHandler.GetUser).Methods("GET")
-	// api.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
-	
-	// Product routes
-	// api.HandleFunc("/products", productHandler.GetProducts).Methods("GET")
-	// api.HandleFunc("/products/{id}", productHandler.GetProduct).Methods("GET")
-	
-	// Health check
-	// router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-	// 	w.WriteHeader(http.StatusOK)
-	// 	w.Write([]byte("OK"))
-	// }).Methods("GET")
-	
+	if err := routes.RegisterAPIRoutes(router, cfg); err != nil {
+		log.Fatalf("routes: %v", err)
+	}
+	// Reloadable settings (log level, rate limits, CORS, security headers)
+	// follow SIGHUP and changes to the config file
+	store := config.NewStore(cfg, configFlags)
+	store.Subscribe(func(c *config.Config) {
+		if err := logging.SetLevel(c.Log.Level); err != nil {
+			log.Printf("config: %v", err)
+		}
+		middleware.Reconfigure(c)
+	})
+	go func() {
+		if err := store.Watch(context.Background()); err != nil {
+			log.Printf("config: reloading disabled: %v", err)
+		}
+	}()
 	// Start server
 	log.Printf("Server starting on port %s", cfg.Server.Port)
-	log.Fatal(http.ListenAndServe(":"+cfg.Server.Port, router))
+	log.Fatal(http.ListenAndServe(":"+cfg.Server.Port, middleware.CORS(router)))
 }
\ No newline at end of file
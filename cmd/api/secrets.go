@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"project/internal/config"
+	"project/internal/database"
+	"project/internal/secrets"
+	"time"
+)
+
+// watchSecrets polls the secret provider every secrets.refresh_seconds and
+// applies rotated credentials. A new Mongo URI reconnects in place; other
+// secrets are signed into tokens or handed to clients at startup and only
+// take effect after a restart.
+func watchSecrets(ctx context.Context, cfg *config.Config) {
+    if cfg.Secrets.RefreshSeconds <= 0 {
+        return
+    }
+    provider, err := cfg.SecretProvider()
+    if err != nil {
+        log.Printf("secrets: refresh disabled: %v", err)
+        return
+    }
+    mongoCfg := cfg.Mongo
+    refresher := secrets.NewRefresher(provider, cfg.SecretValues(), func(name, value string) error {
+        switch name {
+        case "MONGO_URI":
+            mongoCfg.URI = value
+            if err := database.ReconnectMongo(mongoCfg); err != nil {
+                return fmt.Errorf("MONGO_URI rotated but reconnecting failed: %w", err)
+            }
+            log.Printf("secrets: MONGO_URI rotated, reconnected to MongoDB")
+        default:
+            log.Printf("secrets: %s rotated; restart to apply it", name)
+        }
+        return nil
+    })
+    go refresher.Run(ctx, time.Duration(cfg.Secrets.RefreshSeconds)*time.Second)
+}
@@ -0,0 +1,139 @@
+// Package authctx carries the authenticated principal and client metadata
+// through request contexts.
+package authctx
+
+import "context"
+
+// Authentication methods recorded on a Principal.
+const (
+    MethodJWT    = "jwt"     // interactive login session
+    MethodAPIKey = "api_key" // personal API key
+    MethodOAuth  = "oauth"   // access token issued by our OAuth server
+)
+
+// Principal describes who is making the request and with which credentials.
+// TenantID is the organization the credentials were issued in. Groups and
+// Permissions come from the user's group memberships.
+type Principal struct {
+    UserID      string
+    Method      string
+    Scopes      []string
+    ClientID    string
+    TenantID    string
+    Groups      []string
+    Permissions []string
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+    return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal stored by middleware.Auth, if any.
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+    p, ok := ctx.Value(principalKey{}).(*Principal)
+    return p, ok && p != nil
+}
+
+// UserID returns the authenticated user's ID, or "" when there is none.
+func UserID(ctx context.Context) string {
+    if p, ok := PrincipalFrom(ctx); ok {
+        return p.UserID
+    }
+    return ""
+}
+
+type tenantKey struct{}
+
+// WithTenant scopes the request to an organization; "" is the default tenant.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+    return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantID returns the organization the request is scoped to, or "" for the
+// default tenant.
+func TenantID(ctx context.Context) string {
+    tenantID, _ := ctx.Value(tenantKey{}).(string)
+    return tenantID
+}
+
+// IsSession reports whether the principal logged in interactively.
+func (p *Principal) IsSession() bool {
+    return p.Method == MethodJWT
+}
+
+// HasScope reports whether the principal may act within scope. Login sessions
+// carry the user's full rights; keys and OAuth tokens only their granted scopes.
+func (p *Principal) HasScope(scope string) bool {
+    if p.IsSession() {
+        return true
+    }
+    for _, s := range p.Scopes {
+        if s == scope {
+            return true
+        }
+    }
+    return false
+}
+
+// InGroup reports whether the user is a member of the group groupID.
+func (p *Principal) InGroup(groupID string) bool {
+    for _, g := range p.Groups {
+        if g == groupID {
+            return true
+        }
+    }
+    return false
+}
+
+// HasPermission reports whether one of the user's groups grants permission.
+// Keys and OAuth tokens still need the matching scope; see HasScope.
+func (p *Principal) HasPermission(permission string) bool {
+    for _, perm := range p.Permissions {
+        if perm == permission {
+            return true
+        }
+    }
+    return false
+}
+
+// RequestInfo is client metadata captured once per request for auditing.
+// RequestID correlates the audit trail and logs of one request.
+type RequestInfo struct {
+    IP           string
+    ForwardedFor string
+    UserAgent    string
+    RequestID    string
+}
+
+type requestInfoKey struct{}
+
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+    return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+func RequestInfoFrom(ctx context.Context) RequestInfo {
+    info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+    return info
+}
+
+// APIVersion is the API version tree a request was routed to. BasePath is
+// the tree's path prefix, "" for the unversioned compatibility routes.
+type APIVersion struct {
+    Version  string
+    BasePath string
+}
+
+type apiVersionKey struct{}
+
+func WithAPIVersion(ctx context.Context, v APIVersion) context.Context {
+    return context.WithValue(ctx, apiVersionKey{}, v)
+}
+
+// APIVersionFrom returns the version set by middleware.APIVersion; it is the
+// zero value outside the version trees.
+func APIVersionFrom(ctx context.Context) APIVersion {
+    v, _ := ctx.Value(apiVersionKey{}).(APIVersion)
+    return v
+}
@@ -0,0 +1,267 @@
+// Package codec holds the body encodings the API speaks besides JSON, and
+// the negotiation that picks one per request: Content-Type for request bodies,
+// Accept for responses.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"project/pkg/utils"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes response bodies and decodes request bodies in one media type.
+// Structs are mapped through their json tags in every codec, so the field
+// names are the same whatever the encoding.
+type Codec interface {
+    MediaType() string
+    Marshal(v interface{}) ([]byte, error)
+    // Unmarshal decodes exactly one value, rejecting unknown fields and
+    // trailing data.
+    Unmarshal(data []byte, v interface{}) error
+}
+
+// Media types of the supported codecs.
+const (
+    JSONMediaType    = "application/json"
+    MsgPackMediaType = "application/msgpack"
+    CBORMediaType    = "application/cbor"
+)
+
+var (
+    JSON    Codec = jsonCodec{}
+    MsgPack Codec = msgpackCodec{}
+    CBOR    Codec = cborCodec{}
+)
+
+// codecs is in order of preference when the client has none.
+var codecs = []Codec{JSON, MsgPack, CBOR}
+
+// aliases maps other names in use for the same encodings.
+var aliases = map[string]Codec{
+    JSONMediaType:             JSON,
+    MsgPackMediaType:          MsgPack,
+    "application/x-msgpack":   MsgPack,
+    "application/vnd.msgpack": MsgPack,
+    CBORMediaType:             CBOR,
+}
+
+// ForContentType returns the codec for a request Content-Type. Structured
+// syntax suffixes count, so application/merge-patch+json is JSON.
+func ForContentType(contentType string) (Codec, bool) {
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        return nil, false
+    }
+    if c, ok := aliases[mediaType]; ok {
+        return c, true
+    }
+    switch {
+    case strings.HasSuffix(mediaType, "+json"):
+        return JSON, true
+    case strings.HasSuffix(mediaType, "+cbor"):
+        return CBOR, true
+    }
+    return nil, false
+}
+
+// Negotiate picks the response codec for an Accept header (RFC 9110 section
+// 12.5.1): the highest quality wins, a more specific range overrides a
+// wildcard, and ties go to JSON. An empty header means JSON; ok is false when
+// the client accepts none of the codecs.
+func Negotiate(accept string) (c Codec, ok bool) {
+    if strings.TrimSpace(accept) == "" {
+        return JSON, true
+    }
+    ranges := parseAccept(accept)
+    best, bestQ := Codec(nil), 0.0
+    for _, candidate := range codecs {
+        if q := quality(ranges, candidate); q > bestQ {
+            best, bestQ = candidate, q
+        }
+    }
+    return best, best != nil
+}
+
+type mediaRange struct {
+    mediaType string
+    q         float64
+}
+
+func parseAccept(accept string) []mediaRange {
+    var ranges []mediaRange
+    for _, part := range strings.Split(accept, ",") {
+        mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+        if err != nil {
+            continue
+        }
+        q := 1.0
+        if s, ok := params["q"]; ok {
+            if v, err := strconv.ParseFloat(s, 64); err == nil && v >= 0 && v <= 1 {
+                q = v
+            }
+        }
+        ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+    }
+    return ranges
+}
+
+// quality is the q of the most specific range matching c, or 0.
+func quality(ranges []mediaRange, c Codec) float64 {
+    q, specificity := 0.0, -1
+    for _, r := range ranges {
+        s := -1
+        switch {
+        case aliases[r.mediaType] == c:
+            s = 2
+        case r.mediaType == "application/*":
+            s = 1
+        case r.mediaType == "*/*":
+            s = 0
+        }
+        if s > specificity {
+            q, specificity = r.q, s
+        }
+    }
+    return q
+}
+
+// DecodeRequest decodes the request body into dst with the codec chosen by
+// its Content-Type, reading at most maxBytes. JSON goes through
+// utils.DecodeJSON; every failure is a *utils.DecodeError.
+func DecodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}, maxBytes int64) error {
+    c, ok := ForContentType(r.Header.Get("Content-Type"))
+    if !ok {
+        return &utils.DecodeError{
+            Status:  http.StatusUnsupportedMediaType,
+            Message: "Content-Type must be " + JSONMediaType + ", " + MsgPackMediaType + " or " + CBORMediaType,
+        }
+    }
+    if c == JSON {
+        return utils.DecodeJSON(w, r, dst, maxBytes)
+    }
+    body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+    var tooLarge *http.MaxBytesError
+    switch {
+    case errors.As(err, &tooLarge):
+        return &utils.DecodeError{Status: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("Request body must not exceed %d bytes", tooLarge.Limit)}
+    case err != nil:
+        return &utils.DecodeError{Status: http.StatusBadRequest, Message: "Failed to read request body: " + err.Error()}
+    case len(body) == 0:
+        return &utils.DecodeError{Status: http.StatusBadRequest, Message: "Request body must not be empty"}
+    }
+    if err := c.Unmarshal(body, dst); err != nil {
+        return &utils.DecodeError{Status: http.StatusBadRequest, Message: "Invalid " + c.MediaType() + " body: " + err.Error()}
+    }
+    return nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) MediaType() string { return JSONMediaType }
+
+// Marshal matches json.Encoder output, trailing newline included.
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+    var buf bytes.Buffer
+    err := json.NewEncoder(&buf).Encode(v)
+    return buf.Bytes(), err
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+    dec := json.NewDecoder(bytes.NewReader(data))
+    dec.DisallowUnknownFields()
+    if err := dec.Decode(v); err != nil {
+        return err
+    }
+    end := dec.InputOffset()
+    if err := dec.Decode(&json.RawMessage{}); !errors.Is(err, io.EOF) {
+        return fmt.Errorf("unexpected data after the value at offset %d", end)
+    }
+    return nil
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) MediaType() string { return MsgPackMediaType }
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+    var buf bytes.Buffer
+    enc := msgpack.NewEncoder(&buf)
+    enc.SetCustomStructTag("json")
+    enc.UseCompactInts(true)
+    err := enc.Encode(v)
+    return buf.Bytes(), err
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+    r := bytes.NewReader(data)
+    dec := msgpack.NewDecoder(r)
+    dec.SetCustomStructTag("json")
+    dec.DisallowUnknownFields(true)
+    if err := dec.Decode(v); err != nil {
+        return err
+    }
+    if r.Len() > 0 {
+        return fmt.Errorf("unexpected data after the value at offset %d", len(data)-r.Len())
+    }
+    return nil
+}
+
+var (
+    cborEnc, _ = cbor.EncOptions{
+        Time:          cbor.TimeRFC3339Nano,
+        TimeTag:       cbor.EncTagRequired,
+        TextMarshaler: cbor.TextMarshalerTextString,
+    }.EncMode()
+    cborDec, _ = cbor.DecOptions{
+        DupMapKey:         cbor.DupMapKeyEnforcedAPF,
+        ExtraReturnErrors: cbor.ExtraDecErrorUnknownField,
+        TextUnmarshaler:   cbor.TextUnmarshalerTextString,
+    }.DecMode()
+)
+
+type cborCodec struct{}
+
+func (cborCodec) MediaType() string { return CBORMediaType }
+
+func (cborCodec) Marshal(v interface{}) ([]byte, error) { return cborEnc.Marshal(v) }
+
+// Unmarshal rejects trailing data itself: cbor reports ExtraneousDataError.
+func (cborCodec) Unmarshal(data []byte, v interface{}) error { return cborDec.Unmarshal(data, v) }
+
+type codecWriter struct {
+    http.ResponseWriter
+    codec Codec
+}
+
+func (w *codecWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// WithCodec records the negotiated response codec on w for FromWriter.
+func WithCodec(w http.ResponseWriter, c Codec) http.ResponseWriter {
+    return &codecWriter{ResponseWriter: w, codec: c}
+}
+
+// FromWriter returns the codec recorded on w or on a writer it wraps, JSON if
+// there is none. Wrappers must expose Unwrap like http.ResponseController
+// expects.
+func FromWriter(w http.ResponseWriter) Codec {
+    for {
+        if cw, ok := w.(*codecWriter); ok {
+            return cw.codec
+        }
+        u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+        if !ok {
+            return JSON
+        }
+        w = u.Unwrap()
+    }
+}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"log"
-	"os"
+	"project/internal/secrets"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,71 +18,480 @@ func loadEnv() {
 		log.Println("No .env file found, using system environment variables")
 	}
 }
+
+// Config is the application configuration. It is built once at startup by
+// Load and passed to the components that need it. Env is the profile chosen
+// by APP_ENV and is not read from files. Sections tagged reload:"true" can
+// change while running; see Store.
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-    Mongo    MongoConfig
+    Env         string            `yaml:"-" toml:"-"`
+    Server      ServerConfig      `yaml:"server" toml:"server"`
+    Database    DatabaseConfig    `yaml:"database" toml:"database"`
+    JWT         JWTConfig         `yaml:"jwt" toml:"jwt"`
+    Mongo       MongoConfig       `yaml:"mongo" toml:"mongo"`
+    OAuth       OAuthConfig       `yaml:"oauth" toml:"oauth"`
+    OAuthServer OAuthServerConfig `yaml:"oauth_server" toml:"oauth_server"`
+    Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+    Tenancy     TenancyConfig     `yaml:"tenancy" toml:"tenancy"`
+    Admin       AdminConfig       `yaml:"admin" toml:"admin"`
+    Secrets     SecretsConfig     `yaml:"secrets" toml:"secrets"`
+    Log         LogConfig         `yaml:"log" toml:"log" reload:"true"`
+    RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit" reload:"true"`
+    CORS        CORSConfig        `yaml:"cors" toml:"cors" reload:"true"`
+    Security    SecurityConfig    `yaml:"security_headers" toml:"security_headers" reload:"true"`
+    ErrorSink   ErrorSinkConfig   `yaml:"error_sink" toml:"error_sink"`
+    Compression CompressionConfig `yaml:"compression" toml:"compression" reload:"true"`
+    API         APIConfig         `yaml:"api" toml:"api"`
+}
+
+// Profiles selected by APP_ENV. Production refuses insecure settings.
+const (
+    EnvDevelopment = "development"
+    EnvTest        = "test"
+    EnvProduction  = "production"
+)
+
+// IsProduction reports whether the production profile is active.
+func (c *Config) IsProduction() bool {
+    return c.Env == EnvProduction
 }
 
 type ServerConfig struct {
-	Port string
+	Port string `yaml:"port" toml:"port"`
+	// RequireIfMatch rejects user writes without If-Match (428)
+	RequireIfMatch bool `yaml:"require_if_match" toml:"require_if_match"`
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	Name     string
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Name     string `yaml:"name" toml:"name"`
 }
 
 type MongoConfig struct {
-    URI    string
-    DBName string
+    URI    string `yaml:"uri" toml:"uri"`
+    DBName string `yaml:"db" toml:"db"`
 }
 
 type JWTConfig struct {
-	Secret string
-	Expiry int
+	Secret string `yaml:"secret" toml:"secret"`
+	Expiry int    `yaml:"expiry_hours" toml:"expiry_hours"`
 }
 
-func LoadConfig() *Config {
-	loadEnv()
-	return &Config{
-		Server: ServerConfig{
-			Port: getEnv("PORT", "8090"),
-		},
-		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "3306"),
-			User:     getEnv("DB_USER", "angazny"),
-			Password: getEnv("DB_PASSWORD", "Angazny@123"),
-			Name:     getEnv("DB_NAME", "angazny"),
-		},
-		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "secret"),
-			Expiry: getEnvAsInt("JWT_EXPIRY", 24),
-		},
+// OAuthConfig holds the external identity providers users may sign in with,
+// keyed by the name used in /auth/oauth/{provider}/... routes.
+type OAuthConfig struct {
+    RedirectBaseURL string                         `yaml:"redirect_base_url" toml:"redirect_base_url"`
+    Providers       map[string]OAuthProviderConfig `yaml:"providers" toml:"providers"`
+}
+
+// OAuthServerConfig configures this service acting as an OAuth2/OIDC provider.
+type OAuthServerConfig struct {
+    Issuer             string `yaml:"issuer" toml:"issuer"`
+    AccessTokenMinutes int    `yaml:"access_token_minutes" toml:"access_token_minutes"`
+    RefreshTokenDays   int    `yaml:"refresh_token_days" toml:"refresh_token_days"`
+}
+
+// IdempotencyConfig controls how long Idempotency-Key responses are replayed.
+type IdempotencyConfig struct {
+    TTLHours int `yaml:"ttl_hours" toml:"ttl_hours"`
+}
+
+// TenancyConfig controls how requests are mapped to organizations. With a
+// BaseDomain, acme.<BaseDomain> resolves to the organization with slug acme.
+type TenancyConfig struct {
+    BaseDomain string `yaml:"base_domain" toml:"base_domain"`
+}
+
+// AdminConfig bootstraps administration. At startup the user of the default
+// tenant with BootstrapEmail gets the admin role, so there is a first admin to
+// grant it to others; the account must exist, e.g. from cmd/import.
+type AdminConfig struct {
+    BootstrapEmail string `yaml:"bootstrap_email" toml:"bootstrap_email"`
+}
+
+// SecretsConfig selects where credentials such as JWT_SECRET and MONGO_URI
+// are read from: "env" (the default, honouring *_FILE), "file" (one file per
+// secret in Dir) or "vault". With RefreshSeconds, rotated values are picked up
+// while running.
+type SecretsConfig struct {
+    Provider       string      `yaml:"provider" toml:"provider"`
+    Dir            string      `yaml:"dir" toml:"dir"`
+    RefreshSeconds int         `yaml:"refresh_seconds" toml:"refresh_seconds"`
+    Vault          VaultConfig `yaml:"vault" toml:"vault"`
+}
+
+// VaultConfig points at a key/value v2 secret on a Vault compatible server.
+// Prefer VAULT_TOKEN or VAULT_TOKEN_FILE over putting Token in a file.
+type VaultConfig struct {
+    Addr  string `yaml:"addr" toml:"addr"`
+    Token string `yaml:"token" toml:"token"`
+    Mount string `yaml:"mount" toml:"mount"`
+    Path  string `yaml:"path" toml:"path"`
+}
+
+// LogConfig sets the minimum level logged: debug, info, warn or error.
+type LogConfig struct {
+    Level string `yaml:"level" toml:"level"`
+}
+
+// RateLimitConfig limits requests per client IP with a token bucket that
+// refills RequestsPerMinute tokens a minute and holds up to Burst. Zero
+// RequestsPerMinute disables limiting.
+type RateLimitConfig struct {
+    RequestsPerMinute int `yaml:"requests_per_minute" toml:"requests_per_minute"`
+    Burst             int `yaml:"burst" toml:"burst"`
+}
+
+// CORSConfig lets browser apps on other origins call the API. The top-level
+// policy applies to its AllowedOrigins; each of Policies covers its own
+// origins instead, taking the top-level methods, headers and max age where it
+// leaves them empty. Origins are exact ("https://app.example.com"), subdomain
+// wildcards ("https://*.example.com") or "*" for any origin.
+type CORSConfig struct {
+    CORSPolicy `yaml:",inline"`
+    Policies   []CORSPolicy `yaml:"policies" toml:"policies"`
+}
+
+type CORSPolicy struct {
+    AllowedOrigins   []string `yaml:"allowed_origins" toml:"allowed_origins"`
+    AllowedMethods   []string `yaml:"allowed_methods" toml:"allowed_methods"`
+    AllowedHeaders   []string `yaml:"allowed_headers" toml:"allowed_headers"`
+    ExposedHeaders   []string `yaml:"exposed_headers" toml:"exposed_headers"`
+    AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials"`
+    MaxAgeSeconds    int      `yaml:"max_age_seconds" toml:"max_age_seconds"`
+}
+
+// SecurityConfig sets the security headers of every response. Routes, keyed
+// by route path template such as "/users/{id}/avatar" (which also covers
+// "/api/v2/users/{id}/avatar"), override single headers; empty values inherit
+// and "off" omits the header.
+type SecurityConfig struct {
+    SecurityHeaders `yaml:",inline"`
+    Routes          map[string]SecurityHeaders `yaml:"routes" toml:"routes"`
+}
+
+// SecurityHeaders are the values of the security headers. HSTS is only sent
+// over HTTPS.
+type SecurityHeaders struct {
+    HSTS                  string `yaml:"hsts" toml:"hsts"`
+    ContentSecurityPolicy string `yaml:"content_security_policy" toml:"content_security_policy"`
+    ContentTypeOptions    string `yaml:"content_type_options" toml:"content_type_options"`
+    ReferrerPolicy        string `yaml:"referrer_policy" toml:"referrer_policy"`
+    FrameOptions          string `yaml:"frame_options" toml:"frame_options"`
+}
+
+// ErrorSinkConfig forwards recovered panics to a collector; WebhookURL
+// receives each one as a JSON POST.
+type ErrorSinkConfig struct {
+    WebhookURL string `yaml:"webhook_url" toml:"webhook_url"`
+}
+
+// CompressionConfig controls response compression. Encodings are the content
+// codings offered, most preferred first: zstd, br, gzip and deflate; an empty
+// list disables compression. Responses shorter than MinBytes or of a type not
+// in ContentTypes ("type/subtype" or "type/*") are sent as is.
+type CompressionConfig struct {
+    Encodings    []string `yaml:"encodings" toml:"encodings"`
+    MinBytes     int      `yaml:"min_bytes" toml:"min_bytes"`
+    ContentTypes []string `yaml:"content_types" toml:"content_types"`
+}
+
+// APIConfig controls the route trees served under /api/{version}.
+// LegacyRoutes also serves the API without the prefix, as before versioning,
+// in the version the Accept header asks for ("application/json; version=2")
+// or DefaultVersion. Deprecations are keyed by version.
+type APIConfig struct {
+    LegacyRoutes   bool                      `yaml:"legacy_routes" toml:"legacy_routes"`
+    DefaultVersion string                    `yaml:"default_version" toml:"default_version"`
+    Deprecations   map[string]APIDeprecation `yaml:"deprecations" toml:"deprecations"`
+}
+
+// APIDeprecation announces that a version is deprecated. Date and Sunset are
+// YYYY-MM-DD or RFC 3339; Sunset, when the version goes away, and Link, a
+// migration guide, are optional.
+type APIDeprecation struct {
+    Date   string `yaml:"date" toml:"date"`
+    Sunset string `yaml:"sunset" toml:"sunset"`
+    Link   string `yaml:"link" toml:"link"`
+}
+
+// Times parses Date and Sunset; sunset is zero when not set.
+func (d APIDeprecation) Times() (deprecated, sunset time.Time, err error) {
+    if deprecated, err = parseDate(d.Date); err != nil {
+        return
+    }
+    if d.Sunset != "" {
+        sunset, err = parseDate(d.Sunset)
+    }
+    return
+}
+
+func parseDate(s string) (time.Time, error) {
+    if t, err := time.Parse("2006-01-02", s); err == nil {
+        return t, nil
+    }
+    return time.Parse(time.RFC3339, s)
+}
+
+type OAuthProviderConfig struct {
+    Kind         string   `yaml:"kind" toml:"kind"` // "oidc" or "github"
+    ClientID     string   `yaml:"client_id" toml:"client_id"`
+    ClientSecret string   `yaml:"client_secret" toml:"client_secret"`
+    Issuer       string   `yaml:"issuer" toml:"issuer"` // OIDC: endpoints are discovered from the issuer
+    AuthURL      string   `yaml:"auth_url" toml:"auth_url"` // explicit endpoints take precedence over discovery
+    TokenURL     string   `yaml:"token_url" toml:"token_url"`
+    UserInfoURL  string   `yaml:"user_info_url" toml:"user_info_url"`
+    Scopes       []string `yaml:"scopes" toml:"scopes"`
+}
+
+// Defaults returns the built-in configuration, the lowest layer of Load. The
+// JWT secret is a development placeholder that Validate refuses in production.
+func Defaults() *Config {
+    return &Config{
+        Env: EnvDevelopment,
+        Server: ServerConfig{
+            Port: "8090",
+        },
+        Database: DatabaseConfig{
+            Host: "localhost",
+            Port: "3306",
+            User: "angazny",
+            Name: "angazny",
+        },
+        JWT: JWTConfig{
+            Secret: "secret",
+            Expiry: 24,
+        },
         Mongo: MongoConfig{
-            URI:    getEnv("MONGO_URI", "mongodb://localhost:27017"),
-            DBName: getEnv("MONGO_DB", "appdb"),
+            URI:    "mongodb://localhost:27017",
+            DBName: "appdb",
         },
-	}
+        OAuth: OAuthConfig{Providers: map[string]OAuthProviderConfig{}},
+        OAuthServer: OAuthServerConfig{
+            AccessTokenMinutes: 60,
+            RefreshTokenDays:   30,
+        },
+        Idempotency: IdempotencyConfig{
+            TTLHours: 24,
+        },
+        Secrets: SecretsConfig{
+            Provider: SecretsEnv,
+        },
+        Log: LogConfig{
+            Level: "info",
+        },
+        CORS: CORSConfig{CORSPolicy: CORSPolicy{
+            AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+            AllowedHeaders: []string{"Authorization", "Content-Type", "X-API-Key", "X-Tenant-ID", "Idempotency-Key", "If-Match", "If-None-Match", "Content-Encoding"},
+            ExposedHeaders: []string{"ETag", "Location", "Idempotent-Replayed", "Retry-After", "X-Request-ID", "Deprecation", "Sunset", "Link"},
+            MaxAgeSeconds:  600,
+        }},
+        Security: SecurityConfig{SecurityHeaders: SecurityHeaders{
+            HSTS:                  "max-age=31536000; includeSubDomains",
+            ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+            ContentTypeOptions:    "nosniff",
+            ReferrerPolicy:        "no-referrer",
+            FrameOptions:          "DENY",
+        }, Routes: map[string]SecurityHeaders{
+            // The reference page is static HTML with an inline stylesheet
+            "/docs": {ContentSecurityPolicy: "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'"},
+        }},
+        Compression: CompressionConfig{
+            Encodings:    []string{"zstd", "br", "gzip", "deflate"},
+            MinBytes:     1024,
+            ContentTypes: []string{"application/json", "application/msgpack", "application/cbor", "application/xml", "text/*"},
+        },
+        API: APIConfig{
+            LegacyRoutes:   true,
+            DefaultVersion: "v1",
+        },
+    }
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
+// LoadConfig builds the configuration from the defaults and the environment
+// only, without files, flags or validation. Binaries use Load; this remains
+// for tests and tools that only need the environment.
+func LoadConfig() *Config {
+	loadEnv()
+	cfg := Defaults()
+	applyEnv(cfg)
+	cfg.finish()
+	return cfg
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
-	}
-	return defaultValue
-}
\ No newline at end of file
+// applyEnv overrides cfg with the environment variables that are set; each
+// may also be read from the file named by its *_FILE variant. Malformed
+// numbers and booleans are returned as errors.
+func applyEnv(cfg *Config) []string {
+    e := &envReader{}
+    e.str(&cfg.Env, "APP_ENV")
+    e.str(&cfg.Server.Port, "PORT")
+    e.boolean(&cfg.Server.RequireIfMatch, "REQUIRE_IF_MATCH")
+    e.str(&cfg.Database.Host, "DB_HOST")
+    e.str(&cfg.Database.Port, "DB_PORT")
+    e.str(&cfg.Database.User, "DB_USER")
+    e.str(&cfg.Database.Password, "DB_PASSWORD")
+    e.str(&cfg.Database.Name, "DB_NAME")
+    e.str(&cfg.JWT.Secret, "JWT_SECRET")
+    e.integer(&cfg.JWT.Expiry, "JWT_EXPIRY")
+    e.str(&cfg.Mongo.URI, "MONGO_URI")
+    e.str(&cfg.Mongo.DBName, "MONGO_DB")
+    e.str(&cfg.OAuth.RedirectBaseURL, "OAUTH_REDIRECT_BASE_URL")
+    e.str(&cfg.OAuthServer.Issuer, "OAUTH_ISSUER")
+    e.integer(&cfg.OAuthServer.AccessTokenMinutes, "OAUTH_ACCESS_TOKEN_MINUTES")
+    e.integer(&cfg.OAuthServer.RefreshTokenDays, "OAUTH_REFRESH_TOKEN_DAYS")
+    e.integer(&cfg.Idempotency.TTLHours, "IDEMPOTENCY_TTL_HOURS")
+    e.str(&cfg.Tenancy.BaseDomain, "TENANT_BASE_DOMAIN")
+    e.str(&cfg.Admin.BootstrapEmail, "BOOTSTRAP_ADMIN_EMAIL")
+    e.str(&cfg.Secrets.Provider, "SECRETS_PROVIDER")
+    e.str(&cfg.Secrets.Dir, "SECRETS_DIR")
+    e.integer(&cfg.Secrets.RefreshSeconds, "SECRETS_REFRESH_SECONDS")
+    e.str(&cfg.Secrets.Vault.Addr, "VAULT_ADDR")
+    e.str(&cfg.Secrets.Vault.Token, "VAULT_TOKEN")
+    e.str(&cfg.Secrets.Vault.Mount, "VAULT_MOUNT")
+    e.str(&cfg.Secrets.Vault.Path, "VAULT_PATH")
+    e.str(&cfg.Log.Level, "LOG_LEVEL")
+    e.integer(&cfg.RateLimit.RequestsPerMinute, "RATE_LIMIT_PER_MINUTE")
+    e.integer(&cfg.RateLimit.Burst, "RATE_LIMIT_BURST")
+    e.list(&cfg.CORS.AllowedOrigins, "CORS_ALLOWED_ORIGINS")
+    e.boolean(&cfg.CORS.AllowCredentials, "CORS_ALLOW_CREDENTIALS")
+    e.integer(&cfg.CORS.MaxAgeSeconds, "CORS_MAX_AGE_SECONDS")
+    e.str(&cfg.Security.HSTS, "SECURITY_HSTS")
+    e.str(&cfg.Security.ContentSecurityPolicy, "SECURITY_CSP")
+    e.str(&cfg.ErrorSink.WebhookURL, "ERROR_SINK_WEBHOOK_URL")
+    e.list(&cfg.Compression.Encodings, "COMPRESSION_ENCODINGS")
+    e.integer(&cfg.Compression.MinBytes, "COMPRESSION_MIN_BYTES")
+    e.boolean(&cfg.API.LegacyRoutes, "API_LEGACY_ROUTES")
+    e.str(&cfg.API.DefaultVersion, "API_DEFAULT_VERSION")
+    if cfg.OAuth.Providers == nil {
+        cfg.OAuth.Providers = map[string]OAuthProviderConfig{}
+    }
+    for name, p := range e.oauthProviders() {
+        cfg.OAuth.Providers[name] = p
+    }
+    return e.errs
+}
+
+// finish normalizes values and fills settings derived from others.
+func (c *Config) finish() {
+    c.Env = strings.ToLower(strings.TrimSpace(c.Env))
+    if c.Env == "" {
+        c.Env = EnvDevelopment
+    }
+    local := "http://localhost:" + c.Server.Port
+    if c.OAuthServer.Issuer == "" {
+        c.OAuthServer.Issuer = local
+    }
+    if c.OAuth.RedirectBaseURL == "" {
+        c.OAuth.RedirectBaseURL = local
+    }
+    c.OAuthServer.Issuer = strings.TrimRight(c.OAuthServer.Issuer, "/")
+    c.OAuth.RedirectBaseURL = strings.TrimRight(c.OAuth.RedirectBaseURL, "/")
+    c.Tenancy.BaseDomain = strings.ToLower(c.Tenancy.BaseDomain)
+    c.Admin.BootstrapEmail = strings.ToLower(strings.TrimSpace(c.Admin.BootstrapEmail))
+    c.Log.Level = strings.ToLower(c.Log.Level)
+    c.API.DefaultVersion = strings.ToLower(c.API.DefaultVersion)
+    for i, enc := range c.Compression.Encodings {
+        c.Compression.Encodings[i] = strings.ToLower(strings.TrimSpace(enc))
+    }
+    if c.RateLimit.RequestsPerMinute > 0 && c.RateLimit.Burst == 0 {
+        c.RateLimit.Burst = c.RateLimit.RequestsPerMinute
+    }
+}
+
+// oauthProviders enables each provider only when its client ID is set.
+func (e *envReader) oauthProviders() map[string]OAuthProviderConfig {
+    providers := map[string]OAuthProviderConfig{}
+    if id := e.get("OAUTH_GOOGLE_CLIENT_ID", ""); id != "" {
+        providers["google"] = OAuthProviderConfig{
+            Kind:         "oidc",
+            ClientID:     id,
+            ClientSecret: e.get("OAUTH_GOOGLE_CLIENT_SECRET", ""),
+            Issuer:       "https://accounts.google.com",
+            Scopes:       []string{"openid", "email", "profile"},
+        }
+    }
+    if id := e.get("OAUTH_GITHUB_CLIENT_ID", ""); id != "" {
+        providers["github"] = OAuthProviderConfig{
+            Kind:         "github",
+            ClientID:     id,
+            ClientSecret: e.get("OAUTH_GITHUB_CLIENT_SECRET", ""),
+            AuthURL:      "https://github.com/login/oauth/authorize",
+            TokenURL:     "https://github.com/login/oauth/access_token",
+            UserInfoURL:  "https://api.github.com/user",
+            Scopes:       []string{"read:user", "user:email"},
+        }
+    }
+    if id := e.get("OAUTH_OIDC_CLIENT_ID", ""); id != "" {
+        providers[e.get("OAUTH_OIDC_NAME", "oidc")] = OAuthProviderConfig{
+            Kind:         "oidc",
+            ClientID:     id,
+            ClientSecret: e.get("OAUTH_OIDC_CLIENT_SECRET", ""),
+            Issuer:       e.get("OAUTH_OIDC_ISSUER", ""),
+            Scopes:       strings.Fields(e.get("OAUTH_OIDC_SCOPES", "openid email profile")),
+        }
+    }
+    return providers
+}
+
+// envReader assigns set environment variables to config fields and collects
+// the ones that do not parse.
+type envReader struct {
+    errs []string
+}
+
+// get returns the value of key, or defaultValue when it is not set.
+func (e *envReader) get(key, defaultValue string) string {
+    value, ok, err := secrets.LookupEnv(key)
+    if err != nil {
+        e.errs = append(e.errs, err.Error())
+    }
+    if !ok {
+        return defaultValue
+    }
+    return value
+}
+
+func (e *envReader) str(dst *string, key string) {
+    if value := e.get(key, ""); value != "" {
+        *dst = value
+    }
+}
+
+// list reads a comma-separated list.
+func (e *envReader) list(dst *[]string, key string) {
+    if value := e.get(key, ""); value != "" {
+        items := []string{}
+        for _, item := range strings.Split(value, ",") {
+            if item = strings.TrimSpace(item); item != "" {
+                items = append(items, item)
+            }
+        }
+        *dst = items
+    }
+}
+
+func (e *envReader) integer(dst *int, key string) {
+    if value := e.get(key, ""); value != "" {
+        intValue, err := strconv.Atoi(value)
+        if err != nil {
+            e.errs = append(e.errs, key+" must be an integer")
+            return
+        }
+        *dst = intValue
+    }
+}
+
+func (e *envReader) boolean(dst *bool, key string) {
+    if value := e.get(key, ""); value != "" {
+        boolValue, err := strconv.ParseBool(value)
+        if err != nil {
+            e.errs = append(e.errs, key+" must be true or false")
+            return
+        }
+        *dst = boolValue
+    }
+}
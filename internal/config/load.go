@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Flags are the command-line overrides shared by the binaries, the highest
+// layer of Load. Empty values leave the lower layers alone.
+type Flags struct {
+    ConfigFile string
+    Env        string
+    Port       string
+    MongoURI   string
+    MongoDB    string
+}
+
+// BindFlags registers the configuration flags on fs; parse fs before Load.
+func BindFlags(fs *flag.FlagSet) *Flags {
+    f := &Flags{}
+    fs.StringVar(&f.ConfigFile, "config", "", "configuration file, .yaml/.yml or .toml (default: $CONFIG_FILE)")
+    fs.StringVar(&f.Env, "env", "", "profile: development, test or production (default: $APP_ENV)")
+    fs.StringVar(&f.Port, "port", "", "HTTP port (default: $PORT)")
+    fs.StringVar(&f.MongoURI, "mongo-uri", "", "MongoDB connection string (default: $MONGO_URI)")
+    fs.StringVar(&f.MongoDB, "mongo-db", "", "MongoDB database name (default: $MONGO_DB)")
+    return f
+}
+
+// Load builds the configuration from, lowest first: the defaults, the config
+// file, its profile file for APP_ENV, environment variables, the secret
+// provider and flags. flags may be nil. The result is validated; a non-nil
+// error lists every problem.
+//
+// The profile file sits next to the config file with the profile before the
+// extension, e.g. config.production.yaml for config.yaml. It is optional.
+func Load(flags *Flags) (*Config, error) {
+    if flags == nil {
+        flags = &Flags{}
+    }
+    loadEnv()
+    cfg := Defaults()
+
+    // The profile decides which file layers apply, so resolve it first
+    env := firstNonEmpty(flags.Env, os.Getenv("APP_ENV"), EnvDevelopment)
+    env = strings.ToLower(strings.TrimSpace(env))
+    if path := flags.configPath(); path != "" {
+        if err := decodeFile(path, cfg, true); err != nil {
+            return nil, err
+        }
+        if err := decodeFile(profilePath(path, env), cfg, false); err != nil {
+            return nil, err
+        }
+    }
+
+    errs := applyEnv(cfg)
+    if len(errs) > 0 {
+        return nil, fmt.Errorf("invalid configuration: %s", strings.Join(errs, "; "))
+    }
+    // The environment layer already read env secrets, including *_FILE
+    if p := cfg.Secrets.Provider; p != "" && p != SecretsEnv {
+        if err := loadSecrets(cfg); err != nil {
+            return nil, err
+        }
+    }
+    cfg.Env = env
+    applyFlags(cfg, flags)
+    cfg.finish()
+    if err := cfg.Validate(); err != nil {
+        return nil, err
+    }
+    return cfg, nil
+}
+
+func loadSecrets(cfg *Config) error {
+    if problems := cfg.Secrets.problems(); len(problems) > 0 {
+        return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+    }
+    provider, err := cfg.SecretProvider()
+    if err != nil {
+        return err
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    return cfg.ApplySecrets(ctx, provider)
+}
+
+// configPath returns the config file named by -config or CONFIG_FILE.
+func (f *Flags) configPath() string {
+    return firstNonEmpty(f.ConfigFile, os.Getenv("CONFIG_FILE"))
+}
+
+func applyFlags(cfg *Config, f *Flags) {
+    set := func(dst *string, value string) {
+        if value != "" {
+            *dst = value
+        }
+    }
+    set(&cfg.Server.Port, f.Port)
+    set(&cfg.Mongo.URI, f.MongoURI)
+    set(&cfg.Mongo.DBName, f.MongoDB)
+}
+
+// profilePath returns the profile file for path, e.g. conf/app.production.toml
+// for conf/app.toml.
+func profilePath(path, env string) string {
+    ext := filepath.Ext(path)
+    return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+// decodeFile merges the file at path into cfg; keys the file does not set
+// keep their current values. Unknown keys are rejected so typos surface.
+func decodeFile(path string, cfg *Config, required bool) error {
+    data, err := os.ReadFile(path)
+    if errors.Is(err, os.ErrNotExist) && !required {
+        return nil
+    }
+    if err != nil {
+        return fmt.Errorf("config file: %w", err)
+    }
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".yaml", ".yml":
+        dec := yaml.NewDecoder(bytes.NewReader(data))
+        dec.KnownFields(true)
+        err = dec.Decode(cfg)
+        if errors.Is(err, io.EOF) {
+            err = nil // empty file
+        }
+    case ".toml":
+        dec := toml.NewDecoder(bytes.NewReader(data))
+        dec.DisallowUnknownFields()
+        err = dec.Decode(cfg)
+    default:
+        return fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+    }
+    if err != nil {
+        return fmt.Errorf("config file %s: %w", path, err)
+    }
+    return nil
+}
+
+func firstNonEmpty(values ...string) string {
+    for _, v := range values {
+        if v != "" {
+            return v
+        }
+    }
+    return ""
+}
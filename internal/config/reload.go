@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce groups the several events editors and config management
+// tools produce for one change of a file.
+const reloadDebounce = 250 * time.Millisecond
+
+// Store holds the running configuration. Reload re-reads it and swaps in the
+// sections tagged reload:"true"; the other sections keep their startup values
+// until the process restarts.
+type Store struct {
+    flags   *Flags
+    current atomic.Pointer[Config]
+
+    mu          sync.Mutex // serializes reloads and subscriptions
+    subscribers []func(*Config)
+}
+
+// NewStore starts from cfg, which was loaded with flags.
+func NewStore(cfg *Config, flags *Flags) *Store {
+    if flags == nil {
+        flags = &Flags{}
+    }
+    s := &Store{flags: flags}
+    s.current.Store(cfg)
+    return s
+}
+
+// Current returns the configuration in effect. It must not be modified.
+func (s *Store) Current() *Config {
+    return s.current.Load()
+}
+
+// Subscribe calls fn with the current configuration now and with the new one
+// after every successful reload.
+func (s *Store) Subscribe(fn func(*Config)) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.subscribers = append(s.subscribers, fn)
+    fn(s.Current())
+}
+
+// Reload loads the configuration again. An invalid configuration is rejected
+// and the current one kept.
+func (s *Store) Reload() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    loaded, err := Load(s.flags)
+    if err != nil {
+        return err
+    }
+    current := s.Current()
+    next := *current
+    for _, name := range reloadableChanges(&next, loaded) {
+        log.Printf("config: %s changed; restart to apply it", name)
+    }
+    s.current.Store(&next)
+    for _, fn := range s.subscribers {
+        fn(&next)
+    }
+    return nil
+}
+
+// reloadableChanges copies the reloadable sections of src into dst and
+// returns the names of the other sections that differ.
+func reloadableChanges(dst, src *Config) []string {
+    var fixed []string
+    dv, sv := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+    for i := 0; i < dv.NumField(); i++ {
+        field := dv.Type().Field(i)
+        if field.Tag.Get("reload") == "true" {
+            dv.Field(i).Set(sv.Field(i))
+        } else if !reflect.DeepEqual(dv.Field(i).Interface(), sv.Field(i).Interface()) {
+            fixed = append(fixed, field.Name)
+        }
+    }
+    return fixed
+}
+
+// Watch reloads on SIGHUP and when the config file or its profile file
+// changes, until ctx is done. Failed reloads are logged.
+func (s *Store) Watch(ctx context.Context) error {
+    hup := make(chan os.Signal, 1)
+    signal.Notify(hup, syscall.SIGHUP)
+    defer signal.Stop(hup)
+
+    var events <-chan fsnotify.Event
+    var watchErrs <-chan error
+    files := map[string]bool{}
+    if path := s.flags.configPath(); path != "" {
+        watcher, err := fsnotify.NewWatcher()
+        if err != nil {
+            return err
+        }
+        defer watcher.Close()
+        // Watch the directory: editors and Kubernetes replace files rather
+        // than write them in place
+        if err := watcher.Add(filepath.Dir(path)); err != nil {
+            return err
+        }
+        files[filepath.Clean(path)] = true
+        files[filepath.Clean(profilePath(path, s.Current().Env))] = true
+        events, watchErrs = watcher.Events, watcher.Errors
+    }
+
+    var debounce <-chan time.Time
+    reload := func(reason string) {
+        if err := s.Reload(); err != nil {
+            log.Printf("config: reload on %s rejected, keeping the current configuration: %v", reason, err)
+            return
+        }
+        log.Printf("config: reloaded on %s", reason)
+    }
+    for {
+        select {
+        case <-ctx.Done():
+            return nil
+        case <-hup:
+            reload("SIGHUP")
+        case event := <-events:
+            if files[filepath.Clean(event.Name)] && !event.Has(fsnotify.Chmod) {
+                debounce = time.After(reloadDebounce)
+            }
+        case err := <-watchErrs:
+            log.Printf("config: watching files: %v", err)
+        case <-debounce:
+            debounce = nil
+            reload("file change")
+        }
+    }
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"project/internal/secrets"
+	"sort"
+	"strings"
+)
+
+// Secret providers for SecretsConfig.Provider.
+const (
+    SecretsEnv   = "env"
+    SecretsFile  = "file"
+    SecretsVault = "vault"
+)
+
+// SecretProvider returns the provider selected by c.Secrets.
+func (c *Config) SecretProvider() (secrets.Provider, error) {
+    switch c.Secrets.Provider {
+    case "", SecretsEnv:
+        return secrets.EnvProvider{}, nil
+    case SecretsFile:
+        return secrets.FileProvider{Dir: c.Secrets.Dir}, nil
+    case SecretsVault:
+        v := c.Secrets.Vault
+        return &secrets.VaultProvider{Addr: v.Addr, Token: v.Token, Mount: v.Mount, Path: v.Path}, nil
+    }
+    return nil, fmt.Errorf("unknown secrets provider %q", c.Secrets.Provider)
+}
+
+// secretField reads and writes one secret setting.
+type secretField struct {
+    get func() string
+    set func(string)
+}
+
+func stringField(dst *string) secretField {
+    return secretField{get: func() string { return *dst }, set: func(v string) { *dst = v }}
+}
+
+// secretFields maps the name of each secret setting to its field. OAuth
+// client secrets are named OAUTH_<PROVIDER>_CLIENT_SECRET.
+func (c *Config) secretFields() map[string]secretField {
+    fields := map[string]secretField{
+        "JWT_SECRET":  stringField(&c.JWT.Secret),
+        "DB_PASSWORD": stringField(&c.Database.Password),
+        "MONGO_URI":   stringField(&c.Mongo.URI),
+    }
+    for name := range c.OAuth.Providers {
+        name := name
+        key := "OAUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_CLIENT_SECRET"
+        fields[key] = secretField{
+            get: func() string { return c.OAuth.Providers[name].ClientSecret },
+            set: func(v string) {
+                p := c.OAuth.Providers[name]
+                p.ClientSecret = v
+                c.OAuth.Providers[name] = p
+            },
+        }
+    }
+    return fields
+}
+
+// SecretValues returns the current value of every secret setting by name.
+func (c *Config) SecretValues() map[string]string {
+    values := map[string]string{}
+    for name, field := range c.secretFields() {
+        values[name] = field.get()
+    }
+    return values
+}
+
+// ApplySecrets overrides the secret settings the provider holds. Secrets it
+// does not hold keep their value from the lower layers.
+func (c *Config) ApplySecrets(ctx context.Context, provider secrets.Provider) error {
+    fields := c.secretFields()
+    names := make([]string, 0, len(fields))
+    for name := range fields {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    var problems []string
+    for _, name := range names {
+        value, err := provider.Secret(ctx, name)
+        if errors.Is(err, secrets.ErrNotFound) {
+            continue
+        }
+        if err != nil {
+            problems = append(problems, name+": "+err.Error())
+            continue
+        }
+        fields[name].set(value)
+    }
+    if len(problems) > 0 {
+        return errors.New("loading secrets: " + strings.Join(problems, "; "))
+    }
+    return nil
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"errors"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// minProductionSecretLength is the shortest JWT secret accepted in production
+// (256 bits of HMAC key for HS256).
+const minProductionSecretLength = 32
+
+// insecureSecrets are placeholders from defaults, examples and old releases.
+var insecureSecrets = map[string]bool{
+    "":                      true,
+    "secret":                true,
+    "changeme":              true,
+    "change-me":             true,
+    "your-secret-key":       true,
+    "your-super-secret-key": true,
+    "Angazny@123":           true,
+}
+
+var apiVersionPattern = regexp.MustCompile(`^v[1-9][0-9]*$`)
+
+var compressionEncodings = map[string]bool{"zstd": true, "br": true, "gzip": true, "deflate": true}
+
+// Validate checks the configuration and returns every problem found. The
+// production profile additionally refuses placeholder secrets.
+func (c *Config) Validate() error {
+    var problems []string
+    add := func(msg string) { problems = append(problems, msg) }
+
+    switch c.Env {
+    case EnvDevelopment, EnvTest, EnvProduction:
+    default:
+        add("APP_ENV must be development, test or production, got " + strconv.Quote(c.Env))
+    }
+    if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+        add("server.port must be a number between 1 and 65535")
+    }
+    if c.JWT.Expiry <= 0 {
+        add("jwt.expiry_hours must be positive")
+    }
+    if c.Mongo.URI == "" {
+        add("mongo.uri is required")
+    }
+    if c.Mongo.DBName == "" {
+        add("mongo.db is required")
+    }
+    if e := c.Admin.BootstrapEmail; e != "" && (strings.Count(e, "@") != 1 || strings.HasPrefix(e, "@") || strings.HasSuffix(e, "@")) {
+        add("admin.bootstrap_email must be an email address")
+    }
+    if c.OAuthServer.AccessTokenMinutes <= 0 {
+        add("oauth_server.access_token_minutes must be positive")
+    }
+    if c.OAuthServer.RefreshTokenDays <= 0 {
+        add("oauth_server.refresh_token_days must be positive")
+    }
+    if c.Idempotency.TTLHours <= 0 {
+        add("idempotency.ttl_hours must be positive")
+    }
+    problems = append(problems, c.Secrets.problems()...)
+    var level slog.Level
+    if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+        add("log.level must be debug, info, warn or error")
+    }
+    if c.RateLimit.RequestsPerMinute < 0 || c.RateLimit.Burst < 0 {
+        add("rate_limit values must not be negative")
+    }
+    problems = append(problems, corsProblems("cors", c.CORS.CORSPolicy)...)
+    if u := c.ErrorSink.WebhookURL; u != "" {
+        if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+            add("error_sink.webhook_url must be an http(s) URL")
+        }
+    }
+    for _, enc := range c.Compression.Encodings {
+        if !compressionEncodings[enc] {
+            add("compression.encodings: unknown encoding " + strconv.Quote(enc) + ", use zstd, br, gzip or deflate")
+        }
+    }
+    if c.Compression.MinBytes < 0 {
+        add("compression.min_bytes must not be negative")
+    }
+    for i, p := range c.CORS.Policies {
+        name := "cors.policies[" + strconv.Itoa(i) + "]"
+        if len(p.AllowedOrigins) == 0 {
+            add(name + ".allowed_origins is required")
+        }
+        problems = append(problems, corsProblems(name, p)...)
+    }
+    if !apiVersionPattern.MatchString(c.API.DefaultVersion) {
+        add("api.default_version must look like v1")
+    }
+    versions := make([]string, 0, len(c.API.Deprecations))
+    for version := range c.API.Deprecations {
+        versions = append(versions, version)
+    }
+    sort.Strings(versions)
+    for _, version := range versions {
+        d := c.API.Deprecations[version]
+        name := "api.deprecations." + version
+        if !apiVersionPattern.MatchString(version) {
+            add(name + ": version must look like v1")
+        }
+        if _, _, err := d.Times(); err != nil {
+            add(name + ": date and sunset must be YYYY-MM-DD or RFC 3339")
+        }
+        if d.Link != "" {
+            if u, err := url.Parse(d.Link); err != nil || !u.IsAbs() {
+                add(name + ".link must be an absolute URL")
+            }
+        }
+    }
+    names := make([]string, 0, len(c.OAuth.Providers))
+    for name := range c.OAuth.Providers {
+        names = append(names, name)
+    }
+    sort.Strings(names) // stable error messages
+    for _, name := range names {
+        p := c.OAuth.Providers[name]
+        if p.Kind != "oidc" && p.Kind != "github" {
+            add("oauth.providers." + name + ".kind must be oidc or github")
+        }
+        if p.ClientID == "" {
+            add("oauth.providers." + name + ".client_id is required")
+        }
+        if p.Kind == "oidc" && p.Issuer == "" && p.AuthURL == "" {
+            add("oauth.providers." + name + " needs an issuer or explicit endpoints")
+        }
+    }
+
+    if c.IsProduction() {
+        if insecureSecrets[c.JWT.Secret] {
+            add("jwt.secret must be set to a non-default value in production")
+        } else if len(c.JWT.Secret) < minProductionSecretLength {
+            add("jwt.secret must be at least " + strconv.Itoa(minProductionSecretLength) + " characters in production")
+        }
+        if c.Database.Password != "" && insecureSecrets[c.Database.Password] {
+            add("database.password must not be a default value in production")
+        }
+        for _, name := range names {
+            if c.OAuth.Providers[name].ClientSecret == "" {
+                add("oauth.providers." + name + ".client_secret is required in production")
+            }
+        }
+        if strings.HasPrefix(c.OAuthServer.Issuer, "http://localhost") {
+            add("oauth_server.issuer must be set to the public URL in production")
+        }
+    }
+
+    if len(problems) > 0 {
+        return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+    }
+    return nil
+}
+
+func (s *SecretsConfig) problems() []string {
+    var problems []string
+    switch s.Provider {
+    case "", SecretsEnv:
+    case SecretsFile:
+        if s.Dir == "" {
+            problems = append(problems, "secrets.dir is required for the file provider")
+        }
+    case SecretsVault:
+        if s.Vault.Addr == "" || s.Vault.Path == "" || s.Vault.Token == "" {
+            problems = append(problems, "secrets.vault needs addr, path and a token")
+        }
+    default:
+        problems = append(problems, "secrets.provider must be env, file or vault")
+    }
+    if s.RefreshSeconds < 0 {
+        problems = append(problems, "secrets.refresh_seconds must not be negative")
+    }
+    return problems
+}
+
+func corsProblems(name string, p CORSPolicy) []string {
+    var problems []string
+    for _, origin := range p.AllowedOrigins {
+        if origin == "*" {
+            if p.AllowCredentials {
+                problems = append(problems, name+": credentials cannot be allowed for any origin (*)")
+            }
+            continue
+        }
+        if !validOriginPattern(origin) {
+            problems = append(problems, name+": invalid origin "+strconv.Quote(origin)+", use scheme://host[:port] or scheme://*.domain")
+        }
+    }
+    if p.MaxAgeSeconds < 0 {
+        problems = append(problems, name+".max_age_seconds must not be negative")
+    }
+    return problems
+}
+
+// validOriginPattern accepts scheme://host[:port] where host may start with
+// "*." to match subdomains.
+func validOriginPattern(origin string) bool {
+    u, err := url.Parse(origin)
+    if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.User != nil {
+        return false
+    }
+    host := strings.TrimPrefix(u.Hostname(), "*.")
+    return host != "" && !strings.Contains(host, "*")
+}
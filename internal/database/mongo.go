@@ -2,7 +2,9 @@ package database
 
 import (
 	"context"
+	"errors"
 	"project/internal/config"
+	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,6 +15,13 @@ import (
 var MongoClient *mongo.Client
 var MongoDB *mongo.Database
 
+// mongoMu guards the two variables above against ReconnectMongo.
+var mongoMu sync.RWMutex
+
+// reconnectGrace is how long the previous client stays open after a
+// reconnect so operations already using it can finish.
+const reconnectGrace = 30 * time.Second
+
 func InitializeMongo(cfg config.MongoConfig) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
@@ -20,16 +29,51 @@ func InitializeMongo(cfg config.MongoConfig) error {
     if err != nil {
         return err
     }
+    mongoMu.Lock()
     MongoClient = client
     MongoDB = client.Database(cfg.DBName)
+    mongoMu.Unlock()
+    return nil
+}
+
+// ReconnectMongo switches to a new connection, e.g. after the credentials in
+// the URI were rotated. The new client must answer a ping before it replaces
+// the current one, which is closed after a grace period.
+func ReconnectMongo(cfg config.MongoConfig) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.URI))
+    if err != nil {
+        return err
+    }
+    if err := client.Ping(ctx, nil); err != nil {
+        client.Disconnect(ctx)
+        return err
+    }
+    mongoMu.Lock()
+    previous := MongoClient
+    MongoClient = client
+    MongoDB = client.Database(cfg.DBName)
+    mongoMu.Unlock()
+    if previous != nil {
+        time.AfterFunc(reconnectGrace, func() {
+            ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+            defer cancel()
+            previous.Disconnect(ctx)
+        })
+    }
     return nil
 }
 
 func GetMongoDB() *mongo.Database {
+    mongoMu.RLock()
+    defer mongoMu.RUnlock()
     return MongoDB
 }
 
 func CloseMongo() error {
+    mongoMu.RLock()
+    defer mongoMu.RUnlock()
     if MongoClient != nil {
         ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
         defer cancel()
@@ -38,25 +82,127 @@ func CloseMongo() error {
     return nil
 }
 
+// isIndexNotFound reports whether DropOne failed because the index is absent.
+func isIndexNotFound(err error) bool {
+    var cmdErr mongo.CommandError
+    return errors.As(err, &cmdErr) && (cmdErr.Code == 27 || cmdErr.Name == "IndexNotFound")
+}
+
 // EnsureIndexes creates required indexes (e.g., unique email on users)
 func EnsureIndexes() error {
     if MongoDB == nil {
         return nil
     }
     users := MongoDB.Collection("users")
-    // unique index on email
-    // Partial unique index: only for documents without deleted_at
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    // Emails are unique per tenant. Partial unique index: only for documents
+    // without deleted_at. Users of the default tenant have no tenant_id and
+    // are indexed under null.
     idx := mongo.IndexModel{
-        Keys: bson.D{{Key: "email", Value: 1}},
+        Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "email", Value: 1}},
         Options: options.Index().
             SetUnique(true).
-            SetName("uniq_email").
+            SetName("uniq_tenant_email").
             SetPartialFilterExpression(bson.D{{Key: "deleted_at", Value: bson.D{{Key: "$eq", Value: nil}}}}),
     }
-    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-    defer cancel()
-    _, err := users.Indexes().CreateOne(ctx, idx)
-    return err
-}
+    if _, err := users.Indexes().CreateOne(ctx, idx); err != nil {
+        return err
+    }
+    // The global uniq_email index predates organizations and would block the
+    // same email in two of them
+    if _, err := users.Indexes().DropOne(ctx, "uniq_email"); err != nil && !isIndexNotFound(err) {
+        return err
+    }
+
+    // Search: weighted text index over the normalized name and the email.
+    // default_language none keeps Arabic and Latin names unstemmed.
+    _, err := users.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {
+            Keys: bson.D{{Key: "search_name", Value: "text"}, {Key: "email", Value: "text"}},
+            Options: options.Index().
+                SetName("text_search").
+                SetWeights(bson.D{{Key: "search_name", Value: 10}, {Key: "email", Value: 2}}).
+                SetDefaultLanguage("none"),
+        },
+        {Keys: bson.D{{Key: "search_name", Value: 1}}, Options: options.Index().SetName("search_name")},
+    })
+    if err != nil {
+        return err
+    }
+
+    if _, err := MongoDB.Collection("organizations").Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys: bson.D{{Key: "slug", Value: 1}}, Options: options.Index().SetUnique(true).SetName("uniq_slug"),
+    }); err != nil {
+        return err
+    }
 
+    // Group names are unique per tenant; Auth looks groups up by member
+    _, err = MongoDB.Collection("groups").Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "name", Value: 1}}, Options: options.Index().SetUnique(true).SetName("uniq_tenant_name")},
+        {Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "members.user_id", Value: 1}}, Options: options.Index().SetName("tenant_member")},
+    })
+    if err != nil {
+        return err
+    }
 
+    // At most one pending invitation per email and tenant
+    _, err = MongoDB.Collection("invitations").Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "email", Value: 1}},
+        Options: options.Index().SetUnique(true).SetName("uniq_pending_email").
+            SetPartialFilterExpression(bson.D{{Key: "status", Value: "pending"}}),
+    })
+    if err != nil {
+        return err
+    }
+
+    // API keys are looked up by their public prefix on every request
+    apiKeys := MongoDB.Collection("api_keys")
+    _, err = apiKeys.Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "prefix", Value: 1}}, Options: options.Index().SetUnique(true).SetName("uniq_prefix")},
+        {Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("user_created")},
+    })
+    if err != nil {
+        return err
+    }
+
+    // OAuth server: codes and refresh tokens expire out of the collection via TTL
+    if _, err := MongoDB.Collection("oauth_clients").Indexes().CreateOne(ctx, mongo.IndexModel{
+        Keys: bson.D{{Key: "client_id", Value: 1}}, Options: options.Index().SetUnique(true).SetName("uniq_client_id"),
+    }); err != nil {
+        return err
+    }
+    for _, name := range []string{"oauth_codes", "oauth_refresh_tokens"} {
+        hashKey := "code_hash"
+        if name == "oauth_refresh_tokens" {
+            hashKey = "token_hash"
+        }
+        _, err := MongoDB.Collection(name).Indexes().CreateMany(ctx, []mongo.IndexModel{
+            {Keys: bson.D{{Key: hashKey, Value: 1}}, Options: options.Index().SetUnique(true).SetName("uniq_" + hashKey)},
+            {Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expires_at")},
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    for _, name := range []string{"idempotency_keys", "import_jobs"} {
+        _, err = MongoDB.Collection(name).Indexes().CreateOne(ctx, mongo.IndexModel{
+            Keys:    bson.D{{Key: "expires_at", Value: 1}},
+            Options: options.Index().SetExpireAfterSeconds(0).SetName("ttl_expires_at"),
+        })
+        if err != nil {
+            return err
+        }
+    }
+
+    // Audit log is queried by actor, target and action, always by time
+    _, err = MongoDB.Collection("audit_log").Indexes().CreateMany(ctx, []mongo.IndexModel{
+        {Keys: bson.D{{Key: "timestamp", Value: -1}}, Options: options.Index().SetName("timestamp")},
+        {Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "timestamp", Value: -1}}, Options: options.Index().SetName("actor_timestamp")},
+        {Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "timestamp", Value: -1}}, Options: options.Index().SetName("target_timestamp")},
+        {Keys: bson.D{{Key: "action", Value: 1}, {Key: "timestamp", Value: -1}}, Options: options.Index().SetName("action_timestamp")},
+        {Keys: bson.D{{Key: "tenant_id", Value: 1}, {Key: "timestamp", Value: -1}}, Options: options.Index().SetName("tenant_timestamp")},
+    })
+    return err
+}
@@ -0,0 +1,56 @@
+// Package errorsink forwards unexpected server errors, such as recovered
+// panics, to an external collector.
+package errorsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Event describes one unexpected error.
+type Event struct {
+    Time      time.Time `json:"time"`
+    RequestID string    `json:"request_id,omitempty"`
+    Method    string    `json:"method,omitempty"`
+    Path      string    `json:"path,omitempty"`
+    Message   string    `json:"message"`
+    Stack     string    `json:"stack,omitempty"`
+}
+
+// Sink receives error events. Report must not block the request.
+type Sink interface {
+    Report(ctx context.Context, event Event)
+}
+
+// Webhook posts each event as JSON to URL in the background.
+type Webhook struct {
+    URL    string
+    Client *http.Client // defaults to a client with a 5s timeout
+}
+
+func (h *Webhook) Report(ctx context.Context, event Event) {
+    body, err := json.Marshal(event)
+    if err != nil {
+        return
+    }
+    client := h.Client
+    if client == nil {
+        client = &http.Client{Timeout: 5 * time.Second}
+    }
+    // Detached from ctx: the request is over by the time the post completes
+    go func() {
+        resp, err := client.Post(h.URL, "application/json", bytes.NewReader(body))
+        if err != nil {
+            log.Printf("errorsink: reporting %s failed: %v", event.RequestID, err)
+            return
+        }
+        resp.Body.Close()
+        if resp.StatusCode >= 300 {
+            log.Printf("errorsink: reporting %s failed: %s", event.RequestID, resp.Status)
+        }
+    }()
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"io"
+	"log"
+	"mime"
+	"project/internal/authctx"
+	"project/internal/config"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/internal/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Imports larger than asyncImportBytes, after decompression, run as background jobs.
+const (
+    asyncImportBytes = 1 << 20
+    maxImportBytes   = 50 << 20
+)
+
+type AdminHandler struct {
+    auditService  *services.AuditService
+    userService   *services.UserService
+    importService *services.ImportService
+}
+
+func NewAdminHandler(cfg *config.Config) *AdminHandler {
+    userRepo := repositories.NewUserRepositoryMongo()
+    audit := services.NewAuditService(repositories.NewAuditRepositoryMongo())
+    userService := services.NewUserService(userRepo, audit)
+    invitations := services.NewInvitationService(repositories.NewInvitationRepositoryMongo(), userRepo, audit, cfg.JWT)
+    importService := services.NewImportService(userRepo, repositories.NewImportJobRepositoryMongo(), invitations, audit)
+    return &AdminHandler{auditService: audit, userService: userService, importService: importService}
+}
+
+type setRoleRequest struct {
+    Role string `json:"role" validate:"required,oneof=user admin"`
+}
+
+var errAuditAllTenants = errors.New("all_tenants is only available to platform admins")
+
+// parseAuditFilter reads audit filters from the query string. Admins see
+// their own tenant's events; platform admins (admins of the default tenant)
+// may ask for every tenant's with ?all_tenants=true.
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+    q := r.URL.Query()
+    filter := models.AuditFilter{
+        TenantID:   authctx.TenantID(r.Context()),
+        ActorID:    q.Get("actor_id"),
+        Action:     q.Get("action"),
+        TargetType: q.Get("target_type"),
+        TargetID:   q.Get("target_id"),
+    }
+    if q.Get("all_tenants") == "true" {
+        if filter.TenantID != "" {
+            return filter, errAuditAllTenants
+        }
+        filter.AllTenants = true
+    }
+    for _, p := range []struct {
+        name string
+        dst  **time.Time
+    }{{"from", &filter.From}, {"to", &filter.To}} {
+        if v := q.Get(p.name); v != "" {
+            t, err := time.Parse(time.RFC3339, v)
+            if err != nil {
+                return filter, errors.New(p.name + " must be an RFC 3339 timestamp")
+            }
+            *p.dst = &t
+        }
+    }
+    for _, p := range []struct {
+        name string
+        dst  *int64
+    }{{"limit", &filter.Limit}, {"offset", &filter.Offset}} {
+        if v := q.Get(p.name); v != "" {
+            n, err := strconv.ParseInt(v, 10, 64)
+            if err != nil || n < 0 {
+                return filter, errors.New(p.name + " must be a non-negative integer")
+            }
+            *p.dst = n
+        }
+    }
+    return filter, nil
+}
+
+func sendAuditFilterError(w http.ResponseWriter, err error) {
+    if errors.Is(err, errAuditAllTenants) {
+        sendErrorResponse(w, http.StatusForbidden, err.Error())
+        return
+    }
+    sendErrorResponse(w, http.StatusBadRequest, err.Error())
+}
+
+func (h *AdminHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+    filter, err := parseAuditFilter(r)
+    if err != nil {
+        sendAuditFilterError(w, err)
+        return
+    }
+    events, err := h.auditService.Query(filter)
+    if err != nil {
+        sendErrorResponse(w, http.StatusInternalServerError, "Failed to get audit events: "+err.Error())
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Audit events retrieved successfully", events)
+}
+
+// ExportAudit streams matching events as JSON Lines.
+func (h *AdminHandler) ExportAudit(w http.ResponseWriter, r *http.Request) {
+    filter, err := parseAuditFilter(r)
+    if err != nil {
+        sendAuditFilterError(w, err)
+        return
+    }
+    h.auditService.Record(r.Context(), models.AuditLogExported, "audit_log", "", nil, nil, map[string]interface{}{"query": r.URL.RawQuery})
+
+    w.Header().Set("Content-Type", "application/x-ndjson")
+    w.Header().Set("Content-Disposition", `attachment; filename="audit-`+time.Now().UTC().Format("20060102T150405Z")+`.jsonl"`)
+    w.WriteHeader(http.StatusOK)
+    if err := h.auditService.ExportJSONLines(r.Context(), filter, w); err != nil {
+        // Headers are already sent; the truncated body is all we can signal
+        json.NewEncoder(w).Encode(map[string]string{"export_error": err.Error()})
+    }
+}
+
+func (h *AdminHandler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+    var req setRoleRequest
+    if !decodeBody(w, r, &req) {
+        return
+    }
+    user, err := h.userService.SetRole(r.Context(), mux.Vars(r)["id"], req.Role)
+    if err != nil {
+        if errors.Is(err, services.ErrUserNotFound) {
+            sendErrorResponse(w, http.StatusNotFound, err.Error())
+        } else if strings.Contains(err.Error(), "invalid") {
+            sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        } else {
+            sendErrorResponse(w, http.StatusInternalServerError, "Failed to set role: "+err.Error())
+        }
+        return
+    }
+    linkAvatars(r, user)
+    sendSuccessResponse(w, http.StatusOK, "Role updated successfully", user)
+}
+
+// importFormat takes the format from ?format= or the Content-Type.
+func importFormat(r *http.Request) string {
+    if f := r.URL.Query().Get("format"); f != "" {
+        return strings.ToLower(f)
+    }
+    mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+    switch mediaType {
+    case "text/csv":
+        return models.ImportFormatCSV
+    case "application/x-ndjson", "application/jsonl", "application/jsonlines":
+        return models.ImportFormatNDJSON
+    }
+    return ""
+}
+
+// ImportUsers creates users from a CSV or NDJSON body. ?dry_run=true only
+// validates. Large bodies, or ?async=true, return 202 with a job to poll.
+func (h *AdminHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+    q := r.URL.Query()
+    format := importFormat(r)
+    opts := services.ImportOptions{DryRun: q.Get("dry_run") == "true"}
+    if v := q.Get("invite_ttl_hours"); v != "" {
+        hours, err := strconv.Atoi(v)
+        if err != nil || hours < 1 || hours > services.MaxInviteTTLHours {
+            sendErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invite_ttl_hours must be between 1 and %d", services.MaxInviteTTLHours))
+            return
+        }
+        opts.InviteTTL = time.Duration(hours) * time.Hour
+    }
+    body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+    // Gzip and chunked uploads carry no usable Content-Length, so the choice
+    // is made on the bytes actually read
+    head, err := io.ReadAll(io.LimitReader(body, asyncImportBytes+1))
+    if err != nil {
+        sendErrorResponse(w, http.StatusBadRequest, "Failed to read import file: "+err.Error())
+        return
+    }
+    if q.Get("async") == "true" || len(head) > asyncImportBytes {
+        rest, err := io.ReadAll(body)
+        if err != nil {
+            var tooLarge *http.MaxBytesError
+            if errors.As(err, &tooLarge) {
+                sendErrorResponse(w, http.StatusRequestEntityTooLarge, "Import file is too large")
+            } else {
+                sendErrorResponse(w, http.StatusBadRequest, "Failed to read import file: "+err.Error())
+            }
+            return
+        }
+        data := append(head, rest...)
+        job, err := h.importService.StartJob(r.Context(), format, data, opts)
+        if err != nil {
+            switch {
+            case errors.Is(err, services.ErrUnsupportedImportFormat):
+                sendErrorResponse(w, http.StatusUnsupportedMediaType, err.Error())
+            case errors.Is(err, services.ErrInviteTTL):
+                sendErrorResponse(w, http.StatusBadRequest, err.Error())
+            default:
+                sendErrorResponse(w, http.StatusInternalServerError, "Failed to start import: "+err.Error())
+            }
+            return
+        }
+        w.Header().Set("Location", authctx.APIVersionFrom(r.Context()).BasePath+"/admin/imports/"+job.ID.Hex())
+        sendSuccessResponse(w, http.StatusAccepted, "Import started", job)
+        return
+    }
+
+    report, err := h.importService.Import(r.Context(), format, bytes.NewReader(head), opts)
+    if err != nil {
+        switch {
+        case errors.Is(err, services.ErrUnsupportedImportFormat):
+            sendErrorResponse(w, http.StatusUnsupportedMediaType, err.Error())
+        case errors.Is(err, services.ErrInvalidImport), errors.Is(err, services.ErrImportTooManyRows), errors.Is(err, services.ErrInviteTTL):
+            sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        default:
+            sendErrorResponse(w, http.StatusInternalServerError, "Import failed: "+err.Error())
+        }
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Import finished", report)
+}
+
+func (h *AdminHandler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+    job, err := h.importService.GetJob(r.Context(), mux.Vars(r)["id"])
+    if err != nil {
+        sendErrorResponse(w, http.StatusNotFound, "Import job not found")
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Import job retrieved successfully", job)
+}
+
+// ExportUsers streams users matching the GET /users filters as CSV, NDJSON
+// or XLSX. ?columns=id,email selects and orders the columns.
+func (h *AdminHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+    filter, err := parseUserFilter(r)
+    if err != nil {
+        sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    format := strings.ToLower(r.URL.Query().Get("format"))
+    if format == "" {
+        format = services.ExportFormatCSV
+    }
+    var columns []string
+    if v := r.URL.Query().Get("columns"); v != "" {
+        for _, c := range strings.Split(v, ",") {
+            columns = append(columns, strings.TrimSpace(c))
+        }
+    }
+    if _, err := services.ResolveExportColumns(format, columns); err != nil {
+        sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    contentType := services.ExportContentTypes[format]
+    w.Header().Set("Content-Type", contentType[0])
+    w.Header().Set("Content-Disposition", `attachment; filename="users-`+time.Now().UTC().Format("20060102T150405Z")+"."+contentType[1]+`"`)
+    w.WriteHeader(http.StatusOK)
+    if err := h.userService.ExportUsers(r.Context(), filter, format, columns, w); err != nil {
+        // Headers are already sent; the client sees a truncated file
+        log.Printf("user export failed: %v", err)
+    }
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project/internal/authctx"
+	"project/internal/repositories"
+	"project/internal/services"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type APIKeyHandler struct {
+    apiKeyService *services.APIKeyService
+}
+
+func NewAPIKeyHandler() *APIKeyHandler {
+    keyRepo := repositories.NewAPIKeyRepositoryMongo()
+    return &APIKeyHandler{apiKeyService: services.NewAPIKeyService(keyRepo)}
+}
+
+type createAPIKeyRequest struct {
+    Name      string     `json:"name" validate:"required,min=2,max=100"`
+    Scopes    []string   `json:"scopes" validate:"required,min=1"`
+    ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// sessionUserID returns the user of an interactive login session. API keys and
+// OAuth access tokens are rejected: they must not mint credentials or consent.
+func sessionUserID(w http.ResponseWriter, r *http.Request) (string, bool) {
+    p, ok := authctx.PrincipalFrom(r.Context())
+    if !ok || p.UserID == "" {
+        sendErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+        return "", false
+    }
+    if !p.IsSession() {
+        sendErrorResponse(w, http.StatusForbidden, "This operation requires a login session")
+        return "", false
+    }
+    return p.UserID, true
+}
+
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+    userID, ok := sessionUserID(w, r)
+    if !ok {
+        return
+    }
+    var req createAPIKeyRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+
+    created, err := h.apiKeyService.CreateKey(r.Context(), userID, req.Name, req.Scopes, req.ExpiresAt)
+    if err != nil {
+        switch {
+        case errors.Is(err, services.ErrAPIKeyNameRequired), errors.Is(err, services.ErrAPIKeyExpiry),
+            errors.Is(err, services.ErrScopeRequired), errors.Is(err, services.ErrUnknownScope):
+            sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        default:
+            sendErrorResponse(w, http.StatusInternalServerError, "Failed to create API key: "+err.Error())
+        }
+        return
+    }
+    sendSuccessResponse(w, http.StatusCreated, "API key created; store it now, it will not be shown again", created)
+}
+
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+    userID, ok := sessionUserID(w, r)
+    if !ok {
+        return
+    }
+    keys, err := h.apiKeyService.ListKeys(userID)
+    if err != nil {
+        sendErrorResponse(w, http.StatusInternalServerError, "Failed to get API keys: "+err.Error())
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "API keys retrieved successfully", keys)
+}
+
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+    userID, ok := sessionUserID(w, r)
+    if !ok {
+        return
+    }
+    if err := h.apiKeyService.RevokeKey(userID, mux.Vars(r)["id"]); err != nil {
+        sendErrorResponse(w, http.StatusNotFound, err.Error())
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "API key revoked successfully", nil)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// avatarFormField is the multipart field PUT /users/{id}/avatar reads.
+const avatarFormField = "avatar"
+
+// sendAvatarError maps avatar service errors to HTTP statuses.
+func sendAvatarError(w http.ResponseWriter, err error) {
+    var tooLarge *http.MaxBytesError
+    switch {
+    case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrAvatarNotFound), errors.Is(err, services.ErrInvalidAvatarSize):
+        sendErrorResponse(w, http.StatusNotFound, err.Error())
+    case errors.Is(err, services.ErrAvatarTooLarge), errors.As(err, &tooLarge):
+        sendErrorResponse(w, http.StatusRequestEntityTooLarge, services.ErrAvatarTooLarge.Error())
+    case errors.Is(err, services.ErrUnsupportedImage):
+        sendErrorResponse(w, http.StatusUnsupportedMediaType, err.Error())
+    case errors.Is(err, services.ErrAvatarDimensions):
+        sendErrorResponse(w, http.StatusBadRequest, err.Error())
+    default:
+        sendErrorResponse(w, http.StatusInternalServerError, "Avatar request failed: "+err.Error())
+    }
+}
+
+// linkAvatars points the users' avatar URLs at the API version r was
+// served from.
+func linkAvatars(r *http.Request, users ...*models.UserResponse) {
+    base := authctx.APIVersionFrom(r.Context()).BasePath
+    for _, u := range users {
+        u.LinkAvatar(base)
+    }
+}
+
+// linkAvatarList is linkAvatars for a list of users.
+func linkAvatarList(r *http.Request, users []models.UserResponse) {
+    for i := range users {
+        linkAvatars(r, &users[i])
+    }
+}
+
+// readAvatarPart returns the content of the avatar field, or nil if the form
+// has none. At most one byte more than the limit is read.
+func readAvatarPart(r *http.Request) ([]byte, error) {
+    mr, err := r.MultipartReader()
+    if err != nil {
+        return nil, err
+    }
+    for {
+        part, err := mr.NextPart()
+        if err == io.EOF {
+            return nil, nil
+        }
+        if err != nil {
+            return nil, err
+        }
+        if part.FormName() == avatarFormField {
+            return io.ReadAll(io.LimitReader(part, services.MaxAvatarBytes+1))
+        }
+    }
+}
+
+// PutAvatar handles PUT /users/{id}/avatar with the image in the "avatar"
+// field of a multipart/form-data body. The type is sniffed from the content.
+func (h *UserHandler) PutAvatar(w http.ResponseWriter, r *http.Request) {
+    // Room for the multipart framing around the largest accepted image
+    r.Body = http.MaxBytesReader(w, r.Body, services.MaxAvatarBytes+64<<10)
+    data, err := readAvatarPart(r)
+    var tooLarge *http.MaxBytesError
+    if errors.As(err, &tooLarge) {
+        sendAvatarError(w, err)
+        return
+    }
+    if err != nil {
+        sendErrorResponse(w, http.StatusBadRequest, "Invalid multipart/form-data body: "+err.Error())
+        return
+    }
+    if data == nil {
+        sendErrorResponse(w, http.StatusBadRequest, "Missing form field: "+avatarFormField)
+        return
+    }
+    user, err := h.avatarService.SetAvatar(r.Context(), mux.Vars(r)["id"], data)
+    if err != nil {
+        sendAvatarError(w, err)
+        return
+    }
+    w.Header().Set("ETag", userETag(user.Version))
+    linkAvatars(r, user)
+    sendSuccessResponse(w, http.StatusOK, "Avatar updated successfully", user)
+}
+
+// GetAvatar handles GET /users/{id}/avatar?size=64|128|256|original. It
+// streams from GridFS with Range and conditional request support. URLs with
+// the v parameter from avatar_url name one upload and may be cached for good.
+func (h *UserHandler) GetAvatar(w http.ResponseWriter, r *http.Request) {
+    q := r.URL.Query()
+    file, content, err := h.avatarService.Avatar(r.Context(), mux.Vars(r)["id"], q.Get("size"))
+    if err != nil {
+        sendAvatarError(w, err)
+        return
+    }
+    defer content.Close()
+    w.Header().Set("Content-Type", file.ContentType)
+    w.Header().Set("X-Content-Type-Options", "nosniff")
+    w.Header().Set("ETag", `"`+file.ID+`"`)
+    if q.Get("v") != "" {
+        w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+    } else {
+        w.Header().Set("Cache-Control", "private, no-cache")
+    }
+    http.ServeContent(w, r, "", file.UploadDate, content)
+}
+
+func (h *UserHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+    if err := h.avatarService.DeleteAvatar(r.Context(), mux.Vars(r)["id"]); err != nil {
+        sendAvatarError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Avatar deleted successfully", nil)
+}
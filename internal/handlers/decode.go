@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project/internal/codec"
+	"project/pkg/utils"
+)
+
+// decodeBody decodes the request body into req in the encoding named by its
+// Content-Type, with the limits and strictness of codec.DecodeRequest. It
+// writes the error response itself when it fails.
+func decodeBody(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+    err := codec.DecodeRequest(w, r, req, utils.MaxJSONBodyBytes)
+    if err == nil {
+        return true
+    }
+    var derr *utils.DecodeError
+    if errors.As(err, &derr) {
+        sendErrorResponse(w, derr.Status, derr.Message)
+    } else {
+        sendErrorResponse(w, http.StatusBadRequest, "Invalid JSON format: "+err.Error())
+    }
+    return false
+}
+
+// decodeValidated decodes a body into req and validates it, writing the
+// error response itself when it fails.
+func decodeValidated(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+    if !decodeBody(w, r, req) {
+        return false
+    }
+    if errs, err := utils.ValidateStructDetailed(req); err != nil {
+        sendErrorResponse(w, http.StatusBadRequest, "Validation error: "+err.Error())
+        return false
+    } else if len(errs) > 0 {
+        sendValidationErrors(w, errs)
+        return false
+    }
+    return true
+}
+
+// writeEncoded writes v with the codec negotiated for the response, falling
+// back to JSON if the codec cannot represent it.
+func writeEncoded(w http.ResponseWriter, statusCode int, v interface{}) {
+    c := codec.FromWriter(w)
+    body, err := c.Marshal(v)
+    if err != nil && c != codec.JSON {
+        c = codec.JSON
+        body, err = c.Marshal(v)
+    }
+    if err != nil {
+        w.Header().Set("Content-Type", codec.JSONMediaType)
+        w.WriteHeader(http.StatusInternalServerError)
+        w.Write([]byte(`{"success":false,"message":"Failed to encode response"}` + "\n"))
+        return
+    }
+    w.Header().Set("Content-Type", c.MediaType())
+    w.WriteHeader(statusCode)
+    w.Write(body)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"project/api"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DocsHandler serves the embedded OpenAPI document and its reference page.
+type DocsHandler struct {
+    specJSON []byte
+    page     []byte
+    specErr  error
+}
+
+// NewDocsHandler converts the embedded YAML document to JSON and renders the
+// reference page once.
+func NewDocsHandler() *DocsHandler {
+    h := &DocsHandler{}
+    var doc interface{}
+    if err := yaml.Unmarshal(api.Spec, &doc); err != nil {
+        h.specErr = err
+        return h
+    }
+    if h.specJSON, h.specErr = json.Marshal(jsonValue(doc)); h.specErr != nil {
+        return h
+    }
+    h.page, h.specErr = renderDocsPage(api.Spec)
+    return h
+}
+
+// jsonValue turns the maps yaml.v3 decodes with non-string keys, such as
+// unquoted status codes, into maps encoding/json accepts.
+func jsonValue(v interface{}) interface{} {
+    switch v := v.(type) {
+    case map[string]interface{}:
+        for k, e := range v {
+            v[k] = jsonValue(e)
+        }
+        return v
+    case map[interface{}]interface{}:
+        m := make(map[string]interface{}, len(v))
+        for k, e := range v {
+            m[fmt.Sprint(k)] = jsonValue(e)
+        }
+        return m
+    case []interface{}:
+        for i, e := range v {
+            v[i] = jsonValue(e)
+        }
+        return v
+    default:
+        return v
+    }
+}
+
+// OpenAPI handles GET /openapi.json.
+func (h *DocsHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+    if h.specErr != nil {
+        sendErrorResponse(w, http.StatusInternalServerError, "Invalid OpenAPI document: "+h.specErr.Error())
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.WriteHeader(http.StatusOK)
+    w.Write(h.specJSON)
+}
+
+// Page handles GET /docs, the reference rendered from the same document. It
+// is static HTML with inline styles and no scripts.
+func (h *DocsHandler) Page(w http.ResponseWriter, r *http.Request) {
+    if h.specErr != nil {
+        sendErrorResponse(w, http.StatusInternalServerError, "Invalid OpenAPI document: "+h.specErr.Error())
+        return
+    }
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.WriteHeader(http.StatusOK)
+    w.Write(h.page)
+}
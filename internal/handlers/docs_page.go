@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"project/api"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The subset of OpenAPI 3.1 the reference page shows.
+type openAPIDoc struct {
+    Info struct {
+        Title       string `yaml:"title"`
+        Version     string `yaml:"version"`
+        Description string `yaml:"description"`
+    } `yaml:"info"`
+    Servers []struct {
+        URL         string `yaml:"url"`
+        Description string `yaml:"description"`
+    } `yaml:"servers"`
+    Tags []struct {
+        Name        string `yaml:"name"`
+        Description string `yaml:"description"`
+    } `yaml:"tags"`
+    Paths      map[string]openAPIPathItem `yaml:"paths"`
+    Components struct {
+        Parameters map[string]openAPIParameter `yaml:"parameters"`
+        Responses  map[string]openAPIResponse  `yaml:"responses"`
+        Schemas    map[string]interface{}      `yaml:"schemas"`
+    } `yaml:"components"`
+}
+
+type openAPIPathItem struct {
+    Parameters []openAPIParameter `yaml:"parameters"`
+    Get        *openAPIOperation  `yaml:"get"`
+    Post       *openAPIOperation  `yaml:"post"`
+    Put        *openAPIOperation  `yaml:"put"`
+    Patch      *openAPIOperation  `yaml:"patch"`
+    Delete     *openAPIOperation  `yaml:"delete"`
+}
+
+type openAPIOperation struct {
+    Tags        []string           `yaml:"tags"`
+    Summary     string             `yaml:"summary"`
+    Description string             `yaml:"description"`
+    Parameters  []openAPIParameter `yaml:"parameters"`
+    RequestBody *struct {
+        Content map[string]struct {
+            Schema interface{} `yaml:"schema"`
+        } `yaml:"content"`
+    } `yaml:"requestBody"`
+    Responses map[string]openAPIResponse `yaml:"responses"`
+}
+
+type openAPIParameter struct {
+    Ref         string `yaml:"$ref"`
+    Name        string `yaml:"name"`
+    In          string `yaml:"in"`
+    Description string `yaml:"description"`
+    Required    bool   `yaml:"required"`
+}
+
+type openAPIResponse struct {
+    Ref         string `yaml:"$ref"`
+    Description string `yaml:"description"`
+}
+
+// docsOperation is one operation as laid out on the page.
+type docsOperation struct {
+    Method      string
+    Path        string
+    Summary     string
+    Description string
+    Parameters  []openAPIParameter
+    Bodies      []docsBody
+    Responses   []docsResponse
+}
+
+type docsBody struct {
+    ContentType string
+    Schema      string // a components schema name, or the inline schema as JSON
+    Ref         bool
+}
+
+type docsResponse struct {
+    Status      string
+    Description string
+}
+
+type docsTag struct {
+    Name        string
+    Description string
+    Operations  []docsOperation
+}
+
+type docsSchema struct {
+    Name string
+    JSON string
+}
+
+type docsPage struct {
+    Title       string
+    Version     string
+    Description string
+    Servers     []string
+    Tags        []docsTag
+    Schemas     []docsSchema
+}
+
+var docsTemplate = template.Must(template.New("docs").Parse(string(api.DocsPage)))
+
+// renderDocsPage lays out the spec as static HTML, so /docs needs no scripts.
+func renderDocsPage(spec []byte) ([]byte, error) {
+    var doc openAPIDoc
+    if err := yaml.Unmarshal(spec, &doc); err != nil {
+        return nil, err
+    }
+    page := docsPage{Title: doc.Info.Title, Version: doc.Info.Version, Description: doc.Info.Description}
+    for _, s := range doc.Servers {
+        page.Servers = append(page.Servers, s.URL+" — "+s.Description)
+    }
+
+    tagIndex := map[string]int{}
+    for _, t := range doc.Tags {
+        tagIndex[t.Name] = len(page.Tags)
+        page.Tags = append(page.Tags, docsTag{Name: t.Name, Description: t.Description})
+    }
+    paths := make([]string, 0, len(doc.Paths))
+    for p := range doc.Paths {
+        paths = append(paths, p)
+    }
+    sort.Strings(paths)
+    for _, path := range paths {
+        item := doc.Paths[path]
+        for _, m := range []struct {
+            method string
+            op     *openAPIOperation
+        }{{"GET", item.Get}, {"POST", item.Post}, {"PUT", item.Put}, {"PATCH", item.Patch}, {"DELETE", item.Delete}} {
+            if m.op == nil {
+                continue
+            }
+            op := docsOperation{Method: m.method, Path: path, Summary: m.op.Summary, Description: m.op.Description}
+            for _, p := range append(append([]openAPIParameter{}, item.Parameters...), m.op.Parameters...) {
+                if p.Ref != "" {
+                    p = doc.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+                }
+                op.Parameters = append(op.Parameters, p)
+            }
+            if m.op.RequestBody != nil {
+                for _, ct := range sortedKeys(m.op.RequestBody.Content) {
+                    op.Bodies = append(op.Bodies, docsBodyFor(ct, m.op.RequestBody.Content[ct].Schema))
+                }
+            }
+            for _, status := range sortedKeys(m.op.Responses) {
+                res := m.op.Responses[status]
+                if res.Ref != "" {
+                    res = doc.Components.Responses[strings.TrimPrefix(res.Ref, "#/components/responses/")]
+                }
+                op.Responses = append(op.Responses, docsResponse{Status: status, Description: res.Description})
+            }
+
+            tag := "other"
+            if len(m.op.Tags) > 0 {
+                tag = m.op.Tags[0]
+            }
+            i, ok := tagIndex[tag]
+            if !ok {
+                i = len(page.Tags)
+                tagIndex[tag] = i
+                page.Tags = append(page.Tags, docsTag{Name: tag})
+            }
+            page.Tags[i].Operations = append(page.Tags[i].Operations, op)
+        }
+    }
+
+    for _, name := range sortedKeys(doc.Components.Schemas) {
+        out, err := json.MarshalIndent(jsonValue(doc.Components.Schemas[name]), "", "  ")
+        if err != nil {
+            return nil, err
+        }
+        page.Schemas = append(page.Schemas, docsSchema{Name: name, JSON: string(out)})
+    }
+
+    var buf bytes.Buffer
+    if err := docsTemplate.Execute(&buf, page); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+// docsBodyFor links a $ref schema to its section and inlines anything else.
+func docsBodyFor(contentType string, schema interface{}) docsBody {
+    if m, ok := schema.(map[string]interface{}); ok {
+        if ref, ok := m["$ref"].(string); ok && len(m) == 1 {
+            return docsBody{ContentType: contentType, Schema: strings.TrimPrefix(ref, "#/components/schemas/"), Ref: true}
+        }
+    }
+    out, _ := json.MarshalIndent(jsonValue(schema), "", "  ")
+    return docsBody{ContentType: contentType, Schema: string(out)}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+    keys := make([]string, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
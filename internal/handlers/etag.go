@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"project/internal/repositories"
+	"strconv"
+	"strings"
+)
+
+// userETag formats a user version as a strong entity tag.
+func userETag(version int64) string {
+    return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// etagListMatches reports whether an If-Match/If-None-Match value matches
+// etag. W/ tags only count when weak comparison is requested.
+func etagListMatches(header, etag string, weak bool) bool {
+    for _, tag := range strings.Split(header, ",") {
+        tag = strings.TrimSpace(tag)
+        if tag == "*" {
+            return true
+        }
+        if strings.HasPrefix(tag, "W/") {
+            if !weak {
+                continue
+            }
+            tag = tag[len("W/"):]
+        }
+        if tag == etag {
+            return true
+        }
+    }
+    return false
+}
+
+// notModified sets the ETag and answers 304 when If-None-Match matches it.
+func notModified(w http.ResponseWriter, r *http.Request, version int64) bool {
+    etag := userETag(version)
+    w.Header().Set("ETag", etag)
+    if inm := r.Header.Get("If-None-Match"); inm != "" && etagListMatches(inm, etag, true) {
+        w.Header().Del("Content-Type")
+        w.WriteHeader(http.StatusNotModified)
+        return true
+    }
+    return false
+}
+
+// expectedVersion resolves If-Match for a write to the user and returns the
+// version the write must be conditional on. It answers 428 when the header is
+// required but missing and 412 when it does not match the current version.
+func (h *UserHandler) expectedVersion(w http.ResponseWriter, r *http.Request, idStr string) (int64, bool) {
+    ifMatch := r.Header.Get("If-Match")
+    if ifMatch == "" {
+        if h.requireIfMatch {
+            sendErrorResponse(w, http.StatusPreconditionRequired, "If-Match header is required")
+            return 0, false
+        }
+        return repositories.AnyVersion, true
+    }
+    user, err := h.userService.GetUserByID(r.Context(), idStr)
+    if err != nil {
+        // No current representation, so no entity tag can match
+        sendErrorResponse(w, http.StatusPreconditionFailed, "Precondition failed: user not found")
+        return 0, false
+    }
+    // Weak tags are accepted: Compress weakens the tag on encoded responses,
+    // but it still names the user version
+    if !etagListMatches(ifMatch, userETag(user.Version), true) {
+        w.Header().Set("ETag", userETag(user.Version))
+        sendErrorResponse(w, http.StatusPreconditionFailed, "Precondition failed: user was modified")
+        return 0, false
+    }
+    return user.Version, true
+}
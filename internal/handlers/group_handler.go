@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type GroupHandler struct {
+    groupService *services.GroupService
+}
+
+func NewGroupHandler() *GroupHandler {
+    audit := services.NewAuditService(repositories.NewAuditRepositoryMongo())
+    groupService := services.NewGroupService(repositories.NewGroupRepositoryMongo(), repositories.NewUserRepositoryMongo(), audit)
+    return &GroupHandler{groupService: groupService}
+}
+
+// sendGroupError maps group service errors to HTTP statuses.
+func sendGroupError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, services.ErrGroupNotFound), errors.Is(err, services.ErrMemberNotFound):
+        sendErrorResponse(w, http.StatusNotFound, err.Error())
+    case errors.Is(err, services.ErrGroupForbidden), errors.Is(err, services.ErrGroupPermissions):
+        sendErrorResponse(w, http.StatusForbidden, err.Error())
+    case errors.Is(err, repositories.ErrGroupNameTaken), errors.Is(err, services.ErrGroupLastOwner):
+        sendErrorResponse(w, http.StatusConflict, err.Error())
+    case errors.Is(err, services.ErrUnknownPermission):
+        sendErrorResponse(w, http.StatusBadRequest, err.Error())
+    default:
+        sendErrorResponse(w, http.StatusInternalServerError, "Group request failed: "+err.Error())
+    }
+}
+
+func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+    var req models.GroupRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+    group, err := h.groupService.Create(r.Context(), req)
+    if err != nil {
+        sendGroupError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusCreated, "Group created successfully", group)
+}
+
+func (h *GroupHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+    groups, err := h.groupService.List(r.Context())
+    if err != nil {
+        sendGroupError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Groups retrieved successfully", groups)
+}
+
+func (h *GroupHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+    group, err := h.groupService.Get(r.Context(), mux.Vars(r)["id"])
+    if err != nil {
+        sendGroupError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Group retrieved successfully", group)
+}
+
+func (h *GroupHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+    var req models.GroupRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+    group, err := h.groupService.Update(r.Context(), mux.Vars(r)["id"], req)
+    if err != nil {
+        sendGroupError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Group updated successfully", group)
+}
+
+func (h *GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+    if err := h.groupService.Delete(r.Context(), mux.Vars(r)["id"]); err != nil {
+        sendGroupError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Group deleted successfully", nil)
+}
+
+// AddMember handles POST /groups/{id}/members; posting an existing member
+// changes their role.
+func (h *GroupHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+    var req models.GroupMemberRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+    group, err := h.groupService.AddMember(r.Context(), mux.Vars(r)["id"], req)
+    if err != nil {
+        sendGroupError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Member added successfully", group)
+}
+
+func (h *GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    if err := h.groupService.RemoveMember(r.Context(), vars["id"], vars["userId"]); err != nil {
+        sendGroupError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Member removed successfully", nil)
+}
+
+// ListUserGroups handles GET /users/{id}/groups.
+func (h *GroupHandler) ListUserGroups(w http.ResponseWriter, r *http.Request) {
+    h.listGroupsOf(w, r, mux.Vars(r)["id"])
+}
+
+// ListMyGroups handles GET /me/groups.
+func (h *GroupHandler) ListMyGroups(w http.ResponseWriter, r *http.Request) {
+    h.listGroupsOf(w, r, authctx.UserID(r.Context()))
+}
+
+func (h *GroupHandler) listGroupsOf(w http.ResponseWriter, r *http.Request, userID string) {
+    groups, err := h.groupService.UserGroups(r.Context(), userID)
+    if err != nil {
+        sendGroupError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Groups retrieved successfully", groups)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"project/internal/config"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/internal/services"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type InvitationHandler struct {
+    invitationService *services.InvitationService
+}
+
+func NewInvitationHandler(cfg *config.Config) *InvitationHandler {
+    audit := services.NewAuditService(repositories.NewAuditRepositoryMongo())
+    invitationService := services.NewInvitationService(repositories.NewInvitationRepositoryMongo(), repositories.NewUserRepositoryMongo(), audit, cfg.JWT)
+    return &InvitationHandler{invitationService: invitationService}
+}
+
+// sendInvitationError maps invitation service errors to HTTP statuses;
+// anything else is a rejected input.
+func sendInvitationError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, services.ErrInvitationNotFound):
+        sendErrorResponse(w, http.StatusNotFound, err.Error())
+    case errors.Is(err, services.ErrInvitationUsed), errors.Is(err, services.ErrInvitedUserExists), errors.Is(err, repositories.ErrInvitationPending):
+        sendErrorResponse(w, http.StatusConflict, err.Error())
+    default:
+        sendErrorResponse(w, http.StatusBadRequest, err.Error())
+    }
+}
+
+// CreateInvitation handles POST /invitations. The response carries the
+// invitation token for delivery to the invitee.
+func (h *InvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+    var req models.InvitationRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+    res, err := h.invitationService.Create(r.Context(), req)
+    if err != nil {
+        sendInvitationError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusCreated, "Invitation created successfully", res)
+}
+
+// ListInvitations handles GET /invitations?include_expired=true.
+func (h *InvitationHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+    includeExpired, _ := strconv.ParseBool(r.URL.Query().Get("include_expired"))
+    invs, err := h.invitationService.ListPending(r.Context(), includeExpired)
+    if err != nil {
+        sendErrorResponse(w, http.StatusInternalServerError, "Failed to list invitations: "+err.Error())
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Invitations retrieved successfully", invs)
+}
+
+// ResendInvitation handles POST /invitations/{id}/resend?expires_in_hours=N.
+func (h *InvitationHandler) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+    hours := 0
+    if v := r.URL.Query().Get("expires_in_hours"); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil || n < 1 || n > services.MaxInviteTTLHours {
+            sendErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("expires_in_hours must be between 1 and %d", services.MaxInviteTTLHours))
+            return
+        }
+        hours = n
+    }
+    res, err := h.invitationService.Resend(r.Context(), mux.Vars(r)["id"], hours)
+    if err != nil {
+        sendInvitationError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Invitation resent successfully", res)
+}
+
+func (h *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+    if err := h.invitationService.Revoke(r.Context(), mux.Vars(r)["id"]); err != nil {
+        sendInvitationError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Invitation revoked successfully", nil)
+}
+
+// AcceptInvitation lets the invitee choose their name and password and signs
+// them in.
+func (h *InvitationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+    var req models.AcceptInvitationRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+    token, user, err := h.invitationService.Accept(r.Context(), req)
+    if err != nil {
+        sendInvitationError(w, err)
+        return
+    }
+    linkAvatars(r, user)
+    sendSuccessResponse(w, http.StatusCreated, "Invitation accepted", models.LoginResponse{Token: token, User: user})
+}
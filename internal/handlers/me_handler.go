@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"project/internal/authctx"
+	"project/internal/repositories"
+	"project/internal/services"
+)
+
+type updateMeRequest struct {
+    Name  string `json:"name" validate:"omitempty,min=2"`
+    Email string `json:"email" validate:"omitempty,email"`
+}
+
+type changePasswordRequest struct {
+    CurrentPassword string `json:"current_password" validate:"required"`
+    NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+// currentUserID returns the authenticated user for /me routes.
+func currentUserID(w http.ResponseWriter, r *http.Request) (string, bool) {
+    userID := authctx.UserID(r.Context())
+    if userID == "" {
+        sendErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+        return "", false
+    }
+    return userID, true
+}
+
+func (h *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+    userID, ok := currentUserID(w, r)
+    if !ok {
+        return
+    }
+    user, err := h.userService.GetUserByID(r.Context(), userID)
+    if err != nil {
+        sendErrorResponse(w, http.StatusNotFound, "User not found: "+err.Error())
+        return
+    }
+    if notModified(w, r, user.Version) {
+        return
+    }
+    linkAvatars(r, user)
+    sendSuccessResponse(w, http.StatusOK, "User retrieved successfully", user)
+}
+
+// UpdateMe changes the caller's profile. Passwords go through /me/password.
+// If-Match is honoured as on the other user writes.
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+    userID, ok := currentUserID(w, r)
+    if !ok {
+        return
+    }
+    var req updateMeRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+
+    version, ok := h.expectedVersion(w, r, userID)
+    if !ok {
+        return
+    }
+
+    // Omitted fields are kept; the write is conditional like PATCH /users/{id}
+    fields := map[string]string{}
+    if req.Name != "" {
+        fields["name"] = req.Name
+    }
+    if req.Email != "" {
+        fields["email"] = req.Email
+    }
+    patch, err := json.Marshal(fields)
+    if err != nil {
+        sendErrorResponse(w, http.StatusInternalServerError, "Failed to update user: "+err.Error())
+        return
+    }
+    updatedUser, err := h.userService.PatchUser(r.Context(), userID, services.MergePatchMediaType, patch, version)
+    if err != nil {
+        sendUserWriteError(w, err)
+        return
+    }
+    w.Header().Set("ETag", userETag(updatedUser.Version))
+    linkAvatars(r, updatedUser)
+    sendSuccessResponse(w, http.StatusOK, "User updated successfully", updatedUser)
+}
+
+func (h *UserHandler) ChangeMyPassword(w http.ResponseWriter, r *http.Request) {
+    userID, ok := sessionUserID(w, r)
+    if !ok {
+        return
+    }
+    var req changePasswordRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+
+    if err := h.userService.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+        switch {
+        case errors.Is(err, services.ErrUserNotFound):
+            sendErrorResponse(w, http.StatusNotFound, err.Error())
+        case errors.Is(err, services.ErrCurrentPasswordIncorrect):
+            sendErrorResponse(w, http.StatusForbidden, err.Error())
+        case errors.Is(err, services.ErrPasswordTooShort):
+            sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        default:
+            sendErrorResponse(w, http.StatusInternalServerError, "Failed to change password: "+err.Error())
+        }
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Password changed successfully", nil)
+}
+
+// DeleteMe deactivates the caller's own account (soft delete).
+func (h *UserHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+    userID, ok := sessionUserID(w, r)
+    if !ok {
+        return
+    }
+    if err := h.userService.DeleteUser(r.Context(), userID, repositories.AnyVersion); err != nil {
+        if errors.Is(err, services.ErrUserNotFound) {
+            sendErrorResponse(w, http.StatusNotFound, err.Error())
+        } else {
+            sendErrorResponse(w, http.StatusInternalServerError, "Failed to deactivate user: "+err.Error())
+        }
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Account deactivated successfully", nil)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"project/internal/authctx"
+	"project/internal/config"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/internal/services"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const oauthStateCookie = "oauth_state"
+
+type OAuthHandler struct {
+    oauthService *services.OAuthService
+}
+
+func NewOAuthHandler(cfg *config.Config) *OAuthHandler {
+    userRepo := repositories.NewUserRepositoryMongo()
+    audit := services.NewAuditService(repositories.NewAuditRepositoryMongo())
+    return &OAuthHandler{oauthService: services.NewOAuthService(userRepo, audit, cfg.OAuth, cfg.JWT)}
+}
+
+// oauthCookiePath scopes the state cookie to the provider's routes under the
+// version tree the request was served from.
+func oauthCookiePath(r *http.Request, provider string) string {
+    return authctx.APIVersionFrom(r.Context()).BasePath + "/auth/oauth/" + provider
+}
+
+// Start redirects the browser to the provider's authorization page.
+func (h *OAuthHandler) Start(w http.ResponseWriter, r *http.Request) {
+    provider := mux.Vars(r)["provider"]
+    authURL, stateToken, err := h.oauthService.StartAuth(r.Context(), provider)
+    if err != nil {
+        if strings.Contains(err.Error(), "unknown oauth provider") {
+            sendErrorResponse(w, http.StatusNotFound, err.Error())
+        } else {
+            sendErrorResponse(w, http.StatusBadGateway, err.Error())
+        }
+        return
+    }
+    http.SetCookie(w, &http.Cookie{
+        Name:     oauthStateCookie,
+        Value:    stateToken,
+        Path:     oauthCookiePath(r, provider),
+        MaxAge:   600,
+        HttpOnly: true,
+        Secure:   r.TLS != nil,
+        SameSite: http.SameSiteLaxMode,
+    })
+    w.Header().Del("Content-Type")
+    http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback exchanges the authorization code and returns a login response.
+func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+    provider := mux.Vars(r)["provider"]
+    q := r.URL.Query()
+    if providerErr := q.Get("error"); providerErr != "" {
+        sendErrorResponse(w, http.StatusBadRequest, "Provider returned error: "+providerErr)
+        return
+    }
+    cookie, err := r.Cookie(oauthStateCookie)
+    if err != nil {
+        sendErrorResponse(w, http.StatusBadRequest, "invalid oauth state")
+        return
+    }
+    // The state is single use
+    http.SetCookie(w, &http.Cookie{Name: oauthStateCookie, Path: oauthCookiePath(r, provider), MaxAge: -1, HttpOnly: true})
+
+    token, user, err := h.oauthService.HandleCallback(r.Context(), provider, q.Get("code"), q.Get("state"), cookie.Value)
+    if err != nil {
+        msg := err.Error()
+        switch {
+        case strings.Contains(msg, "unknown oauth provider"):
+            sendErrorResponse(w, http.StatusNotFound, msg)
+        case strings.Contains(msg, "failed to fetch"), strings.Contains(msg, "failed to exchange"), strings.Contains(msg, "misconfigured"):
+            sendErrorResponse(w, http.StatusBadGateway, msg)
+        case strings.Contains(msg, "failed to"):
+            sendErrorResponse(w, http.StatusInternalServerError, msg)
+        default:
+            sendErrorResponse(w, http.StatusUnauthorized, msg)
+        }
+        return
+    }
+    linkAvatars(r, user)
+    sendSuccessResponse(w, http.StatusOK, "Login successful", models.LoginResponse{Token: token, User: user})
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"project/internal/authctx"
+	"project/internal/config"
+	"project/internal/repositories"
+	"project/internal/services"
+)
+
+type OAuthServerHandler struct {
+    oauthServerService *services.OAuthServerService
+}
+
+func NewOAuthServerHandler(cfg *config.Config) *OAuthServerHandler {
+    service := services.NewOAuthServerService(
+        repositories.NewOAuthServerRepositoryMongo(),
+        repositories.NewUserRepositoryMongo(),
+        cfg.OAuthServer,
+        cfg.JWT,
+    )
+    return &OAuthServerHandler{oauthServerService: service}
+}
+
+type registerClientRequest struct {
+    Name         string   `json:"name" validate:"required,min=2,max=100"`
+    RedirectURIs []string `json:"redirect_uris"`
+    GrantTypes   []string `json:"grant_types"`
+    Scopes       []string `json:"scopes"`
+    Public       bool     `json:"public"`
+}
+
+type authorizeDecisionRequest struct {
+    Approve bool `json:"approve"`
+}
+
+// sendProtocolJSON writes a bare JSON body as required by the OAuth2/OIDC
+// specs, without our success/message envelope.
+func sendProtocolJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Cache-Control", "no-store")
+    w.Header().Set("Pragma", "no-cache")
+    w.WriteHeader(statusCode)
+    json.NewEncoder(w).Encode(body)
+}
+
+func sendOAuthError(w http.ResponseWriter, err error) {
+    var oerr *services.OAuthError
+    if !errors.As(err, &oerr) {
+        sendProtocolJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error", "error_description": err.Error()})
+        return
+    }
+    if oerr.Code == "invalid_client" {
+        w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+    }
+    sendProtocolJSON(w, oerr.Status, map[string]string{"error": oerr.Code, "error_description": oerr.Description})
+}
+
+func authorizeRequestFromQuery(q url.Values) services.AuthorizeRequest {
+    return services.AuthorizeRequest{
+        ResponseType:        q.Get("response_type"),
+        ClientID:            q.Get("client_id"),
+        RedirectURI:         q.Get("redirect_uri"),
+        Scope:               q.Get("scope"),
+        State:               q.Get("state"),
+        CodeChallenge:       q.Get("code_challenge"),
+        CodeChallengeMethod: q.Get("code_challenge_method"),
+        Nonce:               q.Get("nonce"),
+    }
+}
+
+func (h *OAuthServerHandler) RegisterClient(w http.ResponseWriter, r *http.Request) {
+    userID, ok := sessionUserID(w, r)
+    if !ok {
+        return
+    }
+    var req registerClientRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+    client, err := h.oauthServerService.RegisterClient(r.Context(), userID, req.Name, req.RedirectURIs, req.GrantTypes, req.Scopes, req.Public)
+    if err != nil {
+        switch {
+        case errors.Is(err, services.ErrClientNameRequired), errors.Is(err, services.ErrUnsupportedGrantType),
+            errors.Is(err, services.ErrPublicClientCredentials), errors.Is(err, services.ErrRedirectURIRequired),
+            errors.Is(err, services.ErrInvalidRedirectURI), errors.Is(err, services.ErrUnknownScope):
+            sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        case errors.Is(err, services.ErrPrivilegedClient):
+            sendErrorResponse(w, http.StatusForbidden, err.Error())
+        default:
+            sendErrorResponse(w, http.StatusInternalServerError, "Failed to register client: "+err.Error())
+        }
+        return
+    }
+    sendSuccessResponse(w, http.StatusCreated, "Client registered; store the secret now, it will not be shown again", client)
+}
+
+func (h *OAuthServerHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+    userID, ok := sessionUserID(w, r)
+    if !ok {
+        return
+    }
+    clients, err := h.oauthServerService.ListClients(userID)
+    if err != nil {
+        sendErrorResponse(w, http.StatusInternalServerError, "Failed to get clients: "+err.Error())
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Clients retrieved successfully", clients)
+}
+
+// Authorize returns what the client is asking for so the SPA can render a
+// consent screen.
+func (h *OAuthServerHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+    if _, ok := sessionUserID(w, r); !ok {
+        return
+    }
+    consent, err := h.oauthServerService.ValidateAuthorize(authorizeRequestFromQuery(r.URL.Query()))
+    if err != nil {
+        sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Consent required", consent)
+}
+
+// AuthorizeDecision records the user's answer on the consent screen and
+// returns the redirect the user agent must follow.
+func (h *OAuthServerHandler) AuthorizeDecision(w http.ResponseWriter, r *http.Request) {
+    userID, ok := sessionUserID(w, r)
+    if !ok {
+        return
+    }
+    var req authorizeDecisionRequest
+    if !decodeBody(w, r, &req) {
+        return
+    }
+    redirectTo, err := h.oauthServerService.Decide(r.Context(), userID, authorizeRequestFromQuery(r.URL.Query()), req.Approve)
+    if err != nil {
+        var oerr *services.OAuthError
+        if errors.As(err, &oerr) {
+            sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        } else {
+            sendErrorResponse(w, http.StatusInternalServerError, "Failed to authorize: "+err.Error())
+        }
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Authorization decision recorded", map[string]string{"redirect_to": redirectTo})
+}
+
+// Token is the RFC 6749 token endpoint (form-encoded request, bare JSON response).
+func (h *OAuthServerHandler) Token(w http.ResponseWriter, r *http.Request) {
+    if err := r.ParseForm(); err != nil {
+        sendOAuthError(w, &services.OAuthError{Code: "invalid_request", Description: "malformed form body", Status: http.StatusBadRequest})
+        return
+    }
+    req := services.TokenRequest{
+        GrantType:    r.PostForm.Get("grant_type"),
+        ClientID:     r.PostForm.Get("client_id"),
+        ClientSecret: r.PostForm.Get("client_secret"),
+        Code:         r.PostForm.Get("code"),
+        RedirectURI:  r.PostForm.Get("redirect_uri"),
+        CodeVerifier: r.PostForm.Get("code_verifier"),
+        RefreshToken: r.PostForm.Get("refresh_token"),
+        Scope:        r.PostForm.Get("scope"),
+    }
+    // client_secret_basic: credentials are form-urlencoded inside the header
+    if id, secret, ok := r.BasicAuth(); ok {
+        if decoded, err := url.QueryUnescape(id); err == nil {
+            id = decoded
+        }
+        if decoded, err := url.QueryUnescape(secret); err == nil {
+            secret = decoded
+        }
+        req.ClientID, req.ClientSecret = id, secret
+    }
+    resp, err := h.oauthServerService.Token(req)
+    if err != nil {
+        sendOAuthError(w, err)
+        return
+    }
+    sendProtocolJSON(w, http.StatusOK, resp)
+}
+
+func (h *OAuthServerHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+    p, ok := authctx.PrincipalFrom(r.Context())
+    if !ok || p.Method != authctx.MethodOAuth || p.UserID == "" {
+        w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+        sendProtocolJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token", "error_description": "an access token issued to a client is required"})
+        return
+    }
+    info, err := h.oauthServerService.UserInfo(r.Context(), p.UserID, p.Scopes)
+    if err != nil {
+        var oerr *services.OAuthError
+        if errors.As(err, &oerr) {
+            w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+            sendProtocolJSON(w, http.StatusForbidden, map[string]string{"error": oerr.Code, "error_description": oerr.Description})
+        } else {
+            sendProtocolJSON(w, http.StatusNotFound, map[string]string{"error": "invalid_token", "error_description": err.Error()})
+        }
+        return
+    }
+    sendProtocolJSON(w, http.StatusOK, info)
+}
+
+func (h *OAuthServerHandler) Discovery(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(h.oauthServerService.Discovery(r.Context()))
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type OrganizationHandler struct {
+    orgService *services.OrganizationService
+}
+
+func NewOrganizationHandler() *OrganizationHandler {
+    audit := services.NewAuditService(repositories.NewAuditRepositoryMongo())
+    orgService := services.NewOrganizationService(repositories.NewOrganizationRepositoryMongo(), repositories.NewUserRepositoryMongo(), audit)
+    return &OrganizationHandler{orgService: orgService}
+}
+
+// sendOrganizationError maps organization service errors to HTTP statuses.
+func sendOrganizationError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, services.ErrOrganizationNotFound), errors.Is(err, services.ErrMemberNotFound):
+        sendErrorResponse(w, http.StatusNotFound, err.Error())
+    case errors.Is(err, services.ErrOrganizationForbidden):
+        sendErrorResponse(w, http.StatusForbidden, err.Error())
+    case errors.Is(err, repositories.ErrSlugTaken), errors.Is(err, services.ErrOrganizationNotEmpty), errors.Is(err, services.ErrMemberEmailTaken):
+        sendErrorResponse(w, http.StatusConflict, err.Error())
+    default:
+        sendErrorResponse(w, http.StatusInternalServerError, "Organization request failed: "+err.Error())
+    }
+}
+
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+    var req models.OrganizationRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+    org, err := h.orgService.Create(r.Context(), req)
+    if err != nil {
+        if errors.Is(err, repositories.ErrSlugTaken) || errors.Is(err, services.ErrOrganizationForbidden) {
+            sendOrganizationError(w, err)
+        } else {
+            sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        }
+        return
+    }
+    sendSuccessResponse(w, http.StatusCreated, "Organization created successfully", org)
+}
+
+func (h *OrganizationHandler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+    orgs, err := h.orgService.List(r.Context())
+    if err != nil {
+        sendOrganizationError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Organizations retrieved successfully", orgs)
+}
+
+func (h *OrganizationHandler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+    org, err := h.orgService.Get(r.Context(), mux.Vars(r)["id"])
+    if err != nil {
+        sendOrganizationError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Organization retrieved successfully", org)
+}
+
+func (h *OrganizationHandler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+    var req models.OrganizationRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+    org, err := h.orgService.Update(r.Context(), mux.Vars(r)["id"], req)
+    if err != nil {
+        if errors.Is(err, services.ErrOrganizationNotFound) || errors.Is(err, repositories.ErrSlugTaken) || errors.Is(err, services.ErrOrganizationForbidden) {
+            sendOrganizationError(w, err)
+        } else {
+            sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        }
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Organization updated successfully", org)
+}
+
+func (h *OrganizationHandler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
+    if err := h.orgService.Delete(r.Context(), mux.Vars(r)["id"]); err != nil {
+        sendOrganizationError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Organization deleted successfully", nil)
+}
+
+// ListMembers handles GET /orgs/{id}/members with the GET /users filters.
+func (h *OrganizationHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+    filter, err := parseUserFilter(r)
+    if err != nil {
+        sendErrorResponse(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    members, err := h.orgService.ListMembers(r.Context(), mux.Vars(r)["id"], filter)
+    if err != nil {
+        sendOrganizationError(w, err)
+        return
+    }
+    linkAvatarList(r, members)
+    sendSuccessResponse(w, http.StatusOK, "Members retrieved successfully", members)
+}
+
+func (h *OrganizationHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+    var req models.AddMemberRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+    member, err := h.orgService.AddMember(r.Context(), mux.Vars(r)["id"], req.UserID, req.Role)
+    if err != nil {
+        sendOrganizationError(w, err)
+        return
+    }
+    linkAvatars(r, member)
+    sendSuccessResponse(w, http.StatusCreated, "Member added successfully", member)
+}
+
+func (h *OrganizationHandler) SetMemberRole(w http.ResponseWriter, r *http.Request) {
+    var req models.MemberRoleRequest
+    if !decodeValidated(w, r, &req) {
+        return
+    }
+    vars := mux.Vars(r)
+    member, err := h.orgService.SetMemberRole(r.Context(), vars["id"], vars["userId"], req.Role)
+    if err != nil {
+        sendOrganizationError(w, err)
+        return
+    }
+    linkAvatars(r, member)
+    sendSuccessResponse(w, http.StatusOK, "Member role updated successfully", member)
+}
+
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    if err := h.orgService.RemoveMember(r.Context(), vars["id"], vars["userId"]); err != nil {
+        sendOrganizationError(w, err)
+        return
+    }
+    sendSuccessResponse(w, http.StatusOK, "Member removed successfully", nil)
+}
@@ -1,34 +1,41 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"project/internal/config"
 	"project/internal/models"
 	"project/internal/repositories"
 	"project/internal/services"
 	"project/pkg/utils"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 type UserHandler struct {
-	userService *services.UserService
+	userService    *services.UserService
+	avatarService  *services.AvatarService
+	requireIfMatch bool
 }
 
-func NewUserHandler() *UserHandler {
+func NewUserHandler(cfg *config.Config) *UserHandler {
     // Switch to Mongo repository
     userRepo := repositories.NewUserRepositoryMongo()
-	userService := services.NewUserService(userRepo)
-	return &UserHandler{userService: userService}
+	audit := services.NewAuditService(repositories.NewAuditRepositoryMongo())
+	userService := services.NewUserService(userRepo, audit)
+	avatarService := services.NewAvatarService(userRepo, repositories.NewFileRepositoryMongo("avatars"), audit)
+	return &UserHandler{userService: userService, avatarService: avatarService, requireIfMatch: cfg.Server.RequireIfMatch}
 }
 
 // دالة مساعدة لإرجاع errors كـ JSON
 func sendErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(models.ErrorResponse{
+	writeEncoded(w, statusCode, models.ErrorResponse{
 		Success: false,
 		Message: message,
 	})
@@ -36,54 +43,120 @@ func sendErrorResponse(w http.ResponseWriter, statusCode int, message string) {
 
 // دالة مساعدة لإرجاع success كـ JSON
 func sendSuccessResponse(w http.ResponseWriter, statusCode int, message string, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	
-	response := models.SuccessResponse{
+	writeEncoded(w, statusCode, models.SuccessResponse{
 		Success: true,
 		Message: message,
 		Data:    data,
+	})
+}
+
+// sendValidationErrors returns field-level validation failures as JSON
+func sendValidationErrors(w http.ResponseWriter, errs []utils.ValidationError) {
+    writeEncoded(w, http.StatusBadRequest, map[string]interface{}{
+        "success": false,
+        "message": "Validation failed",
+        "errors":  errs,
+    })
+}
+
+// parseUserFilter reads the user list filters shared by GET /users and the
+// admin export: name, email, role, created_from/created_to (RFC 3339),
+// limit and offset.
+func parseUserFilter(r *http.Request) (models.UserFilter, error) {
+	q := r.URL.Query()
+	filter := models.UserFilter{Name: q.Get("name"), Email: q.Get("email"), Role: q.Get("role")}
+	if filter.Role != "" && filter.Role != models.RoleUser && filter.Role != models.RoleAdmin {
+		return filter, errors.New("role must be user or admin")
 	}
-	
-	json.NewEncoder(w).Encode(response)
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"created_from", &filter.CreatedFrom}, {"created_to", &filter.CreatedTo}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, errors.New(p.name + " must be an RFC 3339 timestamp")
+			}
+			*p.dst = &t
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  *int64
+	}{{"limit", &filter.Limit}, {"offset", &filter.Offset}} {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				return filter, errors.New(p.name + " must be a non-negative integer")
+			}
+			*p.dst = n
+		}
+	}
+	return filter, nil
 }
 
 func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.userService.GetAllUsers()
+	filter, err := parseUserFilter(r)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	users, err := h.userService.GetAllUsers(r.Context(), filter)
 	if err != nil {
 		sendErrorResponse(w, http.StatusInternalServerError, "Failed to get users: "+err.Error())
 		return
 	}
 	
+	linkAvatarList(r, users)
 	sendSuccessResponse(w, http.StatusOK, "Users retrieved successfully", users)
 }
 
+// SearchUsers handles GET /users/search?q=&limit=.
+func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			sendErrorResponse(w, http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		limit = n
+	}
+	results, err := h.userService.SearchUsers(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "search query") {
+			sendErrorResponse(w, http.StatusBadRequest, err.Error())
+		} else {
+			sendErrorResponse(w, http.StatusInternalServerError, "Failed to search users: "+err.Error())
+		}
+		return
+	}
+	for i := range results {
+		linkAvatars(r, results[i].User)
+	}
+	sendSuccessResponse(w, http.StatusOK, "Search completed successfully", results)
+}
+
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	
-	user, err := h.userService.GetUserByID(idStr)
+	user, err := h.userService.GetUserByID(r.Context(), idStr)
 	if err != nil {
 		sendErrorResponse(w, http.StatusNotFound, "User not found: "+err.Error())
 		return
 	}
+	if notModified(w, r, user.Version) {
+		return
+	}
 	
+	linkAvatars(r, user)
 	sendSuccessResponse(w, http.StatusOK, "User retrieved successfully", user)
 }
 
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
-	var user models.User
-	
-	// التحقق من Content-Type
-	contentType := r.Header.Get("Content-Type")
-	if contentType != "application/json" {
-		sendErrorResponse(w, http.StatusBadRequest, "Content-Type must be application/json")
-		return
-	}
-	
-	// تحليل JSON body
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		sendErrorResponse(w, http.StatusBadRequest, "Invalid JSON format: "+err.Error())
+	var user models.CreateUserRequest
+	if !decodeBody(w, r, &user) {
 		return
 	}
 	
@@ -117,17 +190,11 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
         sendErrorResponse(w, http.StatusBadRequest, "Validation error: "+err.Error())
         return
     } else if len(errs) > 0 {
-        w.Header().Set("Content-Type", "application/json")
-        w.WriteHeader(http.StatusBadRequest)
-        json.NewEncoder(w).Encode(map[string]interface{}{
-            "success": false,
-            "message": "Validation failed",
-            "errors":  errs,
-        })
+        sendValidationErrors(w, errs)
         return
     }
 
-    createdUser, err := h.userService.CreateUser(user)
+    createdUser, err := h.userService.CreateUser(r.Context(), user)
 	if err != nil {
 		// إذا كان الخطأ بسبب email مكرر
 		if strings.Contains(err.Error(), "already exists") {
@@ -138,43 +205,112 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	linkAvatars(r, createdUser)
 	sendSuccessResponse(w, http.StatusCreated, "User created successfully", createdUser)
 }
 
 // Login removed from User handler; handled by AuthHandler.
 
+// UpdateUser replaces the user with the request body (PUT semantics): every
+// writable field must be present, and empty values are stored as sent.
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	
-	var user models.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		sendErrorResponse(w, http.StatusBadRequest, "Invalid JSON format: "+err.Error())
+	var user models.UserReplacement
+	if !decodeBody(w, r, &user) {
+		return
+	}
+	version, ok := h.expectedVersion(w, r, idStr)
+	if !ok {
 		return
 	}
 	
-	updatedUser, err := h.userService.UpdateUser(idStr, user)
+	updatedUser, err := h.userService.ReplaceUser(r.Context(), idStr, user, version)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			sendErrorResponse(w, http.StatusNotFound, err.Error())
-		} else if strings.Contains(err.Error(), "already exists") {
-			sendErrorResponse(w, http.StatusBadRequest, err.Error())
-		} else {
-			sendErrorResponse(w, http.StatusInternalServerError, "Failed to update user: "+err.Error())
-		}
+		sendUserWriteError(w, err)
 		return
 	}
+	w.Header().Set("ETag", userETag(updatedUser.Version))
 	
+	linkAvatars(r, updatedUser)
+	sendSuccessResponse(w, http.StatusOK, "User updated successfully", updatedUser)
+}
+
+// PatchUser applies an RFC 7396 merge patch or RFC 6902 JSON Patch, chosen
+// by the request Content-Type.
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	idStr := mux.Vars(r)["id"]
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != services.MergePatchMediaType && mediaType != services.JSONPatchMediaType) {
+		w.Header().Set("Accept-Patch", services.MergePatchMediaType+", "+services.JSONPatchMediaType)
+		sendErrorResponse(w, http.StatusUnsupportedMediaType, "Content-Type must be "+services.MergePatchMediaType+" or "+services.JSONPatchMediaType)
+		return
+	}
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, utils.MaxJSONBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			sendErrorResponse(w, http.StatusRequestEntityTooLarge, "Request body must not exceed "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes")
+			return
+		}
+		sendErrorResponse(w, http.StatusBadRequest, "Failed to read request body: "+err.Error())
+		return
+	}
+
+	version, ok := h.expectedVersion(w, r, idStr)
+	if !ok {
+		return
+	}
+
+	updatedUser, err := h.userService.PatchUser(r.Context(), idStr, mediaType, patch, version)
+	if err != nil {
+		sendUserWriteError(w, err)
+		return
+	}
+	w.Header().Set("ETag", userETag(updatedUser.Version))
+	linkAvatars(r, updatedUser)
 	sendSuccessResponse(w, http.StatusOK, "User updated successfully", updatedUser)
 }
 
+// sendUserWriteError maps ReplaceUser/PatchUser errors to responses.
+func sendUserWriteError(w http.ResponseWriter, err error) {
+	var verrs *services.ValidationErrors
+	switch {
+	case errors.As(err, &verrs):
+		sendValidationErrors(w, verrs.Errors)
+	case errors.Is(err, services.ErrUnsupportedPatchType):
+		sendErrorResponse(w, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, services.ErrInvalidPatch):
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repositories.ErrVersionConflict):
+		sendErrorResponse(w, http.StatusPreconditionFailed, "Precondition failed: "+err.Error())
+	case errors.Is(err, services.ErrPatchConflict):
+		sendErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrUserNotFound):
+		sendErrorResponse(w, http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "already exists"):
+		sendErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		sendErrorResponse(w, http.StatusInternalServerError, "Failed to update user: "+err.Error())
+	}
+}
+
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	
-	err := h.userService.DeleteUser(idStr)
+	version, ok := h.expectedVersion(w, r, idStr)
+	if !ok {
+		return
+	}
+	
+	err := h.userService.DeleteUser(r.Context(), idStr, version)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			sendErrorResponse(w, http.StatusPreconditionFailed, "Precondition failed: "+err.Error())
+		} else if errors.Is(err, services.ErrUserNotFound) {
 			sendErrorResponse(w, http.StatusNotFound, err.Error())
 		} else {
 			sendErrorResponse(w, http.StatusInternalServerError, "Failed to delete user: "+err.Error())
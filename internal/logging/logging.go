@@ -0,0 +1,26 @@
+// Package logging configures the process-wide logger. Messages written with
+// the standard log package are logged at info level.
+package logging
+
+import (
+	"log/slog"
+	"os"
+)
+
+// level is shared by the handler so SetLevel takes effect immediately.
+var level = new(slog.LevelVar)
+
+// Setup installs a text logger on stderr as the default for slog and log.
+func Setup() {
+    slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+}
+
+// SetLevel changes the minimum level logged: debug, info, warn or error.
+func SetLevel(name string) error {
+    var l slog.Level
+    if err := l.UnmarshalText([]byte(name)); err != nil {
+        return err
+    }
+    level.Set(l)
+    return nil
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/repositories"
+)
+
+var adminUserRepo = repositories.NewUserRepositoryMongo()
+
+// RequireAdmin allows only users with the admin role in their tenant or in a
+// group granting the admin permission. Must run after Auth. API keys and
+// OAuth tokens additionally need the admin scope.
+func RequireAdmin(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        p, ok := authctx.PrincipalFrom(r.Context())
+        if !ok || p.UserID == "" {
+            writeAuthError(w, http.StatusUnauthorized, "Authentication required")
+            return
+        }
+        if !p.HasScope(models.ScopeAdmin) {
+            writeAuthError(w, http.StatusForbidden, "Credentials lack required scope: "+models.ScopeAdmin)
+            return
+        }
+        if p.HasPermission(models.ScopeAdmin) {
+            next.ServeHTTP(w, r)
+            return
+        }
+        user, err := adminUserRepo.ForTenant(p.TenantID).FindUserByID(p.UserID)
+        if err != nil || user.Role != models.RoleAdmin {
+            writeAuthError(w, http.StatusForbidden, "Admin role required")
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
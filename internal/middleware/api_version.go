@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"project/internal/authctx"
+	"project/internal/config"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// APIVersion marks requests routed to the API version tree mounted at
+// basePath, "" for the unversioned routes. A deprecated version also answers
+// with Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers; the
+// successor-version link points at the same path under successor, e.g.
+// "/api/v2". dep must have passed config validation.
+func APIVersion(version, basePath string, dep *config.APIDeprecation, successor string) mux.MiddlewareFunc {
+    var deprecation, sunset, docLink string
+    if dep != nil {
+        deprecatedAt, sunsetAt, _ := dep.Times()
+        deprecation = "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+        if !sunsetAt.IsZero() {
+            sunset = sunsetAt.UTC().Format(http.TimeFormat)
+        }
+        if dep.Link != "" {
+            docLink = "<" + dep.Link + `>; rel="deprecation"; type="text/html"`
+        }
+    }
+    info := authctx.APIVersion{Version: version, BasePath: basePath}
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if deprecation != "" {
+                h := w.Header()
+                h.Set("Deprecation", deprecation)
+                if sunset != "" {
+                    h.Set("Sunset", sunset)
+                }
+                if docLink != "" {
+                    h.Add("Link", docLink)
+                }
+                if successor != "" {
+                    h.Add("Link", "<"+successor+strings.TrimPrefix(r.URL.Path, basePath)+`>; rel="successor-version"`)
+                }
+            }
+            next.ServeHTTP(w, r.WithContext(authctx.WithAPIVersion(r.Context(), info)))
+        })
+    }
+}
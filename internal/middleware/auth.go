@@ -1,14 +1,47 @@
 package middleware
 
 import (
-	"context"
 	"encoding/json"
+	"log"
 	"net/http"
-	"project/internal/config"
+	"project/internal/authctx"
+	"project/internal/repositories"
+	"project/internal/services"
 	"project/pkg/utils"
 	"strings"
 )
 
+var apiKeyService = services.NewAPIKeyService(repositories.NewAPIKeyRepositoryMongo())
+
+var groupService = services.NewGroupService(repositories.NewGroupRepositoryMongo(), repositories.NewUserRepositoryMongo(), nil)
+
+func writeAuthError(w http.ResponseWriter, status int, message string) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(map[string]interface{}{
+        "success": false,
+        "message": message,
+    })
+}
+
+// serveAs continues the request as principal, in the principal's tenant.
+// Credentials only work in the tenant they were issued in. The user's group
+// memberships are loaded so permissions can be granted per group.
+func serveAs(w http.ResponseWriter, r *http.Request, next http.Handler, principal *authctx.Principal) {
+    if requested := authctx.TenantID(r.Context()); requested != "" && requested != principal.TenantID {
+        writeAuthError(w, http.StatusForbidden, "Credentials belong to another organization")
+        return
+    }
+    groups, perms, err := groupService.Memberships(principal.TenantID, principal.UserID)
+    if err != nil {
+        // Fail closed: continue with the user's own rights only
+        log.Printf("auth: loading groups of %s: %v", principal.UserID, err)
+    }
+    principal.Groups, principal.Permissions = groups, perms
+    ctx := authctx.WithTenant(authctx.WithPrincipal(r.Context(), principal), principal.TenantID)
+    next.ServeHTTP(w, r.WithContext(ctx))
+}
+
 func Auth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Skip auth for health check and public routes
@@ -16,15 +49,26 @@ func Auth(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 			return
 		}
+
+        // Machine clients authenticate with a personal API key instead of a JWT
+        if rawKey := r.Header.Get("X-API-Key"); rawKey != "" {
+            key, err := apiKeyService.Authenticate(rawKey)
+            if err != nil {
+                writeAuthError(w, http.StatusUnauthorized, "Invalid API key")
+                return
+            }
+            serveAs(w, r, next, &authctx.Principal{
+                UserID:   key.UserID.Hex(),
+                Method:   authctx.MethodAPIKey,
+                Scopes:   key.Scopes,
+                TenantID: key.TenantID,
+            })
+            return
+        }
 		
 		tokenString := r.Header.Get("Authorization")
 		if tokenString == "" {
-            w.Header().Set("Content-Type", "application/json")
-            w.WriteHeader(http.StatusUnauthorized)
-            json.NewEncoder(w).Encode(map[string]interface{}{
-                "success": false,
-                "message": "Authorization header required",
-            })
+            writeAuthError(w, http.StatusUnauthorized, "Authorization header required")
 			return
 		}
 		
@@ -33,20 +77,36 @@ func Auth(next http.Handler) http.Handler {
 			tokenString = tokenString[7:]
 		}
 		
-        cfg := config.LoadConfig()
-        claims, err := utils.ValidateJWTWithSecret(tokenString, cfg.JWT.Secret)
-		if err != nil {
-            w.Header().Set("Content-Type", "application/json")
-            w.WriteHeader(http.StatusUnauthorized)
-            json.NewEncoder(w).Encode(map[string]interface{}{
-                "success": false,
-                "message": "Invalid token",
-            })
+        claims, err := utils.ValidateJWTWithSecret(tokenString, jwtSecret)
+		// Single-purpose tokens (invitations, OAuth state) never authenticate
+		// requests, nor do tokens naming neither a user nor a client
+		if err != nil || claims.Purpose != "" || (claims.UserID == "" && claims.ClientID == "") {
+            writeAuthError(w, http.StatusUnauthorized, "Invalid token")
 			return
 		}
 		
 		// Add claims to context
-		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		principal := &authctx.Principal{UserID: claims.UserID, Method: authctx.MethodJWT, TenantID: claims.TenantID}
+		if claims.ClientID != "" {
+			// Access token issued by our OAuth server: limited to its granted scopes
+			principal.Method = authctx.MethodOAuth
+			principal.ClientID = claims.ClientID
+			principal.Scopes = strings.Fields(claims.Scope)
+		}
+		serveAs(w, r, next, principal)
 	})
-}
\ No newline at end of file
+}
+
+// RequireScope rejects API-key and OAuth requests not granted scope. Requests
+// authenticated with a login JWT act with the user's full rights and pass through.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if p, ok := authctx.PrincipalFrom(r.Context()); ok && !p.HasScope(scope) {
+                writeAuthError(w, http.StatusForbidden, "Credentials lack required scope: "+scope)
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
@@ -0,0 +1,288 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"project/internal/config"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressionPolicy is the resolved CompressionConfig.
+type compressionPolicy struct {
+    encodings    []string
+    minBytes     int
+    types        map[string]bool
+    typePrefixes []string // "text/" for "text/*"
+}
+
+var compression atomic.Pointer[compressionPolicy]
+
+func init() {
+    ConfigureCompression(config.Defaults().Compression)
+}
+
+// ConfigureCompression sets the encodings, threshold and content types used
+// by Compress.
+func ConfigureCompression(cfg config.CompressionConfig) {
+    p := &compressionPolicy{minBytes: cfg.MinBytes, types: map[string]bool{}}
+    for _, enc := range cfg.Encodings {
+        if _, ok := encoderPools[enc]; ok {
+            p.encodings = append(p.encodings, enc)
+        }
+    }
+    for _, t := range cfg.ContentTypes {
+        t = strings.ToLower(t)
+        if strings.HasSuffix(t, "/*") {
+            p.typePrefixes = append(p.typePrefixes, strings.TrimSuffix(t, "*"))
+        } else {
+            p.types[t] = true
+        }
+    }
+    compression.Store(p)
+}
+
+func (p *compressionPolicy) compressible(contentType string) bool {
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        return false
+    }
+    if p.types[mediaType] {
+        return true
+    }
+    for _, prefix := range p.typePrefixes {
+        if strings.HasPrefix(mediaType, prefix) {
+            return true
+        }
+    }
+    return false
+}
+
+// encoder is a compressing writer that can be pooled.
+type encoder interface {
+    io.WriteCloser
+    Flush() error
+    Reset(w io.Writer)
+}
+
+// encoderPools hold encoders per content coding; HTTP "deflate" is the zlib
+// format (RFC 9110 section 8.4.1.2).
+var encoderPools = map[string]*sync.Pool{
+    "zstd": {New: func() interface{} {
+        enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+        return enc
+    }},
+    "br":      {New: func() interface{} { return brotli.NewWriterLevel(nil, 5) }},
+    "gzip":    {New: func() interface{} { return gzip.NewWriter(nil) }},
+    "deflate": {New: func() interface{} { return zlib.NewWriter(nil) }},
+}
+
+// negotiateEncoding picks the offered coding the Accept-Encoding header rates
+// highest, preferring earlier offers on ties. "*" covers codings not named.
+// It returns "" when none is acceptable.
+func negotiateEncoding(acceptEncoding string, offered []string) string {
+    qualities := map[string]float64{}
+    for _, part := range strings.Split(acceptEncoding, ",") {
+        name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+        name = strings.ToLower(strings.TrimSpace(name))
+        if name == "" {
+            continue
+        }
+        q := 1.0
+        if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+            if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+                q = f
+            }
+        }
+        qualities[name] = q
+    }
+    best, bestQ := "", 0.0
+    for _, enc := range offered {
+        q, ok := qualities[enc]
+        if !ok {
+            q = qualities["*"]
+        }
+        if q > bestQ {
+            best, bestQ = enc, q
+        }
+    }
+    return best
+}
+
+// Compress compresses responses with the coding negotiated from
+// Accept-Encoding. Bodies are buffered up to the configured threshold so
+// short responses go out as is; responses that are already encoded, partial
+// or of a type outside the allowlist pass through. A strong ETag is weakened
+// on compressed responses, since the encoded bytes differ per coding.
+func Compress(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        p := compression.Load()
+        if len(p.encodings) == 0 {
+            next.ServeHTTP(w, r)
+            return
+        }
+        w.Header().Add("Vary", "Accept-Encoding")
+        encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), p.encodings)
+        if encoding == "" || r.Method == http.MethodHead {
+            next.ServeHTTP(w, r)
+            return
+        }
+        cw := &compressWriter{ResponseWriter: w, policy: p, encoding: encoding}
+        next.ServeHTTP(cw, r)
+        cw.close()
+    })
+}
+
+// compressWriter holds the body back until it knows whether to compress:
+// once MinBytes are buffered, on Flush, or when the handler returns.
+type compressWriter struct {
+    http.ResponseWriter
+    policy   *compressionPolicy
+    encoding string
+    status   int
+    buf      []byte
+    decided  bool
+    enc      encoder // nil when passing through
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+    if status < 200 {
+        w.ResponseWriter.WriteHeader(status) // informational, e.g. 103
+        return
+    }
+    if w.status != 0 {
+        return
+    }
+    w.status = status
+    h := w.Header()
+    if status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent ||
+        h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" || !w.policy.compressible(h.Get("Content-Type")) {
+        w.passThrough()
+    }
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+    if w.status == 0 {
+        w.WriteHeader(http.StatusOK)
+    }
+    if w.decided {
+        if w.enc != nil {
+            return w.enc.Write(b)
+        }
+        return w.ResponseWriter.Write(b)
+    }
+    w.buf = append(w.buf, b...)
+    if len(w.buf) >= w.policy.minBytes {
+        if err := w.startCompression(); err != nil {
+            return 0, err
+        }
+    }
+    return len(b), nil
+}
+
+// Flush starts compressing whatever has been buffered so streamed responses
+// are not held back.
+func (w *compressWriter) Flush() {
+    if w.status == 0 {
+        w.WriteHeader(http.StatusOK)
+    }
+    if !w.decided {
+        w.startCompression()
+    }
+    if w.enc != nil {
+        w.enc.Flush()
+    }
+    http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
+
+func (w *compressWriter) passThrough() error {
+    w.decided = true
+    w.ResponseWriter.WriteHeader(w.status)
+    if len(w.buf) == 0 {
+        return nil
+    }
+    _, err := w.ResponseWriter.Write(w.buf)
+    w.buf = nil
+    return err
+}
+
+func (w *compressWriter) startCompression() error {
+    w.decided = true
+    h := w.Header()
+    h.Del("Content-Length")
+    h.Set("Content-Encoding", w.encoding)
+    if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+        h.Set("ETag", "W/"+etag)
+    }
+    w.ResponseWriter.WriteHeader(w.status)
+    w.enc = encoderPools[w.encoding].Get().(encoder)
+    w.enc.Reset(w.ResponseWriter)
+    _, err := w.enc.Write(w.buf)
+    w.buf = nil
+    return err
+}
+
+// close sends a body that stayed under the threshold as is, or finishes the
+// compressed stream.
+func (w *compressWriter) close() {
+    switch {
+    case w.status == 0:
+        // Nothing written; net/http sends an empty 200
+    case !w.decided:
+        w.passThrough()
+    case w.enc != nil:
+        w.enc.Close()
+        encoderPools[w.encoding].Put(w.enc)
+        w.enc = nil
+    }
+}
+
+// DecompressRequest accepts request bodies sent with Content-Encoding gzip,
+// for bulk uploads. The handler's own body limit then applies to the
+// decompressed size. Other codings are refused with 415 and an
+// Accept-Encoding listing gzip (RFC 7694).
+func DecompressRequest(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+        case "", "identity":
+        case "gzip", "x-gzip":
+            zr, err := gzip.NewReader(r.Body)
+            if err != nil {
+                writeAuthError(w, http.StatusBadRequest, "Request body is not valid gzip")
+                return
+            }
+            r.Body = &gzipBody{Reader: zr, body: r.Body}
+            r.Header.Del("Content-Encoding")
+            r.Header.Del("Content-Length")
+            r.ContentLength = -1
+        default:
+            w.Header().Set("Accept-Encoding", "gzip")
+            writeAuthError(w, http.StatusUnsupportedMediaType, "Content-Encoding must be gzip or identity")
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+
+// gzipBody reads the decompressed request body and closes the original.
+type gzipBody struct {
+    *gzip.Reader
+    body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+    b.Reader.Close()
+    return b.body.Close()
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"project/internal/config"
+	"project/internal/errorsink"
+	"project/internal/repositories"
+	"project/internal/services"
+	"time"
+)
+
+// Settings used by the middlewares, set once at startup by Configure. The
+// zero values match config.Defaults so tests can use the middlewares as is.
+var (
+    jwtSecret        = config.Defaults().JWT.Secret
+    tenantBaseDomain string
+)
+
+// Configure applies cfg to the middlewares. Call it before serving requests.
+func Configure(cfg *config.Config) {
+    Reconfigure(cfg)
+    jwtSecret = cfg.JWT.Secret
+    tenantBaseDomain = cfg.Tenancy.BaseDomain
+    if cfg.ErrorSink.WebhookURL != "" {
+        SetErrorSink(&errorsink.Webhook{URL: cfg.ErrorSink.WebhookURL})
+    }
+    idempotencyService = services.NewIdempotencyService(
+        repositories.NewIdempotencyRepositoryMongo(),
+        time.Duration(cfg.Idempotency.TTLHours)*time.Hour,
+    )
+}
+
+// Reconfigure applies the reloadable sections of cfg; config.Store calls it
+// through a subscription after every reload.
+func Reconfigure(cfg *config.Config) {
+    ConfigureRateLimit(cfg.RateLimit)
+    ConfigureCORS(cfg.CORS)
+    ConfigureSecurityHeaders(cfg.Security)
+    ConfigureCompression(cfg.Compression)
+}
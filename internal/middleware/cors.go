@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"net/http"
+	"project/internal/config"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+)
+
+// corsPolicy is a config.CORSPolicy prepared for matching.
+type corsPolicy struct {
+    anyOrigin   bool
+    origins     map[string]bool
+    wildcards   []wildcardOrigin
+    methods     []string
+    headers     map[string]bool
+    allowedList string
+    exposed     string
+    credentials bool
+    maxAge      string
+}
+
+// wildcardOrigin matches scheme://<one or more labels><suffix>, where suffix
+// is ".example.com" with the port, if any.
+type wildcardOrigin struct {
+    prefix string
+    suffix string
+}
+
+func (w wildcardOrigin) match(origin string) bool {
+    if !strings.HasPrefix(origin, w.prefix) || !strings.HasSuffix(origin, w.suffix) {
+        return false
+    }
+    sub := origin[len(w.prefix) : len(origin)-len(w.suffix)]
+    return sub != "" && !strings.ContainsAny(sub, "/:@")
+}
+
+var corsPolicies atomic.Pointer[[]*corsPolicy]
+
+// ConfigureCORS sets the policies applied by CORS. Policies are tried in
+// order and the top-level policy last.
+func ConfigureCORS(cfg config.CORSConfig) {
+    policies := make([]*corsPolicy, 0, len(cfg.Policies)+1)
+    for _, p := range cfg.Policies {
+        if len(p.AllowedMethods) == 0 {
+            p.AllowedMethods = cfg.AllowedMethods
+        }
+        if len(p.AllowedHeaders) == 0 {
+            p.AllowedHeaders = cfg.AllowedHeaders
+        }
+        if len(p.ExposedHeaders) == 0 {
+            p.ExposedHeaders = cfg.ExposedHeaders
+        }
+        if p.MaxAgeSeconds == 0 {
+            p.MaxAgeSeconds = cfg.MaxAgeSeconds
+        }
+        policies = append(policies, compileCORSPolicy(p))
+    }
+    policies = append(policies, compileCORSPolicy(cfg.CORSPolicy))
+    corsPolicies.Store(&policies)
+}
+
+func compileCORSPolicy(p config.CORSPolicy) *corsPolicy {
+    c := &corsPolicy{
+        origins:     map[string]bool{},
+        headers:     map[string]bool{},
+        credentials: p.AllowCredentials,
+        exposed:     strings.Join(p.ExposedHeaders, ", "),
+    }
+    for _, origin := range p.AllowedOrigins {
+        origin = strings.ToLower(strings.TrimRight(origin, "/"))
+        if origin == "*" {
+            c.anyOrigin = true
+        } else if scheme, rest, ok := strings.Cut(origin, "://*"); ok {
+            c.wildcards = append(c.wildcards, wildcardOrigin{prefix: scheme + "://", suffix: rest})
+        } else {
+            c.origins[origin] = true
+        }
+    }
+    for _, m := range p.AllowedMethods {
+        c.methods = append(c.methods, strings.ToUpper(m))
+    }
+    allowed := make([]string, 0, len(p.AllowedHeaders))
+    for _, h := range p.AllowedHeaders {
+        c.headers[strings.ToLower(h)] = true
+        allowed = append(allowed, h)
+    }
+    c.allowedList = strings.Join(allowed, ", ")
+    if p.MaxAgeSeconds > 0 {
+        c.maxAge = strconv.Itoa(p.MaxAgeSeconds)
+    }
+    return c
+}
+
+func (c *corsPolicy) allows(origin string) bool {
+    if c.anyOrigin || c.origins[origin] {
+        return true
+    }
+    for _, w := range c.wildcards {
+        if w.match(origin) {
+            return true
+        }
+    }
+    return false
+}
+
+// policyFor returns the first policy allowing origin, if any.
+func policyFor(origin string) *corsPolicy {
+    policies := corsPolicies.Load()
+    if policies == nil {
+        return nil
+    }
+    origin = strings.ToLower(origin)
+    for _, p := range *policies {
+        if p.allows(origin) {
+            return p
+        }
+    }
+    return nil
+}
+
+// allowOrigin sets the headers shared by preflight and actual responses.
+func (c *corsPolicy) allowOrigin(h http.Header, origin string) {
+    if c.anyOrigin && !c.credentials {
+        h.Set("Access-Control-Allow-Origin", "*")
+    } else {
+        h.Set("Access-Control-Allow-Origin", origin)
+    }
+    if c.credentials {
+        h.Set("Access-Control-Allow-Credentials", "true")
+    }
+}
+
+// CORS answers preflight requests and adds CORS headers to responses for
+// allowed origins. It wraps the whole router rather than being added with
+// Use, because mux only runs middlewares for matched routes and a preflight
+// OPTIONS request matches none. Preflights for a method the path does not
+// serve are left to the router, which answers with its JSON 404 or 405.
+func CORS(router *mux.Router) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        // Responses depend on the Origin, so caches must key on it
+        w.Header().Add("Vary", "Origin")
+        origin := r.Header.Get("Origin")
+        if origin == "" {
+            router.ServeHTTP(w, r)
+            return
+        }
+        policy := policyFor(origin)
+
+        requestedMethod := r.Header.Get("Access-Control-Request-Method")
+        if r.Method != http.MethodOptions || requestedMethod == "" {
+            if policy != nil {
+                policy.allowOrigin(w.Header(), origin)
+                if policy.exposed != "" {
+                    w.Header().Set("Access-Control-Expose-Headers", policy.exposed)
+                }
+            }
+            router.ServeHTTP(w, r)
+            return
+        }
+
+        w.Header().Add("Vary", "Access-Control-Request-Method")
+        w.Header().Add("Vary", "Access-Control-Request-Headers")
+        if policy == nil {
+            writeAuthError(w, http.StatusForbidden, "Origin not allowed")
+            return
+        }
+        requestedMethod = strings.ToUpper(requestedMethod)
+        served := servedMethods(router, r, append([]string{requestedMethod}, policy.methods...))
+        if !containsMethod(served, requestedMethod) {
+            router.ServeHTTP(w, r)
+            return
+        }
+        if !containsMethod(policy.methods, requestedMethod) {
+            writeAuthError(w, http.StatusForbidden, "Method not allowed for this origin")
+            return
+        }
+        var methods []string
+        for _, m := range policy.methods {
+            if containsMethod(served, m) {
+                methods = append(methods, m)
+            }
+        }
+        for _, h := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+            if h = strings.ToLower(strings.TrimSpace(h)); h != "" && !policy.headers[h] {
+                writeAuthError(w, http.StatusForbidden, "Header not allowed: "+h)
+                return
+            }
+        }
+
+        policy.allowOrigin(w.Header(), origin)
+        w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+        if policy.allowedList != "" {
+            w.Header().Set("Access-Control-Allow-Headers", policy.allowedList)
+        }
+        if policy.maxAge != "" {
+            w.Header().Set("Access-Control-Max-Age", policy.maxAge)
+        }
+        w.WriteHeader(http.StatusNoContent)
+    })
+}
+
+// servedMethods returns the candidates some route of router serves for the
+// path of r. Leaf routes carry the matchers of their parent subrouters, so
+// each is tried on its own; a subrouter's Match would answer a method
+// mismatch with its MethodNotAllowedHandler instead of reporting it.
+func servedMethods(router *mux.Router, r *http.Request, candidates []string) []string {
+    var served []string
+    router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+        if route.GetHandler() == nil {
+            return nil
+        }
+        for _, m := range candidates {
+            if containsMethod(served, m) {
+                continue
+            }
+            probe := r.Clone(r.Context())
+            probe.Method = m
+            var match mux.RouteMatch
+            if route.Match(probe, &match) {
+                served = append(served, m)
+            }
+        }
+        return nil
+    })
+    return served
+}
+
+func containsMethod(methods []string, method string) bool {
+    for _, m := range methods {
+        if m == method {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"project/internal/authctx"
+	"project/internal/config"
+	"project/internal/repositories"
+	"project/internal/services"
+	"project/pkg/utils"
+	"time"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// idempotencyService is replaced by Configure with the configured TTL.
+var idempotencyService = services.NewIdempotencyService(
+    repositories.NewIdempotencyRepositoryMongo(),
+    time.Duration(config.Defaults().Idempotency.TTLHours)*time.Hour,
+)
+
+// recordingWriter passes the response through while keeping a copy of it.
+type recordingWriter struct {
+    http.ResponseWriter
+    status int
+    body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+    if w.status == 0 {
+        w.status = status
+    }
+    w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+    if w.status == 0 {
+        w.status = http.StatusOK
+    }
+    w.body.Write(b)
+    return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
+
+// idempotencyScope is the caller keys are stored under: the user, or the
+// client for client_credentials tokens. IDs are unique across tenants. It is
+// "" for unauthenticated requests.
+func idempotencyScope(r *http.Request) string {
+    p, ok := authctx.PrincipalFrom(r.Context())
+    switch {
+    case !ok:
+        return ""
+    case p.UserID != "":
+        return p.UserID
+    case p.ClientID != "":
+        return "client:" + p.ClientID
+    }
+    return ""
+}
+
+// Idempotency makes POST requests carrying an Idempotency-Key safe to retry
+// using the default Mongo-backed store. Must run after Auth.
+func Idempotency(next http.Handler) http.Handler {
+    return IdempotencyWith(idempotencyService)(next)
+}
+
+// IdempotencyWith stores the first response per caller and key and replays
+// it on retries with the same request. Reusing a key with a different request
+// is rejected with 422, and keys from unauthenticated callers with 401; 5xx
+// responses are not stored so the client can retry. Bodies are limited to
+// utils.MaxJSONBodyBytes.
+// Responses are stored as sent, so do not use it on endpoints that return
+// secrets such as new API keys or client credentials.
+func IdempotencyWith(svc *services.IdempotencyService) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            key := r.Header.Get("Idempotency-Key")
+            if r.Method != http.MethodPost || key == "" {
+                next.ServeHTTP(w, r)
+                return
+            }
+            if len(key) > maxIdempotencyKeyLength {
+                writeAuthError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+                return
+            }
+            // Keys are scoped to the caller, so there must be one
+            scope := idempotencyScope(r)
+            if scope == "" {
+                writeAuthError(w, http.StatusUnauthorized, "Idempotency-Key requires an authenticated request")
+                return
+            }
+            // Bounded before any handler limit applies; keyed POSTs carry JSON
+            body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, utils.MaxJSONBodyBytes))
+            var tooLarge *http.MaxBytesError
+            switch {
+            case errors.As(err, &tooLarge):
+                writeAuthError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must not exceed %d bytes", tooLarge.Limit))
+                return
+            case err != nil:
+                writeAuthError(w, http.StatusBadRequest, "Failed to read request body")
+                return
+            }
+            r.Body = io.NopCloser(bytes.NewReader(body))
+
+            h := sha256.New()
+            io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+            h.Write(body)
+
+            stored, id, err := svc.Begin(scope, key, hex.EncodeToString(h.Sum(nil)))
+            switch {
+            case errors.Is(err, services.ErrIdempotencyKeyReused):
+                writeAuthError(w, http.StatusUnprocessableEntity, err.Error())
+                return
+            case errors.Is(err, services.ErrIdempotencyInProgress):
+                w.Header().Set("Retry-After", "1")
+                writeAuthError(w, http.StatusConflict, err.Error())
+                return
+            case err != nil:
+                writeAuthError(w, http.StatusInternalServerError, "Failed to check Idempotency-Key")
+                return
+            }
+            if stored != nil {
+                for name, values := range stored.ResponseHeaders {
+                    w.Header()[name] = values
+                }
+                w.Header().Set("Idempotent-Replayed", "true")
+                w.WriteHeader(stored.ResponseStatus)
+                w.Write(stored.ResponseBody)
+                return
+            }
+
+            rec := &recordingWriter{ResponseWriter: w}
+            next.ServeHTTP(rec, r)
+            if rec.status == 0 {
+                rec.status = http.StatusOK
+            }
+            if rec.status >= 500 {
+                if err := svc.Release(id); err != nil {
+                    log.Printf("idempotency: failed to release key: %v", err)
+                }
+                return
+            }
+            headers := w.Header().Clone()
+            headers.Del("Set-Cookie")
+            // The body is recorded before Compress encodes it; the replay is
+            // encoded again for the retrying client
+            headers.Del("Content-Encoding")
+            headers.Del("Content-Length")
+            headers.Del("Vary")
+            if err := svc.Complete(id, rec.status, headers, rec.body.Bytes()); err != nil {
+                log.Printf("idempotency: failed to store response: %v", err)
+            }
+        })
+    }
+}
@@ -1,24 +1,41 @@
 package middleware
 
 import (
-	"encoding/json"
 	"net/http"
-	"time"
 )
 
-// JSONMiddleware ensures Content-Type header and recovers panics with JSON.
+// defaultTypeWriter sets a Content-Type of JSON when the handler has not
+// chosen one by the time the header is written.
+type defaultTypeWriter struct {
+    http.ResponseWriter
+    wroteHeader bool
+}
+
+func (w *defaultTypeWriter) WriteHeader(status int) {
+    if !w.wroteHeader {
+        w.wroteHeader = true
+        if w.Header().Get("Content-Type") == "" {
+            w.Header().Set("Content-Type", "application/json")
+        }
+    }
+    w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *defaultTypeWriter) Write(b []byte) (int, error) {
+    if !w.wroteHeader {
+        w.WriteHeader(http.StatusOK)
+    }
+    return w.ResponseWriter.Write(b)
+}
+
+func (w *defaultTypeWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
+
+// JSONMiddleware makes JSON the default Content-Type; routes serving other
+// content set their own. Panics are handled by Recover.
 func JSONMiddleware(next http.Handler) http.Handler {
     return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-        w.Header().Set("Content-Type", "application/json")
-        defer func(start time.Time) {
-            if rec := recover(); rec != nil {
-                w.WriteHeader(http.StatusInternalServerError)
-                json.NewEncoder(w).Encode(map[string]interface{}{
-                    "success": false,
-                    "message": "Internal server error",
-                })
-            }
-        }(time.Now())
-        next.ServeHTTP(w, r)
+        next.ServeHTTP(&defaultTypeWriter{ResponseWriter: w}, r)
     })
-}
\ No newline at end of file
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+	"project/internal/codec"
+)
+
+// Negotiate picks the response encoding from the Accept header for the
+// handlers' send helpers. Clients accepting none of the codecs get JSON
+// rather than 406, since routes such as avatars and exports serve their own
+// types and ignore the codec.
+func Negotiate(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        c, ok := codec.Negotiate(r.Header.Get("Accept"))
+        if !ok {
+            c = codec.JSON
+        }
+        w.Header().Add("Vary", "Accept")
+        next.ServeHTTP(codec.WithCodec(w, c), r)
+    })
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"project/internal/authctx"
+	"project/internal/config"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitIdle is how long an untouched bucket is kept; after it the bucket
+// would be full again anyway.
+const rateLimitIdle = 10 * time.Minute
+
+// bucket is a token bucket for one client.
+type bucket struct {
+    tokens float64
+    last   time.Time
+}
+
+// rateLimiter keeps a bucket per client IP. Its limits can change while
+// running; existing buckets keep their tokens up to the new burst.
+type rateLimiter struct {
+    mu        sync.Mutex
+    perSecond float64
+    burst     float64
+    buckets   map[string]*bucket
+    lastSweep time.Time
+}
+
+var limiter = &rateLimiter{buckets: map[string]*bucket{}}
+
+// ConfigureRateLimit sets the limits applied by RateLimit.
+func ConfigureRateLimit(cfg config.RateLimitConfig) {
+    limiter.mu.Lock()
+    defer limiter.mu.Unlock()
+    limiter.perSecond = float64(cfg.RequestsPerMinute) / 60
+    limiter.burst = float64(cfg.Burst)
+}
+
+// allow takes a token for key, or returns how long until one is available.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if l.perSecond <= 0 {
+        return true, 0
+    }
+    if now.Sub(l.lastSweep) > rateLimitIdle {
+        for k, b := range l.buckets {
+            if now.Sub(b.last) > rateLimitIdle {
+                delete(l.buckets, k)
+            }
+        }
+        l.lastSweep = now
+    }
+    b, ok := l.buckets[key]
+    if !ok {
+        b = &bucket{tokens: l.burst, last: now}
+        l.buckets[key] = b
+    }
+    b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.perSecond)
+    b.last = now
+    if b.tokens >= 1 {
+        b.tokens--
+        return true, 0
+    }
+    wait := time.Duration((1 - b.tokens) / l.perSecond * float64(time.Second))
+    return false, wait
+}
+
+// RateLimit rejects clients that exceed the configured request rate with 429
+// and a Retry-After header. Clients are told apart by their IP address, so
+// it must run after RequestInfo.
+func RateLimit(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        ok, wait := limiter.allow(authctx.RequestInfoFrom(r.Context()).IP, time.Now())
+        if !ok {
+            w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+            writeAuthError(w, http.StatusTooManyRequests, "Too many requests")
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"project/internal/authctx"
+	"project/internal/errorsink"
+	"runtime/debug"
+	"time"
+)
+
+// errorSink receives recovered panics; nil only logs them.
+var errorSink errorsink.Sink
+
+// SetErrorSink sets where Recover reports panics; nil disables reporting.
+func SetErrorSink(sink errorsink.Sink) {
+    errorSink = sink
+}
+
+// headerTracker records whether the response header was sent.
+type headerTracker struct {
+    http.ResponseWriter
+    wroteHeader bool
+}
+
+func (w *headerTracker) WriteHeader(status int) {
+    w.wroteHeader = true
+    w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *headerTracker) Write(b []byte) (int, error) {
+    w.wroteHeader = true
+    return w.ResponseWriter.Write(b)
+}
+
+func (w *headerTracker) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
+
+// Recover turns a panicking handler into a 500 JSON response carrying the
+// request ID. The panic and its stack are logged and reported to the error
+// sink. If the handler had already started its response, the connection is
+// aborted instead so the client does not take a truncated body as complete.
+// Run it after RequestInfo so the request ID is known.
+func Recover(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        tracker := &headerTracker{ResponseWriter: w}
+        defer func() {
+            rec := recover()
+            if rec == nil {
+                return
+            }
+            if rec == http.ErrAbortHandler {
+                panic(rec)
+            }
+            info := authctx.RequestInfoFrom(r.Context())
+            stack := debug.Stack()
+            slog.Error("panic serving request",
+                "request_id", info.RequestID,
+                "method", r.Method,
+                "path", r.URL.Path,
+                "panic", rec,
+                "stack", string(stack),
+            )
+            if errorSink != nil {
+                errorSink.Report(r.Context(), errorsink.Event{
+                    Time:      time.Now().UTC(),
+                    RequestID: info.RequestID,
+                    Method:    r.Method,
+                    Path:      r.URL.Path,
+                    Message:   fmt.Sprint(rec),
+                    Stack:     string(stack),
+                })
+            }
+            if tracker.wroteHeader {
+                panic(http.ErrAbortHandler)
+            }
+            w.Header().Set("Content-Type", "application/json")
+            w.WriteHeader(http.StatusInternalServerError)
+            json.NewEncoder(w).Encode(map[string]interface{}{
+                "success":    false,
+                "message":    "Internal server error",
+                "request_id": info.RequestID,
+            })
+        }()
+        next.ServeHTTP(tracker, r)
+    })
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"project/internal/authctx"
+)
+
+// RequestIDHeader carries the request ID; a proxy may set it, and it is
+// echoed on the response.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// requestID keeps a well-formed incoming ID so logs can be correlated with
+// the proxy's, and generates one otherwise.
+func requestID(r *http.Request) string {
+    if id := r.Header.Get(RequestIDHeader); id != "" && len(id) <= maxRequestIDLength && printableASCII(id) {
+        return id
+    }
+    b := make([]byte, 16)
+    rand.Read(b)
+    return hex.EncodeToString(b)
+}
+
+func printableASCII(s string) bool {
+    for i := 0; i < len(s); i++ {
+        if s[i] < 0x21 || s[i] > 0x7e {
+            return false
+        }
+    }
+    return true
+}
+
+// RequestInfo records the client address, user agent and request ID on the
+// request context. X-Forwarded-For is kept separately because clients can
+// set it freely.
+func RequestInfo(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        ip := r.RemoteAddr
+        if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+            ip = host
+        }
+        id := requestID(r)
+        w.Header().Set(RequestIDHeader, id)
+        ctx := authctx.WithRequestInfo(r.Context(), authctx.RequestInfo{
+            IP:           ip,
+            ForwardedFor: r.Header.Get("X-Forwarded-For"),
+            UserAgent:    r.UserAgent(),
+            RequestID:    id,
+        })
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
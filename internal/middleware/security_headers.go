@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"net/http"
+	"project/internal/config"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+)
+
+// headerPolicy is the resolved set of security headers for a route; empty
+// values are not sent.
+type headerPolicy struct {
+    hsts    string
+    headers [][2]string
+}
+
+type securityPolicies struct {
+    base   *headerPolicy
+    routes map[string]*headerPolicy
+}
+
+var securityHeaders atomic.Pointer[securityPolicies]
+
+func init() {
+    ConfigureSecurityHeaders(config.Defaults().Security)
+}
+
+// ConfigureSecurityHeaders sets the headers added by SecurityHeaders.
+func ConfigureSecurityHeaders(cfg config.SecurityConfig) {
+    p := &securityPolicies{base: resolveHeaders(cfg.SecurityHeaders), routes: map[string]*headerPolicy{}}
+    for template, override := range cfg.Routes {
+        p.routes[template] = resolveHeaders(mergeHeaders(cfg.SecurityHeaders, override))
+    }
+    securityHeaders.Store(p)
+}
+
+// mergeHeaders overlays the non-empty values of override on base.
+func mergeHeaders(base, override config.SecurityHeaders) config.SecurityHeaders {
+    pick := func(b, o string) string {
+        if o != "" {
+            return o
+        }
+        return b
+    }
+    return config.SecurityHeaders{
+        HSTS:                  pick(base.HSTS, override.HSTS),
+        ContentSecurityPolicy: pick(base.ContentSecurityPolicy, override.ContentSecurityPolicy),
+        ContentTypeOptions:    pick(base.ContentTypeOptions, override.ContentTypeOptions),
+        ReferrerPolicy:        pick(base.ReferrerPolicy, override.ReferrerPolicy),
+        FrameOptions:          pick(base.FrameOptions, override.FrameOptions),
+    }
+}
+
+func resolveHeaders(h config.SecurityHeaders) *headerPolicy {
+    on := func(v string) string {
+        if v == "off" {
+            return ""
+        }
+        return v
+    }
+    p := &headerPolicy{hsts: on(h.HSTS)}
+    for _, kv := range [][2]string{
+        {"Content-Security-Policy", on(h.ContentSecurityPolicy)},
+        {"X-Content-Type-Options", on(h.ContentTypeOptions)},
+        {"Referrer-Policy", on(h.ReferrerPolicy)},
+        {"X-Frame-Options", on(h.FrameOptions)},
+    } {
+        if kv[1] != "" {
+            p.headers = append(p.headers, kv)
+        }
+    }
+    return p
+}
+
+// isHTTPS reports whether the client connected over TLS, directly or through
+// a proxy that terminates it.
+func isHTTPS(r *http.Request) bool {
+    return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// unversionedTemplate strips an /api/{version} prefix from a route template,
+// so overrides written for "/users/{id}/avatar" cover every version.
+func unversionedTemplate(template string) string {
+    rest, ok := strings.CutPrefix(template, "/api/v")
+    if !ok {
+        return template
+    }
+    i := strings.IndexByte(rest, '/')
+    if i <= 0 || strings.Trim(rest[:i], "0123456789") != "" {
+        return template
+    }
+    return rest[i:]
+}
+
+// SecurityHeaders adds the configured security headers, with the overrides
+// of the matched route. Handlers may still replace them.
+func SecurityHeaders(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        policies := securityHeaders.Load()
+        policy := policies.base
+        if route := mux.CurrentRoute(r); route != nil {
+            if template, err := route.GetPathTemplate(); err == nil {
+                if p, ok := policies.routes[template]; ok {
+                    policy = p
+                } else if p, ok := policies.routes[unversionedTemplate(template)]; ok {
+                    policy = p
+                }
+            }
+        }
+        h := w.Header()
+        for _, kv := range policy.headers {
+            h.Set(kv[0], kv[1])
+        }
+        if policy.hsts != "" && isHTTPS(r) {
+            h.Set("Strict-Transport-Security", policy.hsts)
+        }
+        next.ServeHTTP(w, r)
+    })
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"project/internal/authctx"
+	"project/internal/repositories"
+	"project/internal/services"
+	"strings"
+)
+
+// TenantHeader names the organization, by slug or ID, a request is for.
+const TenantHeader = "X-Tenant-ID"
+
+var organizationService = services.NewOrganizationService(
+    repositories.NewOrganizationRepositoryMongo(),
+    repositories.NewUserRepositoryMongo(),
+    nil,
+)
+
+// Tenant scopes requests to the organization named by the X-Tenant-ID header
+// or the subdomain of TENANT_BASE_DOMAIN.
+func Tenant(next http.Handler) http.Handler {
+    return TenantWith(organizationService, tenantBaseDomain)(next)
+}
+
+// tenantRef returns the organization slug or ID the request names, if any.
+// The header wins over the subdomain.
+func tenantRef(r *http.Request, baseDomain string) string {
+    if ref := strings.TrimSpace(r.Header.Get(TenantHeader)); ref != "" {
+        return ref
+    }
+    if baseDomain == "" {
+        return ""
+    }
+    host := r.Host
+    if h, _, err := net.SplitHostPort(host); err == nil {
+        host = h
+    }
+    sub, ok := strings.CutSuffix(strings.ToLower(host), "."+baseDomain)
+    if !ok || sub == "" || strings.Contains(sub, ".") {
+        return ""
+    }
+    return sub
+}
+
+// TenantWith resolves the named organization and stores its ID on the
+// context. Requests naming none stay in the default tenant; unknown
+// organizations get 404. Auth later checks that credentials match.
+func TenantWith(orgs *services.OrganizationService, baseDomain string) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            ref := tenantRef(r, baseDomain)
+            if ref == "" {
+                next.ServeHTTP(w, r)
+                return
+            }
+            org, err := orgs.ResolveTenant(ref)
+            if err != nil {
+                writeAuthError(w, http.StatusNotFound, "Unknown organization: "+ref)
+                return
+            }
+            next.ServeHTTP(w, r.WithContext(authctx.WithTenant(r.Context(), org.ID.Hex())))
+        })
+    }
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// API key scopes that can be granted to a personal key.
+const (
+    ScopeUsersRead  = "users:read"
+    ScopeUsersWrite = "users:write"
+    ScopeAdmin      = "admin" // also requires the user to have the admin role
+)
+
+var APIKeyScopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeAdmin}
+
+// APIKey is a personal key used by scripts and integrations instead of a JWT.
+// Only the public prefix and a SHA-256 hash of the full key are stored.
+type APIKey struct {
+    ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+    UserID     primitive.ObjectID `json:"user_id" bson:"user_id"`
+    TenantID   string             `json:"-" bson:"tenant_id,omitempty"` // tenant of the owning user
+    Name       string             `json:"name" bson:"name"`
+    Prefix     string             `json:"prefix" bson:"prefix"`
+    Hash       string             `json:"-" bson:"hash"`
+    Scopes     []string           `json:"scopes" bson:"scopes"`
+    ExpiresAt  *time.Time         `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+    LastUsedAt *time.Time         `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+    CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+    RevokedAt  *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+type APIKeyResponse struct {
+    ID         string     `json:"id"`
+    Name       string     `json:"name"`
+    Prefix     string     `json:"prefix"`
+    Scopes     []string   `json:"scopes"`
+    ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+    LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+    CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKeyResponse carries the plaintext key; it is returned exactly once.
+type CreatedAPIKeyResponse struct {
+    APIKeyResponse
+    Key string `json:"key"`
+}
+
+func (k *APIKey) ToResponse() *APIKeyResponse {
+    return &APIKeyResponse{
+        ID:         k.ID.Hex(),
+        Name:       k.Name,
+        Prefix:     k.Prefix,
+        Scopes:     k.Scopes,
+        ExpiresAt:  k.ExpiresAt,
+        LastUsedAt: k.LastUsedAt,
+        CreatedAt:  k.CreatedAt,
+    }
+}
+
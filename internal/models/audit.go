@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Audit actions recorded by the services.
+const (
+    AuditUserCreated        = "user.created"
+    AuditUserUpdated        = "user.updated"
+    AuditUserDeleted        = "user.deleted"
+    AuditPasswordChanged    = "user.password_changed"
+    AuditRoleChanged        = "user.role_changed"
+    AuditUsersImported      = "user.bulk_imported"
+    AuditUsersExported      = "user.exported"
+    AuditLoginSucceeded     = "auth.login_succeeded"
+    AuditLoginFailed        = "auth.login_failed"
+    AuditLogExported        = "audit.exported"
+    AuditOrgCreated         = "org.created"
+    AuditOrgUpdated         = "org.updated"
+    AuditOrgDeleted         = "org.deleted"
+    AuditMemberAdded        = "org.member_added"
+    AuditMemberRemoved      = "org.member_removed"
+    AuditGroupCreated       = "group.created"
+    AuditGroupUpdated       = "group.updated"
+    AuditGroupDeleted       = "group.deleted"
+    AuditGroupJoined        = "group.member_added"
+    AuditGroupLeft          = "group.member_removed"
+    AuditInvitationSent     = "invitation.sent"
+    AuditInvitationRevoked  = "invitation.revoked"
+    AuditInvitationAccepted = "invitation.accepted"
+    AuditAvatarChanged      = "user.avatar_changed"
+)
+
+// AuditEvent is an append-only record of a security-relevant or data-changing
+// action. Before/After hold only the changed fields, with secrets redacted.
+type AuditEvent struct {
+    ID           primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+    Timestamp    time.Time              `json:"timestamp" bson:"timestamp"`
+    ActorID      string                 `json:"actor_id,omitempty" bson:"actor_id,omitempty"`
+    ActorMethod  string                 `json:"actor_method,omitempty" bson:"actor_method,omitempty"`
+    ClientID     string                 `json:"client_id,omitempty" bson:"client_id,omitempty"`
+    TenantID     string                 `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+    Action       string                 `json:"action" bson:"action"`
+    TargetType   string                 `json:"target_type,omitempty" bson:"target_type,omitempty"`
+    TargetID     string                 `json:"target_id,omitempty" bson:"target_id,omitempty"`
+    Before       map[string]interface{} `json:"before,omitempty" bson:"before,omitempty"`
+    After        map[string]interface{} `json:"after,omitempty" bson:"after,omitempty"`
+    Metadata     map[string]interface{} `json:"metadata,omitempty" bson:"metadata,omitempty"`
+    IP           string                 `json:"ip,omitempty" bson:"ip,omitempty"`
+    ForwardedFor string                 `json:"forwarded_for,omitempty" bson:"forwarded_for,omitempty"`
+    UserAgent    string                 `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
+    RequestID    string                 `json:"request_id,omitempty" bson:"request_id,omitempty"`
+}
+
+// AuditFilter selects audit events; zero values match everything except
+// TenantID, where empty means the default tenant. AllTenants ignores
+// TenantID and is meant for platform admins only.
+type AuditFilter struct {
+    TenantID   string
+    AllTenants bool
+    ActorID    string
+    Action     string
+    TargetType string
+    TargetID   string
+    From       *time.Time
+    To         *time.Time
+    Limit      int64
+    Offset     int64
+}
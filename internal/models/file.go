@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// StoredFile describes a file kept in GridFS.
+type StoredFile struct {
+    ID          string    `json:"id"`
+    Name        string    `json:"name"`
+    ContentType string    `json:"content_type"`
+    Length      int64     `json:"length"`
+    UploadDate  time.Time `json:"upload_date"`
+}
+
+// Avatar sizes. Thumbnails are square and named by their edge in pixels;
+// the original is kept as uploaded.
+const (
+    AvatarOriginal    = "original"
+    DefaultAvatarSize = "128"
+)
+
+var AvatarThumbnailSizes = []int{64, 128, 256}
+
+// Avatar references a user's profile picture in GridFS, one file per size.
+type Avatar struct {
+    Files     map[string]string `bson:"files"` // GridFS file ID by size
+    UpdatedAt time.Time         `bson:"updated_at"`
+}
+
+// Version identifies the upload; avatar URLs carry it so caches can keep
+// each version forever.
+func (a *Avatar) Version() string {
+    return a.Files[AvatarOriginal]
+}
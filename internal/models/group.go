@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles within a group. Owners manage the group and its members.
+const (
+    GroupRoleOwner  = "owner"
+    GroupRoleMember = "member"
+)
+
+// Group is a team of users in one tenant. Permissions (from APIKeyScopes) are
+// granted to every member, e.g. "admin" makes all members admins.
+type Group struct {
+    ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+    TenantID    string             `json:"-" bson:"tenant_id,omitempty"`
+    Name        string             `json:"name" bson:"name"`
+    Description string             `json:"description,omitempty" bson:"description,omitempty"`
+    Permissions []string           `json:"permissions" bson:"permissions"`
+    Members     []GroupMember      `json:"members" bson:"members"`
+    CreatedBy   string             `json:"created_by,omitempty" bson:"created_by,omitempty"`
+    CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+    UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type GroupMember struct {
+    UserID  string    `json:"user_id" bson:"user_id"`
+    Role    string    `json:"role" bson:"role"`
+    AddedAt time.Time `json:"added_at" bson:"added_at"`
+}
+
+// RoleOf returns the user's role in the group, or "" if they are not a member.
+func (g *Group) RoleOf(userID string) string {
+    for _, m := range g.Members {
+        if m.UserID == userID {
+            return m.Role
+        }
+    }
+    return ""
+}
+
+// GroupRequest creates or updates a group. Only admins may set Permissions.
+type GroupRequest struct {
+    Name        string   `json:"name" validate:"required,min=2,max=100"`
+    Description string   `json:"description,omitempty" validate:"max=500"`
+    Permissions []string `json:"permissions,omitempty"`
+}
+
+type GroupMemberRequest struct {
+    UserID string `json:"user_id" validate:"required"`
+    Role   string `json:"role,omitempty" validate:"omitempty,oneof=owner member"`
+}
+
+// UserGroup is one of a user's groups as listed by GET /users/{id}/groups.
+type UserGroup struct {
+    ID          string   `json:"id"`
+    Name        string   `json:"name"`
+    Role        string   `json:"role"`
+    Permissions []string `json:"permissions"`
+}
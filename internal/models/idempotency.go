@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Idempotency record states.
+const (
+    IdempotencyPending   = "pending"
+    IdempotencyCompleted = "completed"
+)
+
+// IdempotencyRecord stores the first response to a request made with an
+// Idempotency-Key so retries can be answered without running it again.
+type IdempotencyRecord struct {
+    ID              string              `json:"id" bson:"_id"` // hash of user scope and key
+    UserID          string              `json:"user_id" bson:"user_id"`
+    Key             string              `json:"key" bson:"key"`
+    RequestHash     string              `json:"request_hash" bson:"request_hash"`
+    State           string              `json:"state" bson:"state"`
+    ResponseStatus  int                 `json:"response_status,omitempty" bson:"response_status,omitempty"`
+    ResponseHeaders map[string][]string `json:"response_headers,omitempty" bson:"response_headers,omitempty"`
+    ResponseBody    []byte              `json:"-" bson:"response_body,omitempty"`
+    CreatedAt       time.Time           `json:"created_at" bson:"created_at"`
+    ExpiresAt       time.Time           `json:"expires_at" bson:"expires_at"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Bulk import file formats.
+const (
+    ImportFormatCSV    = "csv"
+    ImportFormatNDJSON = "ndjson"
+)
+
+// Per-row import outcomes.
+const (
+    ImportRowCreated   = "created"
+    ImportRowInvited   = "invited" // an invitation was sent instead of creating the user
+    ImportRowValid     = "valid"   // dry run: would be created or invited
+    ImportRowInvalid   = "invalid"
+    ImportRowDuplicate = "duplicate"
+    ImportRowFailed    = "failed"
+)
+
+// Import job states.
+const (
+    ImportJobQueued    = "queued"
+    ImportJobRunning   = "running"
+    ImportJobCompleted = "completed"
+    ImportJobFailed    = "failed"
+)
+
+// ImportRow is one user in an import file. Rows without a password become
+// invitations; the invitee picks a password when accepting.
+type ImportRow struct {
+    Name     string `json:"name" validate:"required,min=2"`
+    Email    string `json:"email" validate:"required,email"`
+    Password string `json:"password,omitempty" validate:"omitempty,min=8"`
+}
+
+type ImportRowResult struct {
+    Row          int      `json:"row" bson:"row"`
+    Email        string   `json:"email,omitempty" bson:"email,omitempty"`
+    Status       string   `json:"status" bson:"status"`
+    UserID       string   `json:"user_id,omitempty" bson:"user_id,omitempty"`
+    InvitationID string   `json:"invitation_id,omitempty" bson:"invitation_id,omitempty"`
+    // Only returned by synchronous imports; job reports are stored, so they
+    // keep the invitation ID and admins resend the invitation instead.
+    InviteToken  string   `json:"invite_token,omitempty" bson:"-"`
+    Errors       []string `json:"errors,omitempty" bson:"errors,omitempty"`
+}
+
+type ImportReport struct {
+    DryRun    bool              `json:"dry_run" bson:"dry_run"`
+    Total     int               `json:"total" bson:"total"`
+    Created   int               `json:"created" bson:"created"`
+    Invited   int               `json:"invited" bson:"invited"`
+    Valid     int               `json:"valid" bson:"valid"`
+    Invalid   int               `json:"invalid" bson:"invalid"`
+    Duplicate int               `json:"duplicate" bson:"duplicate"`
+    Failed    int               `json:"failed" bson:"failed"`
+    Rows      []ImportRowResult `json:"rows" bson:"rows"`
+}
+
+// ImportJob tracks an import running in the background. Jobs expire with
+// their report, which holds invitation tokens.
+type ImportJob struct {
+    ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+    Status     string             `json:"status" bson:"status"`
+    Format     string             `json:"format" bson:"format"`
+    DryRun     bool               `json:"dry_run" bson:"dry_run"`
+    CreatedBy  string             `json:"created_by,omitempty" bson:"created_by,omitempty"`
+    TenantID   string             `json:"-" bson:"tenant_id,omitempty"`
+    Report     *ImportReport      `json:"report,omitempty" bson:"report,omitempty"`
+    Error      string             `json:"error,omitempty" bson:"error,omitempty"`
+    CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+    FinishedAt *time.Time         `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+    ExpiresAt  time.Time          `json:"expires_at" bson:"expires_at"`
+}
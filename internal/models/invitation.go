@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invitation states. Pending invitations past ExpiresAt can still be resent.
+const (
+    InvitationPending  = "pending"
+    InvitationAccepted = "accepted"
+    InvitationRevoked  = "revoked"
+)
+
+// Invitation asks someone to join the tenant with Role. The invitee chooses
+// their name and password when accepting. Only a SHA-256 hash of the current
+// token is stored, so resending invalidates earlier tokens.
+type Invitation struct {
+    ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+    TenantID   string             `json:"-" bson:"tenant_id,omitempty"`
+    Email      string             `json:"email" bson:"email"`
+    Name       string             `json:"name,omitempty" bson:"name,omitempty"` // suggested; the invitee may change it
+    Role       string             `json:"role,omitempty" bson:"role,omitempty"`
+    Status     string             `json:"status" bson:"status"`
+    TokenHash  string             `json:"-" bson:"token_hash"`
+    InvitedBy  string             `json:"invited_by,omitempty" bson:"invited_by,omitempty"`
+    SendCount  int                `json:"send_count" bson:"send_count"`
+    UserID     string             `json:"user_id,omitempty" bson:"user_id,omitempty"` // set once accepted
+    CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+    SentAt     time.Time          `json:"sent_at" bson:"sent_at"`
+    ExpiresAt  time.Time          `json:"expires_at" bson:"expires_at"`
+    AcceptedAt *time.Time         `json:"accepted_at,omitempty" bson:"accepted_at,omitempty"`
+    RevokedAt  *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// Expired reports whether a pending invitation can no longer be accepted.
+func (i *Invitation) Expired(now time.Time) bool {
+    return i.Status == InvitationPending && !now.Before(i.ExpiresAt)
+}
+
+type InvitationRequest struct {
+    Email          string `json:"email" validate:"required,email"`
+    Name           string `json:"name,omitempty" validate:"omitempty,min=2"`
+    Role           string `json:"role,omitempty" validate:"omitempty,oneof=user admin"`
+    ExpiresInHours int    `json:"expires_in_hours,omitempty" validate:"omitempty,min=1,max=720"`
+}
+
+// InvitationResponse carries the token, which is shown only when the
+// invitation is created or resent; delivering it to the invitee is up to
+// the caller.
+type InvitationResponse struct {
+    Invitation *Invitation `json:"invitation"`
+    Token      string      `json:"token"`
+}
+
+type AcceptInvitationRequest struct {
+    Token    string `json:"token" validate:"required"`
+    Name     string `json:"name,omitempty" validate:"omitempty,min=2"` // defaults to the invitation's name
+    Password string `json:"password" validate:"required,min=8"`
+}
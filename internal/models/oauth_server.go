@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OpenID Connect scopes understood by our authorization server. Clients may
+// additionally request any of the APIKeyScopes.
+const (
+    ScopeOpenID        = "openid"
+    ScopeProfile       = "profile"
+    ScopeEmail         = "email"
+    ScopeOfflineAccess = "offline_access"
+)
+
+const (
+    GrantAuthorizationCode = "authorization_code"
+    GrantClientCredentials = "client_credentials"
+    GrantRefreshToken      = "refresh_token"
+)
+
+var OAuthScopes = append([]string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopeOfflineAccess}, APIKeyScopes...)
+
+// OAuthClient is an application registered to obtain tokens from us.
+// Public clients (SPAs, mobile) have no secret and must use PKCE.
+type OAuthClient struct {
+    ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+    ClientID     string             `json:"client_id" bson:"client_id"`
+    SecretHash   string             `json:"-" bson:"secret_hash,omitempty"`
+    Name         string             `json:"name" bson:"name"`
+    RedirectURIs []string           `json:"redirect_uris" bson:"redirect_uris"`
+    GrantTypes   []string           `json:"grant_types" bson:"grant_types"`
+    Scopes       []string           `json:"scopes" bson:"scopes"`
+    Public       bool               `json:"public" bson:"public"`
+    OwnerID      primitive.ObjectID `json:"owner_id" bson:"owner_id"`
+    TenantID     string             `json:"-" bson:"tenant_id,omitempty"` // owner's tenant, used by client_credentials tokens
+    CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type OAuthClientResponse struct {
+    ClientID     string    `json:"client_id"`
+    Name         string    `json:"name"`
+    RedirectURIs []string  `json:"redirect_uris"`
+    GrantTypes   []string  `json:"grant_types"`
+    Scopes       []string  `json:"scopes"`
+    Public       bool      `json:"public"`
+    CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreatedOAuthClientResponse carries the client secret; it is returned exactly once.
+type CreatedOAuthClientResponse struct {
+    OAuthClientResponse
+    ClientSecret string `json:"client_secret,omitempty"`
+}
+
+func (c *OAuthClient) ToResponse() *OAuthClientResponse {
+    return &OAuthClientResponse{
+        ClientID:     c.ClientID,
+        Name:         c.Name,
+        RedirectURIs: c.RedirectURIs,
+        GrantTypes:   c.GrantTypes,
+        Scopes:       c.Scopes,
+        Public:       c.Public,
+        CreatedAt:    c.CreatedAt,
+    }
+}
+
+// OAuthAuthorizationCode is a single-use code handed to the client's redirect URI.
+type OAuthAuthorizationCode struct {
+    ID                  primitive.ObjectID `bson:"_id,omitempty"`
+    CodeHash            string             `bson:"code_hash"`
+    ClientID            string             `bson:"client_id"`
+    UserID              primitive.ObjectID `bson:"user_id"`
+    TenantID            string             `bson:"tenant_id,omitempty"`
+    RedirectURI         string             `bson:"redirect_uri"`
+    Scope               string             `bson:"scope"`
+    CodeChallenge       string             `bson:"code_challenge,omitempty"`
+    CodeChallengeMethod string             `bson:"code_challenge_method,omitempty"`
+    Nonce               string             `bson:"nonce,omitempty"`
+    AuthTime            time.Time          `bson:"auth_time"`
+    ExpiresAt           time.Time          `bson:"expires_at"`
+    UsedAt              *time.Time         `bson:"used_at,omitempty"`
+}
+
+type OAuthRefreshToken struct {
+    ID        primitive.ObjectID `bson:"_id,omitempty"`
+    TokenHash string             `bson:"token_hash"`
+    ClientID  string             `bson:"client_id"`
+    UserID    primitive.ObjectID `bson:"user_id"`
+    TenantID  string             `bson:"tenant_id,omitempty"`
+    Scope     string             `bson:"scope"`
+    AuthTime  time.Time          `bson:"auth_time"`
+    ExpiresAt time.Time          `bson:"expires_at"`
+    CreatedAt time.Time          `bson:"created_at"`
+    RevokedAt *time.Time         `bson:"revoked_at,omitempty"`
+}
+
+// OAuthTokenResponse is the RFC 6749 token endpoint response body.
+type OAuthTokenResponse struct {
+    AccessToken  string `json:"access_token"`
+    TokenType    string `json:"token_type"`
+    ExpiresIn    int    `json:"expires_in"`
+    RefreshToken string `json:"refresh_token,omitempty"`
+    IDToken      string `json:"id_token,omitempty"`
+    Scope        string `json:"scope,omitempty"`
+}
+
+// OAuthConsentResponse describes a pending authorization for the consent screen.
+type OAuthConsentResponse struct {
+    ClientID    string   `json:"client_id"`
+    ClientName  string   `json:"client_name"`
+    RedirectURI string   `json:"redirect_uri"`
+    Scopes      []string `json:"scopes"`
+    State       string   `json:"state,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Organization is a tenant. Its users carry its ID as their TenantID; users
+// without one belong to the default tenant, whose admins manage organizations.
+type Organization struct {
+    ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+    Name      string             `json:"name" bson:"name"`
+    Slug      string             `json:"slug" bson:"slug"` // subdomain and X-Tenant-ID value
+    CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+    UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// OrganizationRequest creates or renames an organization.
+type OrganizationRequest struct {
+    Name string `json:"name" validate:"required,min=2,max=100"`
+    Slug string `json:"slug" validate:"required,min=2,max=63"`
+}
+
+// AddMemberRequest moves a user of the default tenant into an organization.
+type AddMemberRequest struct {
+    UserID string `json:"user_id" validate:"required"`
+    Role   string `json:"role,omitempty" validate:"omitempty,oneof=user admin"`
+}
+
+// MemberRoleRequest sets a member's role within their organization.
+type MemberRoleRequest struct {
+    Role string `json:"role" validate:"required,oneof=user admin"`
+}
@@ -7,20 +7,98 @@ import (
 )
 
 type User struct {
-    ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-    Name      string             `json:"name" validate:"required,min=2" bson:"name"`
-    Email     string             `json:"email" validate:"required,email" bson:"email"`
-    Password  string             `json:"password" validate:"required,min=8" bson:"password"`
-    CreatedAt time.Time          `json:"created_at" bson:"created_at"`
-    UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
-    DeletedAt *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+    ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+    Name       string             `json:"name" validate:"required,min=2" bson:"name"`
+    Email      string             `json:"email" validate:"required,email" bson:"email"`
+    Password   string             `json:"password" validate:"required,min=8" bson:"password"`
+    CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+    UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+    DeletedAt  *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+    Role       string             `json:"role,omitempty" bson:"role,omitempty"`
+    Identities []ExternalIdentity `json:"-" bson:"identities,omitempty"`
+    Version    int64              `json:"-" bson:"version"`
+    SearchName string             `json:"-" bson:"search_name,omitempty"` // normalized name for the text index
+    TenantID   string             `json:"-" bson:"tenant_id,omitempty"` // organization ID; empty for the default tenant
+    Avatar     *Avatar            `json:"-" bson:"avatar,omitempty"`
+}
+
+// User roles. Users without a role are regular users.
+const (
+    RoleUser  = "user"
+    RoleAdmin = "admin"
+)
+
+// ExternalIdentity links a user to an account at an external identity provider.
+type ExternalIdentity struct {
+    Provider string    `json:"provider" bson:"provider"`
+    Subject  string    `json:"subject" bson:"subject"`
+    Email    string    `json:"email" bson:"email"`
+    LinkedAt time.Time `json:"linked_at" bson:"linked_at"`
+}
+
+// CreateUserRequest is the body of POST /users. Server-managed fields such as
+// id, role and the timestamps are not part of it and are rejected if sent.
+type CreateUserRequest struct {
+    Name     string `json:"name" validate:"required,min=2"`
+    Email    string `json:"email" validate:"required,email"`
+    Password string `json:"password" validate:"required,min=8"`
+}
+
+// UserReplacement is the client-writable representation of a user. PUT sends
+// it whole and PATCH documents are applied to it, so both share the User
+// validation rules. Password is write-only: omitted keeps the current one.
+type UserReplacement struct {
+    Name     string `json:"name" validate:"required,min=2"`
+    Email    string `json:"email" validate:"required,email"`
+    Password string `json:"password,omitempty" validate:"omitempty,min=8"`
+}
+
+// UserFilter selects users for listing and export; zero values match
+// everything and a zero Limit returns all matches.
+type UserFilter struct {
+    Name        string // case-insensitive substring
+    Email       string
+    Role        string
+    CreatedFrom *time.Time
+    CreatedTo   *time.Time
+    Limit       int64
+    Offset      int64
+}
+
+// ScoredUser is a search match with its relevance score.
+type ScoredUser struct {
+    User  `bson:",inline"`
+    Score float64 `bson:"score"`
+}
+
+// UserSearchResult is one hit of GET /users/search. Highlights holds the
+// matched fields, HTML-escaped, with matches wrapped in <mark>.
+type UserSearchResult struct {
+    User       *UserResponse     `json:"user"`
+    Score      float64           `json:"score"`
+    Highlights map[string]string `json:"highlights,omitempty"`
 }
 
 type UserResponse struct {
     ID        string    `json:"id"`
     Name      string    `json:"name"`
     Email     string    `json:"email"`
+    Role      string    `json:"role,omitempty"`
+    TenantID  string    `json:"tenant_id,omitempty"`
+    AvatarURL string    `json:"avatar_url,omitempty"`
+    Version   int64     `json:"version"`
     CreatedAt time.Time `json:"created_at"`
+    // AvatarVersion is set when the user has an avatar; handlers turn it
+    // into AvatarURL with LinkAvatar, as the path depends on the API version.
+    AvatarVersion string `json:"-"`
+}
+
+// LinkAvatar sets AvatarURL under basePath, the root of the API version
+// the response is served from.
+func (r *UserResponse) LinkAvatar(basePath string) {
+    if r != nil && r.AvatarVersion != "" {
+        r.AvatarURL = basePath + "/users/" + r.ID + "/avatar?v=" + r.AvatarVersion
+    }
 }
 // ErrorResponse هيكل لردود الأخطاء بشكل JSON
 type ErrorResponse struct {
@@ -40,10 +118,17 @@ type LoginResponse struct {
     User  *UserResponse `json:"user"`
 }
 func (u *User) ToResponse() *UserResponse {
-    return &UserResponse{
+    res := &UserResponse{
         ID:        u.ID.Hex(),
         Name:      u.Name,
         Email:     u.Email,
+        Role:      u.Role,
+        TenantID:  u.TenantID,
+        Version:   u.Version,
         CreatedAt: u.CreatedAt,
     }
+    if u.Avatar != nil {
+        res.AvatarVersion = u.Avatar.Version()
+    }
+    return res
 }
\ No newline at end of file
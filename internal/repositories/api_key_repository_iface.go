@@ -0,0 +1,11 @@
+package repositories
+
+import "project/internal/models"
+
+type APIKeyRepositoryInterface interface {
+    Create(key models.APIKey) (*models.APIKey, error)
+    FindByUser(userIDStr string) ([]models.APIKey, error)
+    FindByPrefix(prefix string) (*models.APIKey, error)
+    Revoke(userIDStr string, idStr string) error
+    TouchLastUsed(idStr string) error
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"project/internal/database"
+	"project/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepositoryMongo struct{}
+
+func NewAPIKeyRepositoryMongo() *APIKeyRepositoryMongo { return &APIKeyRepositoryMongo{} }
+
+func (r *APIKeyRepositoryMongo) col() *mongo.Collection {
+    return database.GetMongoDB().Collection("api_keys")
+}
+
+func (r *APIKeyRepositoryMongo) Create(key models.APIKey) (*models.APIKey, error) {
+    key.ID = primitive.NewObjectID()
+    key.CreatedAt = time.Now()
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.col().InsertOne(ctx, key)
+    if err != nil { return nil, err }
+    return &key, nil
+}
+
+// FindByUser returns the user's keys that have not been revoked, newest first.
+func (r *APIKeyRepositoryMongo) FindByUser(userIDStr string) ([]models.APIKey, error) {
+    userID, err := primitive.ObjectIDFromHex(userIDStr)
+    if err != nil { return nil, err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+    cur, err := r.col().Find(ctx, bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}, opts)
+    if err != nil { return nil, err }
+    defer cur.Close(ctx)
+    res := []models.APIKey{}
+    if err := cur.All(ctx, &res); err != nil { return nil, err }
+    return res, nil
+}
+
+func (r *APIKeyRepositoryMongo) FindByPrefix(prefix string) (*models.APIKey, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var k models.APIKey
+    err := r.col().FindOne(ctx, bson.M{"prefix": prefix, "revoked_at": bson.M{"$exists": false}}).Decode(&k)
+    if err != nil { return nil, err }
+    return &k, nil
+}
+
+// Revoke marks a key as revoked. It returns mongo.ErrNoDocuments when the key
+// does not exist, belongs to another user or is already revoked.
+func (r *APIKeyRepositoryMongo) Revoke(userIDStr string, idStr string) error {
+    userID, err := primitive.ObjectIDFromHex(userIDStr)
+    if err != nil { return err }
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    filter := bson.M{"_id": id, "user_id": userID, "revoked_at": bson.M{"$exists": false}}
+    res, err := r.col().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+    if err != nil { return err }
+    if res.MatchedCount == 0 { return mongo.ErrNoDocuments }
+    return nil
+}
+
+func (r *APIKeyRepositoryMongo) TouchLastUsed(idStr string) error {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err = r.col().UpdateByID(ctx, id, bson.M{"$set": bson.M{"last_used_at": time.Now()}})
+    return err
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+	"project/internal/models"
+)
+
+// AuditRepositoryInterface is append-only: events are never updated or deleted.
+type AuditRepositoryInterface interface {
+    Insert(event models.AuditEvent) error
+    Find(filter models.AuditFilter) ([]models.AuditEvent, error)
+    Stream(ctx context.Context, filter models.AuditFilter, fn func(event models.AuditEvent) error) error
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"project/internal/database"
+	"project/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditRepositoryMongo struct{}
+
+func NewAuditRepositoryMongo() *AuditRepositoryMongo { return &AuditRepositoryMongo{} }
+
+func (r *AuditRepositoryMongo) col() *mongo.Collection {
+    return database.GetMongoDB().Collection("audit_log")
+}
+
+func auditQuery(filter models.AuditFilter) bson.M {
+    q := bson.M{}
+    switch {
+    case filter.AllTenants:
+    case filter.TenantID == "":
+        // Events in the default tenant have no tenant_id
+        q["tenant_id"] = bson.M{"$exists": false}
+    default:
+        q["tenant_id"] = filter.TenantID
+    }
+    if filter.ActorID != "" { q["actor_id"] = filter.ActorID }
+    if filter.Action != "" { q["action"] = filter.Action }
+    if filter.TargetType != "" { q["target_type"] = filter.TargetType }
+    if filter.TargetID != "" { q["target_id"] = filter.TargetID }
+    if filter.From != nil || filter.To != nil {
+        ts := bson.M{}
+        if filter.From != nil { ts["$gte"] = *filter.From }
+        if filter.To != nil { ts["$lt"] = *filter.To }
+        q["timestamp"] = ts
+    }
+    return q
+}
+
+func (r *AuditRepositoryMongo) Insert(event models.AuditEvent) error {
+    event.ID = primitive.NewObjectID()
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.col().InsertOne(ctx, event)
+    return err
+}
+
+// Find returns matching events, newest first.
+func (r *AuditRepositoryMongo) Find(filter models.AuditFilter) ([]models.AuditEvent, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}})
+    if filter.Limit > 0 { opts.SetLimit(filter.Limit) }
+    if filter.Offset > 0 { opts.SetSkip(filter.Offset) }
+    cur, err := r.col().Find(ctx, auditQuery(filter), opts)
+    if err != nil { return nil, err }
+    defer cur.Close(ctx)
+    res := []models.AuditEvent{}
+    if err := cur.All(ctx, &res); err != nil { return nil, err }
+    return res, nil
+}
+
+// Stream walks matching events oldest first without loading them all into
+// memory. It is bounded by ctx rather than a fixed timeout because exports
+// can be large.
+func (r *AuditRepositoryMongo) Stream(ctx context.Context, filter models.AuditFilter, fn func(event models.AuditEvent) error) error {
+    opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+    if filter.Limit > 0 { opts.SetLimit(filter.Limit) }
+    cur, err := r.col().Find(ctx, auditQuery(filter), opts)
+    if err != nil { return err }
+    defer cur.Close(ctx)
+    for cur.Next(ctx) {
+        var e models.AuditEvent
+        if err := cur.Decode(&e); err != nil { return err }
+        if err := fn(e); err != nil { return err }
+    }
+    return cur.Err()
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"io"
+	"project/internal/models"
+)
+
+// FileRepositoryInterface stores binary files, such as avatars, by ID.
+type FileRepositoryInterface interface {
+    Save(name, contentType string, data io.Reader) (string, error)
+    // Open returns the file's description and a reader the caller must close.
+    Open(idStr string) (*models.StoredFile, io.ReadSeekCloser, error)
+    Delete(idStr string) error
+}
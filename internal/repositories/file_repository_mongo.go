@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"errors"
+	"io"
+	"project/internal/database"
+	"project/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FileRepositoryMongo keeps files in a GridFS bucket. The content type is
+// stored in the file's metadata.
+type FileRepositoryMongo struct {
+    bucketName string
+}
+
+func NewFileRepositoryMongo(bucketName string) *FileRepositoryMongo {
+    return &FileRepositoryMongo{bucketName: bucketName}
+}
+
+func (r *FileRepositoryMongo) bucket() (*gridfs.Bucket, error) {
+    return gridfs.NewBucket(database.GetMongoDB(), options.GridFSBucket().SetName(r.bucketName))
+}
+
+type fileMetadata struct {
+    ContentType string `bson:"content_type"`
+}
+
+func (r *FileRepositoryMongo) Save(name, contentType string, data io.Reader) (string, error) {
+    b, err := r.bucket()
+    if err != nil { return "", err }
+    if err := b.SetWriteDeadline(time.Now().Add(30 * time.Second)); err != nil { return "", err }
+    opts := options.GridFSUpload().SetMetadata(fileMetadata{ContentType: contentType})
+    id, err := b.UploadFromStream(name, data, opts)
+    if err != nil { return "", err }
+    return id.Hex(), nil
+}
+
+func (r *FileRepositoryMongo) Open(idStr string) (*models.StoredFile, io.ReadSeekCloser, error) {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return nil, nil, err }
+    b, err := r.bucket()
+    if err != nil { return nil, nil, err }
+    if err := b.SetReadDeadline(time.Now().Add(30 * time.Second)); err != nil { return nil, nil, err }
+    stream, err := b.OpenDownloadStream(id)
+    if err != nil { return nil, nil, err }
+    f := stream.GetFile()
+    var meta fileMetadata
+    if len(f.Metadata) > 0 {
+        bson.Unmarshal(f.Metadata, &meta)
+    }
+    file := &models.StoredFile{ID: idStr, Name: f.Name, ContentType: meta.ContentType, Length: f.Length, UploadDate: f.UploadDate}
+    return file, &gridFSReader{bucket: b, id: id, length: f.Length, stream: stream}, nil
+}
+
+func (r *FileRepositoryMongo) Delete(idStr string) error {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return err }
+    b, err := r.bucket()
+    if err != nil { return err }
+    if err := b.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil { return err }
+    return b.Delete(id)
+}
+
+// gridFSReader makes a download stream seekable so http.ServeContent can
+// answer range requests. Seeking forward skips chunks; seeking backwards
+// reopens the stream.
+type gridFSReader struct {
+    bucket *gridfs.Bucket
+    id     primitive.ObjectID
+    length int64
+    stream *gridfs.DownloadStream
+    pos    int64 // position of stream
+    offset int64 // position of the next Read
+}
+
+func (g *gridFSReader) Read(p []byte) (int, error) {
+    if g.offset >= g.length {
+        return 0, io.EOF
+    }
+    if g.stream == nil || g.offset < g.pos {
+        if g.stream != nil {
+            g.stream.Close()
+        }
+        stream, err := g.bucket.OpenDownloadStream(g.id)
+        if err != nil { return 0, err }
+        g.stream, g.pos = stream, 0
+    }
+    if g.offset > g.pos {
+        skipped, err := g.stream.Skip(g.offset - g.pos)
+        g.pos += skipped
+        if err != nil { return 0, err }
+    }
+    n, err := g.stream.Read(p)
+    g.pos += int64(n)
+    g.offset = g.pos
+    return n, err
+}
+
+func (g *gridFSReader) Seek(offset int64, whence int) (int64, error) {
+    switch whence {
+    case io.SeekStart:
+    case io.SeekCurrent:
+        offset += g.offset
+    case io.SeekEnd:
+        offset += g.length
+    default:
+        return 0, errors.New("gridfs: invalid whence")
+    }
+    if offset < 0 {
+        return 0, errors.New("gridfs: negative position")
+    }
+    g.offset = offset
+    return offset, nil
+}
+
+func (g *gridFSReader) Close() error {
+    if g.stream == nil { return nil }
+    return g.stream.Close()
+}
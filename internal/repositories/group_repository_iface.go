@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"errors"
+	"project/internal/models"
+)
+
+// ErrGroupNameTaken is returned when the tenant already has a group of that name.
+var ErrGroupNameTaken = errors.New("group name already exists")
+
+// GroupRepositoryInterface is scoped to a single tenant like
+// UserRepositoryInterface.
+type GroupRepositoryInterface interface {
+    ForTenant(tenantID string) GroupRepositoryInterface
+    Create(group models.Group) (*models.Group, error)
+    FindAll() ([]models.Group, error)
+    FindByID(idStr string) (*models.Group, error)
+    FindByMember(userID string) ([]models.Group, error)
+    Update(idStr string, name, description string, permissions []string) (*models.Group, error)
+    Delete(idStr string) error
+    SetMember(idStr string, member models.GroupMember) error
+    RemoveMember(idStr string, userID string) error
+}
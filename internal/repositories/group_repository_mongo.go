@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"project/internal/database"
+	"project/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GroupRepositoryMongo only sees groups of one tenant, like UserRepositoryMongo.
+type GroupRepositoryMongo struct {
+    tenantID string
+}
+
+func NewGroupRepositoryMongo() *GroupRepositoryMongo { return &GroupRepositoryMongo{} }
+
+func (r *GroupRepositoryMongo) ForTenant(tenantID string) GroupRepositoryInterface {
+    return &GroupRepositoryMongo{tenantID: tenantID}
+}
+
+func (r *GroupRepositoryMongo) col() *mongo.Collection {
+    return database.GetMongoDB().Collection("groups")
+}
+
+func (r *GroupRepositoryMongo) byID(idStr string) (bson.M, error) {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return nil, err }
+    return scopeToTenant(bson.M{"_id": id}, r.tenantID), nil
+}
+
+func (r *GroupRepositoryMongo) Create(group models.Group) (*models.Group, error) {
+    group.ID = primitive.NewObjectID()
+    group.TenantID = r.tenantID
+    group.CreatedAt = time.Now()
+    group.UpdatedAt = group.CreatedAt
+    if group.Permissions == nil { group.Permissions = []string{} }
+    if group.Members == nil { group.Members = []models.GroupMember{} }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.col().InsertOne(ctx, group)
+    if mongo.IsDuplicateKeyError(err) { return nil, ErrGroupNameTaken }
+    if err != nil { return nil, err }
+    return &group, nil
+}
+
+func (r *GroupRepositoryMongo) find(filter bson.M) ([]models.Group, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    cur, err := r.col().Find(ctx, scopeToTenant(filter, r.tenantID), options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+    if err != nil { return nil, err }
+    defer cur.Close(ctx)
+    res := []models.Group{}
+    if err := cur.All(ctx, &res); err != nil { return nil, err }
+    return res, nil
+}
+
+func (r *GroupRepositoryMongo) FindAll() ([]models.Group, error) {
+    return r.find(bson.M{})
+}
+
+// FindByMember returns the groups userID belongs to.
+func (r *GroupRepositoryMongo) FindByMember(userID string) ([]models.Group, error) {
+    return r.find(bson.M{"members.user_id": userID})
+}
+
+func (r *GroupRepositoryMongo) FindByID(idStr string) (*models.Group, error) {
+    filter, err := r.byID(idStr)
+    if err != nil { return nil, err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var g models.Group
+    if err := r.col().FindOne(ctx, filter).Decode(&g); err != nil { return nil, err }
+    return &g, nil
+}
+
+func (r *GroupRepositoryMongo) Update(idStr string, name, description string, permissions []string) (*models.Group, error) {
+    filter, err := r.byID(idStr)
+    if err != nil { return nil, err }
+    if permissions == nil { permissions = []string{} }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    update := bson.M{"$set": bson.M{"name": name, "description": description, "permissions": permissions, "updated_at": time.Now()}}
+    res, err := r.col().UpdateOne(ctx, filter, update)
+    if mongo.IsDuplicateKeyError(err) { return nil, ErrGroupNameTaken }
+    if err != nil { return nil, err }
+    if res.MatchedCount == 0 { return nil, mongo.ErrNoDocuments }
+    return r.FindByID(idStr)
+}
+
+func (r *GroupRepositoryMongo) Delete(idStr string) error {
+    filter, err := r.byID(idStr)
+    if err != nil { return err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    res, err := r.col().DeleteOne(ctx, filter)
+    if err != nil { return err }
+    if res.DeletedCount == 0 { return mongo.ErrNoDocuments }
+    return nil
+}
+
+// SetMember adds the member or changes the role of an existing one.
+func (r *GroupRepositoryMongo) SetMember(idStr string, member models.GroupMember) error {
+    filter, err := r.byID(idStr)
+    if err != nil { return err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    now := time.Now()
+    filter["members.user_id"] = member.UserID
+    res, err := r.col().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"members.$.role": member.Role, "updated_at": now}})
+    if err != nil { return err }
+    if res.MatchedCount > 0 { return nil }
+    filter["members.user_id"] = bson.M{"$ne": member.UserID}
+    res, err = r.col().UpdateOne(ctx, filter, bson.M{"$push": bson.M{"members": member}, "$set": bson.M{"updated_at": now}})
+    if err != nil { return err }
+    if res.MatchedCount == 0 { return mongo.ErrNoDocuments }
+    return nil
+}
+
+func (r *GroupRepositoryMongo) RemoveMember(idStr string, userID string) error {
+    filter, err := r.byID(idStr)
+    if err != nil { return err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    filter["members.user_id"] = userID
+    update := bson.M{"$pull": bson.M{"members": bson.M{"user_id": userID}}, "$set": bson.M{"updated_at": time.Now()}}
+    res, err := r.col().UpdateOne(ctx, filter, update)
+    if err != nil { return err }
+    if res.MatchedCount == 0 { return mongo.ErrNoDocuments }
+    return nil
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"errors"
+	"project/internal/models"
+)
+
+// ErrIdempotencyKeyExists is returned by Create when the record already exists.
+var ErrIdempotencyKeyExists = errors.New("idempotency key already exists")
+
+type IdempotencyRepositoryInterface interface {
+    Create(record models.IdempotencyRecord) error
+    Find(id string) (*models.IdempotencyRecord, error)
+    Complete(id string, status int, headers map[string][]string, body []byte) error
+    Delete(id string) error
+}
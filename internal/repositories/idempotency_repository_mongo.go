@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"project/internal/database"
+	"project/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IdempotencyRepositoryMongo struct{}
+
+func NewIdempotencyRepositoryMongo() *IdempotencyRepositoryMongo { return &IdempotencyRepositoryMongo{} }
+
+func (r *IdempotencyRepositoryMongo) col() *mongo.Collection {
+    return database.GetMongoDB().Collection("idempotency_keys")
+}
+
+// Create inserts a pending record; the _id makes concurrent retries collide.
+func (r *IdempotencyRepositoryMongo) Create(record models.IdempotencyRecord) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.col().InsertOne(ctx, record)
+    if mongo.IsDuplicateKeyError(err) { return ErrIdempotencyKeyExists }
+    return err
+}
+
+func (r *IdempotencyRepositoryMongo) Find(id string) (*models.IdempotencyRecord, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var record models.IdempotencyRecord
+    err := r.col().FindOne(ctx, bson.M{"_id": id, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&record)
+    if err != nil { return nil, err }
+    return &record, nil
+}
+
+func (r *IdempotencyRepositoryMongo) Complete(id string, status int, headers map[string][]string, body []byte) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.col().UpdateOne(ctx, bson.M{"_id": id, "state": models.IdempotencyPending}, bson.M{"$set": bson.M{
+        "state":            models.IdempotencyCompleted,
+        "response_status":  status,
+        "response_headers": headers,
+        "response_body":    body,
+    }})
+    return err
+}
+
+func (r *IdempotencyRepositoryMongo) Delete(id string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.col().DeleteOne(ctx, bson.M{"_id": id})
+    return err
+}
@@ -0,0 +1,9 @@
+package repositories
+
+import "project/internal/models"
+
+type ImportJobRepositoryInterface interface {
+    Create(job models.ImportJob) (*models.ImportJob, error)
+    FindByID(idStr string) (*models.ImportJob, error)
+    Update(job models.ImportJob) error
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+	"project/internal/database"
+	"project/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ImportJobRepositoryMongo struct{}
+
+func NewImportJobRepositoryMongo() *ImportJobRepositoryMongo { return &ImportJobRepositoryMongo{} }
+
+func (r *ImportJobRepositoryMongo) col() *mongo.Collection {
+    return database.GetMongoDB().Collection("import_jobs")
+}
+
+func (r *ImportJobRepositoryMongo) Create(job models.ImportJob) (*models.ImportJob, error) {
+    job.ID = primitive.NewObjectID()
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.col().InsertOne(ctx, job)
+    if err != nil { return nil, err }
+    return &job, nil
+}
+
+func (r *ImportJobRepositoryMongo) FindByID(idStr string) (*models.ImportJob, error) {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return nil, err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var job models.ImportJob
+    if err := r.col().FindOne(ctx, bson.M{"_id": id}).Decode(&job); err != nil { return nil, err }
+    return &job, nil
+}
+
+func (r *ImportJobRepositoryMongo) Update(job models.ImportJob) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.col().ReplaceOne(ctx, bson.M{"_id": job.ID}, job)
+    return err
+}
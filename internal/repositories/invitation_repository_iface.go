@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"errors"
+	"project/internal/models"
+	"time"
+)
+
+// ErrInvitationPending is returned when the email already has a pending invitation.
+var ErrInvitationPending = errors.New("a pending invitation already exists for this email")
+
+// InvitationRepositoryInterface is scoped to a single tenant like
+// UserRepositoryInterface.
+type InvitationRepositoryInterface interface {
+    ForTenant(tenantID string) InvitationRepositoryInterface
+    Create(inv models.Invitation) (*models.Invitation, error)
+    FindByID(idStr string) (*models.Invitation, error)
+    FindPending() ([]models.Invitation, error)
+    // Resend replaces the token of a pending invitation and extends its expiry.
+    Resend(idStr string, tokenHash string, expiresAt time.Time) (*models.Invitation, error)
+    // SetStatus moves an invitation from status from to status to, recording
+    // userID on acceptance. It fails with mongo.ErrNoDocuments if the
+    // invitation is not in status from.
+    SetStatus(idStr string, from, to string, userID string) error
+}
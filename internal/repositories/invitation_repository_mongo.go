@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"project/internal/database"
+	"project/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvitationRepositoryMongo struct {
+    tenantID string
+}
+
+func NewInvitationRepositoryMongo() *InvitationRepositoryMongo { return &InvitationRepositoryMongo{} }
+
+func (r *InvitationRepositoryMongo) ForTenant(tenantID string) InvitationRepositoryInterface {
+    return &InvitationRepositoryMongo{tenantID: tenantID}
+}
+
+func (r *InvitationRepositoryMongo) col() *mongo.Collection {
+    return database.GetMongoDB().Collection("invitations")
+}
+
+func (r *InvitationRepositoryMongo) byID(idStr string) (bson.M, error) {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return nil, err }
+    return scopeToTenant(bson.M{"_id": id}, r.tenantID), nil
+}
+
+func (r *InvitationRepositoryMongo) Create(inv models.Invitation) (*models.Invitation, error) {
+    inv.ID = primitive.NewObjectID()
+    inv.TenantID = r.tenantID
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.col().InsertOne(ctx, inv)
+    if mongo.IsDuplicateKeyError(err) { return nil, ErrInvitationPending }
+    if err != nil { return nil, err }
+    return &inv, nil
+}
+
+func (r *InvitationRepositoryMongo) FindByID(idStr string) (*models.Invitation, error) {
+    filter, err := r.byID(idStr)
+    if err != nil { return nil, err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var inv models.Invitation
+    if err := r.col().FindOne(ctx, filter).Decode(&inv); err != nil { return nil, err }
+    return &inv, nil
+}
+
+// FindPending returns pending invitations, expired ones included, newest first.
+func (r *InvitationRepositoryMongo) FindPending() ([]models.Invitation, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    filter := scopeToTenant(bson.M{"status": models.InvitationPending}, r.tenantID)
+    cur, err := r.col().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+    if err != nil { return nil, err }
+    defer cur.Close(ctx)
+    res := []models.Invitation{}
+    if err := cur.All(ctx, &res); err != nil { return nil, err }
+    return res, nil
+}
+
+func (r *InvitationRepositoryMongo) Resend(idStr string, tokenHash string, expiresAt time.Time) (*models.Invitation, error) {
+    filter, err := r.byID(idStr)
+    if err != nil { return nil, err }
+    filter["status"] = models.InvitationPending
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    update := bson.M{
+        "$set": bson.M{"token_hash": tokenHash, "expires_at": expiresAt, "sent_at": time.Now()},
+        "$inc": bson.M{"send_count": 1},
+    }
+    var inv models.Invitation
+    err = r.col().FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&inv)
+    if err != nil { return nil, err }
+    return &inv, nil
+}
+
+func (r *InvitationRepositoryMongo) SetStatus(idStr string, from, to string, userID string) error {
+    filter, err := r.byID(idStr)
+    if err != nil { return err }
+    filter["status"] = from
+    now := time.Now()
+    set := bson.M{"status": to}
+    unset := bson.M{}
+    switch to {
+    case models.InvitationAccepted:
+        set["accepted_at"], set["user_id"] = now, userID
+    case models.InvitationRevoked:
+        set["revoked_at"] = now
+    default:
+        unset["accepted_at"], unset["revoked_at"], unset["user_id"] = "", "", ""
+    }
+    update := bson.M{"$set": set}
+    if len(unset) > 0 { update["$unset"] = unset }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    res, err := r.col().UpdateOne(ctx, filter, update)
+    if err != nil { return err }
+    if res.MatchedCount == 0 { return mongo.ErrNoDocuments }
+    return nil
+}
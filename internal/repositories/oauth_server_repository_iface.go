@@ -0,0 +1,14 @@
+package repositories
+
+import "project/internal/models"
+
+type OAuthServerRepositoryInterface interface {
+    CreateClient(client models.OAuthClient) (*models.OAuthClient, error)
+    FindClientByClientID(clientID string) (*models.OAuthClient, error)
+    FindClientsByOwner(ownerIDStr string) ([]models.OAuthClient, error)
+    CreateCode(code models.OAuthAuthorizationCode) error
+    ConsumeCode(codeHash string) (*models.OAuthAuthorizationCode, error)
+    CreateRefreshToken(token models.OAuthRefreshToken) error
+    FindRefreshToken(tokenHash string) (*models.OAuthRefreshToken, error)
+    RevokeRefreshToken(idStr string) error
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"project/internal/database"
+	"project/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OAuthServerRepositoryMongo struct{}
+
+func NewOAuthServerRepositoryMongo() *OAuthServerRepositoryMongo { return &OAuthServerRepositoryMongo{} }
+
+func (r *OAuthServerRepositoryMongo) clients() *mongo.Collection {
+    return database.GetMongoDB().Collection("oauth_clients")
+}
+
+func (r *OAuthServerRepositoryMongo) codes() *mongo.Collection {
+    return database.GetMongoDB().Collection("oauth_codes")
+}
+
+func (r *OAuthServerRepositoryMongo) refreshTokens() *mongo.Collection {
+    return database.GetMongoDB().Collection("oauth_refresh_tokens")
+}
+
+func (r *OAuthServerRepositoryMongo) CreateClient(client models.OAuthClient) (*models.OAuthClient, error) {
+    client.ID = primitive.NewObjectID()
+    client.CreatedAt = time.Now()
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.clients().InsertOne(ctx, client)
+    if err != nil { return nil, err }
+    return &client, nil
+}
+
+func (r *OAuthServerRepositoryMongo) FindClientByClientID(clientID string) (*models.OAuthClient, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var c models.OAuthClient
+    err := r.clients().FindOne(ctx, bson.M{"client_id": clientID}).Decode(&c)
+    if err != nil { return nil, err }
+    return &c, nil
+}
+
+func (r *OAuthServerRepositoryMongo) FindClientsByOwner(ownerIDStr string) ([]models.OAuthClient, error) {
+    ownerID, err := primitive.ObjectIDFromHex(ownerIDStr)
+    if err != nil { return nil, err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    cur, err := r.clients().Find(ctx, bson.M{"owner_id": ownerID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+    if err != nil { return nil, err }
+    defer cur.Close(ctx)
+    res := []models.OAuthClient{}
+    if err := cur.All(ctx, &res); err != nil { return nil, err }
+    return res, nil
+}
+
+func (r *OAuthServerRepositoryMongo) CreateCode(code models.OAuthAuthorizationCode) error {
+    code.ID = primitive.NewObjectID()
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.codes().InsertOne(ctx, code)
+    return err
+}
+
+// ConsumeCode atomically marks an unused code as used and returns it, so a
+// code can be redeemed at most once even under concurrent requests.
+func (r *OAuthServerRepositoryMongo) ConsumeCode(codeHash string) (*models.OAuthAuthorizationCode, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var c models.OAuthAuthorizationCode
+    filter := bson.M{"code_hash": codeHash, "used_at": bson.M{"$exists": false}}
+    err := r.codes().FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"used_at": time.Now()}}).Decode(&c)
+    if err != nil { return nil, err }
+    return &c, nil
+}
+
+func (r *OAuthServerRepositoryMongo) CreateRefreshToken(token models.OAuthRefreshToken) error {
+    token.ID = primitive.NewObjectID()
+    token.CreatedAt = time.Now()
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.refreshTokens().InsertOne(ctx, token)
+    return err
+}
+
+func (r *OAuthServerRepositoryMongo) FindRefreshToken(tokenHash string) (*models.OAuthRefreshToken, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var t models.OAuthRefreshToken
+    err := r.refreshTokens().FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&t)
+    if err != nil { return nil, err }
+    return &t, nil
+}
+
+// RevokeRefreshToken returns mongo.ErrNoDocuments if the token was already revoked.
+func (r *OAuthServerRepositoryMongo) RevokeRefreshToken(idStr string) error {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    filter := bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}}
+    res, err := r.refreshTokens().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+    if err != nil { return err }
+    if res.MatchedCount == 0 { return mongo.ErrNoDocuments }
+    return nil
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"errors"
+	"project/internal/models"
+)
+
+// ErrSlugTaken is returned when another organization already uses the slug.
+var ErrSlugTaken = errors.New("organization slug already exists")
+
+type OrganizationRepositoryInterface interface {
+    Create(org models.Organization) (*models.Organization, error)
+    FindAll() ([]models.Organization, error)
+    FindByID(idStr string) (*models.Organization, error)
+    FindBySlug(slug string) (*models.Organization, error)
+    Update(idStr string, name, slug string) (*models.Organization, error)
+    Delete(idStr string) error
+}
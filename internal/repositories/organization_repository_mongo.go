@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"project/internal/database"
+	"project/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OrganizationRepositoryMongo struct{}
+
+func NewOrganizationRepositoryMongo() *OrganizationRepositoryMongo { return &OrganizationRepositoryMongo{} }
+
+func (r *OrganizationRepositoryMongo) col() *mongo.Collection {
+    return database.GetMongoDB().Collection("organizations")
+}
+
+func (r *OrganizationRepositoryMongo) Create(org models.Organization) (*models.Organization, error) {
+    org.ID = primitive.NewObjectID()
+    org.CreatedAt = time.Now()
+    org.UpdatedAt = org.CreatedAt
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    _, err := r.col().InsertOne(ctx, org)
+    if mongo.IsDuplicateKeyError(err) { return nil, ErrSlugTaken }
+    if err != nil { return nil, err }
+    return &org, nil
+}
+
+func (r *OrganizationRepositoryMongo) FindAll() ([]models.Organization, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    cur, err := r.col().Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "slug", Value: 1}}))
+    if err != nil { return nil, err }
+    defer cur.Close(ctx)
+    res := []models.Organization{}
+    if err := cur.All(ctx, &res); err != nil { return nil, err }
+    return res, nil
+}
+
+func (r *OrganizationRepositoryMongo) FindByID(idStr string) (*models.Organization, error) {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return nil, err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var org models.Organization
+    if err := r.col().FindOne(ctx, bson.M{"_id": id}).Decode(&org); err != nil { return nil, err }
+    return &org, nil
+}
+
+func (r *OrganizationRepositoryMongo) FindBySlug(slug string) (*models.Organization, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var org models.Organization
+    if err := r.col().FindOne(ctx, bson.M{"slug": slug}).Decode(&org); err != nil { return nil, err }
+    return &org, nil
+}
+
+func (r *OrganizationRepositoryMongo) Update(idStr string, name, slug string) (*models.Organization, error) {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return nil, err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    update := bson.M{"$set": bson.M{"name": name, "slug": slug, "updated_at": time.Now()}}
+    res, err := r.col().UpdateByID(ctx, id, update)
+    if mongo.IsDuplicateKeyError(err) { return nil, ErrSlugTaken }
+    if err != nil { return nil, err }
+    if res.MatchedCount == 0 { return nil, mongo.ErrNoDocuments }
+    return r.FindByID(idStr)
+}
+
+func (r *OrganizationRepositoryMongo) Delete(idStr string) error {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    res, err := r.col().DeleteOne(ctx, bson.M{"_id": id})
+    if err != nil { return err }
+    if res.DeletedCount == 0 { return mongo.ErrNoDocuments }
+    return nil
+}
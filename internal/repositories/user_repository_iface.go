@@ -1,14 +1,41 @@
 package repositories
 
-import "project/internal/models"
+import (
+	"context"
+	"errors"
+	"project/internal/models"
+)
 
+// AnyVersion skips the optimistic concurrency check on conditional writes.
+const AnyVersion int64 = -1
+
+// ErrVersionConflict means the user changed since the expected version was read.
+var ErrVersionConflict = errors.New("user was modified concurrently")
+
+// UserRepositoryInterface is scoped to a single tenant: every query and
+// write only sees that tenant's users. ForTenant returns the same repository
+// for another tenant.
 type UserRepositoryInterface interface {
-    FindAll() ([]models.UserResponse, error)
+    ForTenant(tenantID string) UserRepositoryInterface
+    FindAll(filter models.UserFilter) ([]models.UserResponse, error)
+    Stream(ctx context.Context, filter models.UserFilter, fn func(user models.User) error) error
     FindByID(idStr string) (*models.UserResponse, error)
     FindByEmail(email string) (*models.User, error)
+    FindUserByID(idStr string) (*models.User, error)
     Create(user models.User) (*models.UserResponse, error)
+    CreateMany(users []models.User) (map[int]error, error)
+    ExistingEmails(emails []string) (map[string]bool, error)
+    SearchText(terms string, limit int64) ([]models.ScoredUser, error)
+    SearchPrefix(prefix string, limit int64) ([]models.User, error)
     Update(idStr string, user models.User) (*models.UserResponse, error)
-    Delete(idStr string) error
+    Replace(idStr string, user models.User, expectedVersion int64) (*models.UserResponse, error)
+    Delete(idStr string, expectedVersion int64) error
+    SetRole(idStr string, role string) error
+    SetTenant(idStr string, tenantID string) error
+    // SetAvatar replaces the user's avatar; nil removes it.
+    SetAvatar(idStr string, avatar *models.Avatar) error
+    FindByIdentity(provider, subject string) (*models.User, error)
+    LinkIdentity(idStr string, identity models.ExternalIdentity) error
 }
 
 
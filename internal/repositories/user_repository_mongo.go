@@ -2,55 +2,220 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"project/internal/database"
 	"project/internal/models"
+	"project/pkg/utils"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type UserRepositoryMongo struct{}
+// UserRepositoryMongo only sees users of one tenant. NewUserRepositoryMongo
+// starts in the default tenant; ForTenant switches to an organization.
+type UserRepositoryMongo struct {
+    tenantID string
+}
 
 func NewUserRepositoryMongo() *UserRepositoryMongo { return &UserRepositoryMongo{} }
 
+func (r *UserRepositoryMongo) ForTenant(tenantID string) UserRepositoryInterface {
+    return &UserRepositoryMongo{tenantID: tenantID}
+}
+
 func (r *UserRepositoryMongo) col() *mongo.Collection {
     return database.GetMongoDB().Collection("users")
 }
 
-func (r *UserRepositoryMongo) FindAll() ([]models.UserResponse, error) {
+// scopeToTenant restricts filter to tenantID. Documents of the default tenant
+// have no tenant_id.
+func scopeToTenant(filter bson.M, tenantID string) bson.M {
+    if tenantID == "" {
+        filter["tenant_id"] = bson.M{"$exists": false}
+    } else {
+        filter["tenant_id"] = tenantID
+    }
+    return filter
+}
+
+func (r *UserRepositoryMongo) scope(filter bson.M) bson.M {
+    return scopeToTenant(filter, r.tenantID)
+}
+
+// matchVersion adds the optimistic concurrency condition to filter. Users
+// stored before versioning have no version field and count as version 0.
+func matchVersion(filter bson.M, expectedVersion int64) bson.M {
+    switch expectedVersion {
+    case AnyVersion:
+    case 0:
+        filter["version"] = bson.M{"$in": bson.A{0, nil}}
+    default:
+        filter["version"] = expectedVersion
+    }
+    return filter
+}
+
+// conditionalUpdate applies update when the user exists at expectedVersion and
+// tells a missing user apart from a stale version.
+func (r *UserRepositoryMongo) conditionalUpdate(idStr string, expectedVersion int64, update bson.M) error {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    filter := matchVersion(r.scope(bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}), expectedVersion)
+    res, err := r.col().UpdateOne(ctx, filter, update)
+    if err != nil { return err }
+    if res.MatchedCount == 0 {
+        if _, err := r.FindUserByID(idStr); err != nil { return err }
+        return ErrVersionConflict
+    }
+    return nil
+}
+
+// userQuery turns a filter into a query over active users. Regular users
+// may have no stored role.
+func userQuery(filter models.UserFilter) bson.M {
+    q := bson.M{"deleted_at": bson.M{"$exists": false}}
+    if filter.Name != "" { q["name"] = bson.M{"$regex": regexp.QuoteMeta(filter.Name), "$options": "i"} }
+    if filter.Email != "" { q["email"] = strings.ToLower(strings.TrimSpace(filter.Email)) }
+    switch filter.Role {
+    case "":
+    case models.RoleUser:
+        q["role"] = bson.M{"$in": bson.A{nil, "", models.RoleUser}}
+    default:
+        q["role"] = filter.Role
+    }
+    if filter.CreatedFrom != nil || filter.CreatedTo != nil {
+        created := bson.M{}
+        if filter.CreatedFrom != nil { created["$gte"] = *filter.CreatedFrom }
+        if filter.CreatedTo != nil { created["$lt"] = *filter.CreatedTo }
+        q["created_at"] = created
+    }
+    return q
+}
+
+func (r *UserRepositoryMongo) FindAll(filter models.UserFilter) ([]models.UserResponse, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
-    cur, err := r.col().Find(ctx, bson.M{"deleted_at": bson.M{"$exists": false}})
+    opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(filter.Offset)
+    if filter.Limit > 0 { opts.SetLimit(filter.Limit) }
+    cur, err := r.col().Find(ctx, r.scope(userQuery(filter)), opts)
     if err != nil { return nil, err }
     defer cur.Close(ctx)
     var res []models.UserResponse
     for cur.Next(ctx) {
         var u models.User
         if err := cur.Decode(&u); err != nil { return nil, err }
-        res = append(res, models.UserResponse{ID: u.ID.Hex(), Name: u.Name, Email: u.Email, CreatedAt: u.CreatedAt})
+        res = append(res, *u.ToResponse())
     }
     return res, cur.Err()
 }
 
+// Stream calls fn for each matching user straight from the cursor, so exports
+// never hold the whole collection in memory. It stops at fn's first error.
+func (r *UserRepositoryMongo) Stream(ctx context.Context, filter models.UserFilter, fn func(user models.User) error) error {
+    opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(filter.Offset).SetBatchSize(500)
+    if filter.Limit > 0 { opts.SetLimit(filter.Limit) }
+    cur, err := r.col().Find(ctx, r.scope(userQuery(filter)), opts)
+    if err != nil { return err }
+    defer cur.Close(ctx)
+    for cur.Next(ctx) {
+        var u models.User
+        if err := cur.Decode(&u); err != nil { return err }
+        if err := fn(u); err != nil { return err }
+    }
+    return cur.Err()
+}
+
+// SearchText ranks users matching terms in the text index over the
+// normalized name and the email, best first.
+func (r *UserRepositoryMongo) SearchText(terms string, limit int64) ([]models.ScoredUser, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    score := bson.M{"$meta": "textScore"}
+    opts := options.Find().SetProjection(bson.M{"score": score, "password": 0}).SetSort(bson.M{"score": score}).SetLimit(limit)
+    filter := r.scope(bson.M{"$text": bson.M{"$search": terms}, "deleted_at": bson.M{"$exists": false}})
+    cur, err := r.col().Find(ctx, filter, opts)
+    if err != nil { return nil, err }
+    defer cur.Close(ctx)
+    res := []models.ScoredUser{}
+    if err := cur.All(ctx, &res); err != nil { return nil, err }
+    return res, nil
+}
+
+// SearchPrefix finds users whose email or any word of their normalized name
+// starts with prefix, for typeahead.
+func (r *UserRepositoryMongo) SearchPrefix(prefix string, limit int64) ([]models.User, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    filter := r.scope(bson.M{
+        "$or": bson.A{
+            bson.M{"email": bson.M{"$regex": "^" + regexp.QuoteMeta(strings.ToLower(prefix))}},
+            bson.M{"search_name": bson.M{"$regex": "(^| )" + regexp.QuoteMeta(utils.NormalizeSearchText(prefix))}},
+        },
+        "deleted_at": bson.M{"$exists": false},
+    })
+    cur, err := r.col().Find(ctx, filter, options.Find().SetProjection(bson.M{"password": 0}).SetLimit(limit))
+    if err != nil { return nil, err }
+    defer cur.Close(ctx)
+    res := []models.User{}
+    if err := cur.All(ctx, &res); err != nil { return nil, err }
+    return res, nil
+}
+
+// BackfillSearchNames sets search_name on users stored before search existed,
+// in every tenant.
+func (r *UserRepositoryMongo) BackfillSearchNames() (int, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+    defer cancel()
+    cur, err := r.col().Find(ctx, bson.M{"search_name": bson.M{"$exists": false}}, options.Find().SetProjection(bson.M{"name": 1}))
+    if err != nil { return 0, err }
+    defer cur.Close(ctx)
+    n := 0
+    for cur.Next(ctx) {
+        var u models.User
+        if err := cur.Decode(&u); err != nil { return n, err }
+        if _, err := r.col().UpdateByID(ctx, u.ID, bson.M{"$set": bson.M{"search_name": utils.NormalizeSearchText(u.Name)}}); err != nil {
+            return n, err
+        }
+        n++
+    }
+    return n, cur.Err()
+}
+
 func (r *UserRepositoryMongo) FindByID(idStr string) (*models.UserResponse, error) {
     id, err := primitive.ObjectIDFromHex(idStr)
     if err != nil { return nil, err }
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
     var u models.User
-    err = r.col().FindOne(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}).Decode(&u)
+    err = r.col().FindOne(ctx, r.scope(bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}})).Decode(&u)
+    if err != nil { return nil, err }
+    return u.ToResponse(), nil
+}
+
+// FindUserByID returns the stored document, including the password hash.
+func (r *UserRepositoryMongo) FindUserByID(idStr string) (*models.User, error) {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return nil, err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var u models.User
+    err = r.col().FindOne(ctx, r.scope(bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}})).Decode(&u)
     if err != nil { return nil, err }
-    resp := &models.UserResponse{ID: u.ID.Hex(), Name: u.Name, Email: u.Email, CreatedAt: u.CreatedAt}
-    return resp, nil
+    return &u, nil
 }
 
 func (r *UserRepositoryMongo) FindByEmail(email string) (*models.User, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
     var u models.User
-    err := r.col().FindOne(ctx, bson.M{"email": email, "deleted_at": bson.M{"$exists": false}}).Decode(&u)
+    err := r.col().FindOne(ctx, r.scope(bson.M{"email": email, "deleted_at": bson.M{"$exists": false}})).Decode(&u)
     if err != nil { return nil, err }
     return &u, nil
 }
@@ -59,36 +224,171 @@ func (r *UserRepositoryMongo) Create(user models.User) (*models.UserResponse, er
     user.ID = primitive.NewObjectID()
     user.CreatedAt = time.Now()
     user.UpdatedAt = time.Now()
+    user.Version = 1
+    user.TenantID = r.tenantID
+    user.SearchName = utils.NormalizeSearchText(user.Name)
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
     _, err := r.col().InsertOne(ctx, user)
     if err != nil { return nil, err }
-    resp := &models.UserResponse{ID: user.ID.Hex(), Name: user.Name, Email: user.Email, CreatedAt: user.CreatedAt}
-    return resp, nil
+    return user.ToResponse(), nil
+}
+
+// CreateMany inserts users unordered, so one bad row does not stop the batch,
+// and assigns their IDs in place. It returns the error of each failed index.
+func (r *UserRepositoryMongo) CreateMany(users []models.User) (map[int]error, error) {
+    if len(users) == 0 { return nil, nil }
+    now := time.Now()
+    docs := make([]interface{}, len(users))
+    for i := range users {
+        users[i].ID = primitive.NewObjectID()
+        users[i].CreatedAt = now
+        users[i].UpdatedAt = now
+        users[i].Version = 1
+        users[i].TenantID = r.tenantID
+        users[i].SearchName = utils.NormalizeSearchText(users[i].Name)
+        docs[i] = users[i]
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+    defer cancel()
+    _, err := r.col().InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+    var bwe mongo.BulkWriteException
+    if !errors.As(err, &bwe) || bwe.WriteConcernError != nil { return nil, err }
+    failed := make(map[int]error, len(bwe.WriteErrors))
+    for _, we := range bwe.WriteErrors {
+        if mongo.IsDuplicateKeyError(we.WriteError) {
+            failed[we.Index] = errors.New("email already exists")
+        } else {
+            failed[we.Index] = errors.New(we.Message)
+        }
+    }
+    return failed, nil
+}
+
+// ExistingEmails reports which of emails belong to active users.
+func (r *UserRepositoryMongo) ExistingEmails(emails []string) (map[string]bool, error) {
+    found := map[string]bool{}
+    if len(emails) == 0 { return found, nil }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    opts := options.Find().SetProjection(bson.M{"email": 1})
+    cur, err := r.col().Find(ctx, r.scope(bson.M{"email": bson.M{"$in": emails}, "deleted_at": bson.M{"$exists": false}}), opts)
+    if err != nil { return nil, err }
+    defer cur.Close(ctx)
+    for cur.Next(ctx) {
+        var u models.User
+        if err := cur.Decode(&u); err != nil { return nil, err }
+        found[u.Email] = true
+    }
+    return found, cur.Err()
 }
 
 func (r *UserRepositoryMongo) Update(idStr string, user models.User) (*models.UserResponse, error) {
     id, err := primitive.ObjectIDFromHex(idStr)
     if err != nil { return nil, err }
-    update := bson.M{"$set": bson.M{"updated_at": time.Now()}}
-    if user.Name != "" { update["$set"].(bson.M)["name"] = user.Name }
+    update := bson.M{"$set": bson.M{"updated_at": time.Now()}, "$inc": bson.M{"version": 1}}
+    if user.Name != "" {
+        update["$set"].(bson.M)["name"] = user.Name
+        update["$set"].(bson.M)["search_name"] = utils.NormalizeSearchText(user.Name)
+    }
     if user.Email != "" { update["$set"].(bson.M)["email"] = user.Email }
     if user.Password != "" { update["$set"].(bson.M)["password"] = user.Password }
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
-    _, err = r.col().UpdateByID(ctx, id, update)
+    _, err = r.col().UpdateOne(ctx, r.scope(bson.M{"_id": id}), update)
+    if err != nil { return nil, err }
+    return r.FindByID(idStr)
+}
+
+// Replace overwrites every writable field, including empty values.
+func (r *UserRepositoryMongo) Replace(idStr string, user models.User, expectedVersion int64) (*models.UserResponse, error) {
+    err := r.conditionalUpdate(idStr, expectedVersion, bson.M{
+        "$set": bson.M{
+            "name":        user.Name,
+            "search_name": utils.NormalizeSearchText(user.Name),
+            "email":       user.Email,
+            "password":    user.Password,
+            "updated_at":  time.Now(),
+        },
+        "$inc": bson.M{"version": 1},
+    })
     if err != nil { return nil, err }
     return r.FindByID(idStr)
 }
 
-func (r *UserRepositoryMongo) Delete(idStr string) error {
+func (r *UserRepositoryMongo) SetRole(idStr string, role string) error {
     id, err := primitive.ObjectIDFromHex(idStr)
     if err != nil { return err }
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
-    now := time.Now()
-    _, err = r.col().UpdateByID(ctx, id, bson.M{"$set": bson.M{"deleted_at": now}})
+    _, err = r.col().UpdateOne(ctx, r.scope(bson.M{"_id": id}), bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}, "$inc": bson.M{"version": 1}})
     return err
 }
 
+func (r *UserRepositoryMongo) SetAvatar(idStr string, avatar *models.Avatar) error {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    update := bson.M{"$set": bson.M{"updated_at": time.Now()}, "$inc": bson.M{"version": 1}}
+    if avatar == nil {
+        update["$unset"] = bson.M{"avatar": ""}
+    } else {
+        update["$set"].(bson.M)["avatar"] = avatar
+    }
+    res, err := r.col().UpdateOne(ctx, r.scope(bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}), update)
+    if err != nil { return err }
+    if res.MatchedCount == 0 { return mongo.ErrNoDocuments }
+    return nil
+}
+
+// SetTenant moves a user of this repository's tenant into tenantID. The
+// email must be free there, or the unique index rejects the move.
+func (r *UserRepositoryMongo) SetTenant(idStr string, tenantID string) error {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    update := bson.M{"$set": bson.M{"updated_at": time.Now()}, "$inc": bson.M{"version": 1}}
+    if tenantID == "" {
+        update["$unset"] = bson.M{"tenant_id": ""}
+    } else {
+        update["$set"].(bson.M)["tenant_id"] = tenantID
+    }
+    res, err := r.col().UpdateOne(ctx, r.scope(bson.M{"_id": id, "deleted_at": bson.M{"$exists": false}}), update)
+    if err != nil { return err }
+    if res.MatchedCount == 0 { return mongo.ErrNoDocuments }
+    return nil
+}
+
+func (r *UserRepositoryMongo) Delete(idStr string, expectedVersion int64) error {
+    now := time.Now()
+    return r.conditionalUpdate(idStr, expectedVersion, bson.M{"$set": bson.M{"deleted_at": now}, "$inc": bson.M{"version": 1}})
+}
+
+func (r *UserRepositoryMongo) FindByIdentity(provider, subject string) (*models.User, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    var u models.User
+    filter := r.scope(bson.M{
+        "identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}},
+        "deleted_at": bson.M{"$exists": false},
+    })
+    err := r.col().FindOne(ctx, filter).Decode(&u)
+    if err != nil { return nil, err }
+    return &u, nil
+}
 
+func (r *UserRepositoryMongo) LinkIdentity(idStr string, identity models.ExternalIdentity) error {
+    id, err := primitive.ObjectIDFromHex(idStr)
+    if err != nil { return err }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    update := bson.M{
+        "$push": bson.M{"identities": identity},
+        "$set":  bson.M{"updated_at": time.Now()},
+        "$inc":  bson.M{"version": 1},
+    }
+    _, err = r.col().UpdateOne(ctx, r.scope(bson.M{"_id": id}), update)
+    return err
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"net/http"
+	"project/internal/handlers"
+	"project/internal/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+func RegisterAdminRoutes(router *mux.Router, adminHandler *handlers.AdminHandler) {
+    adminRouter := router.PathPrefix("/admin").Subrouter()
+    adminRouter.Use(middleware.Auth, middleware.RequireAdmin)
+
+    adminRouter.HandleFunc("/audit", adminHandler.ListAudit).Methods("GET")
+    adminRouter.HandleFunc("/audit/export", adminHandler.ExportAudit).Methods("GET")
+    adminRouter.HandleFunc("/users/{id}/role", adminHandler.SetUserRole).Methods("PUT")
+    // Bulk uploads may be gzip-compressed
+    adminRouter.Handle("/users/import", middleware.DecompressRequest(http.HandlerFunc(adminHandler.ImportUsers))).Methods("POST")
+    adminRouter.HandleFunc("/users/export", adminHandler.ExportUsers).Methods("GET")
+    adminRouter.HandleFunc("/imports/{id}", adminHandler.GetImportJob).Methods("GET")
+}
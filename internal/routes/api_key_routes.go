@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"project/internal/handlers"
+	"project/internal/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+func RegisterAPIKeyRoutes(router *mux.Router, apiKeyHandler *handlers.APIKeyHandler) {
+    // Key management always requires an authenticated user
+    keyRouter := router.PathPrefix("/api-keys").Subrouter()
+    keyRouter.Use(middleware.Auth)
+
+    keyRouter.HandleFunc("", apiKeyHandler.ListKeys).Methods("GET")
+    keyRouter.HandleFunc("", apiKeyHandler.CreateKey).Methods("POST")
+    keyRouter.HandleFunc("/{id}", apiKeyHandler.RevokeKey).Methods("DELETE")
+}
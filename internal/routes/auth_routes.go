@@ -12,4 +12,9 @@ func RegisterAuthRoutes(router *mux.Router, authHandler *handlers.AuthHandler) {
     authRouter.HandleFunc("/login", authHandler.Login).Methods("POST")
 }
 
-
+func RegisterOAuthRoutes(router *mux.Router, oauthHandler *handlers.OAuthHandler) {
+    // Social login via external identity providers - public like /auth/login
+    oauthRouter := router.PathPrefix("/auth/oauth/{provider}").Subrouter()
+    oauthRouter.HandleFunc("/start", oauthHandler.Start).Methods("GET")
+    oauthRouter.HandleFunc("/callback", oauthHandler.Callback).Methods("GET")
+}
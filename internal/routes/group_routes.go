@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"net/http"
+	"project/internal/handlers"
+	"project/internal/middleware"
+	"project/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterGroupRoutes mounts group management and the per-user group lists.
+// Register before the /users and /me routes so their prefixes don't shadow
+// /users/{id}/groups and /me/groups.
+func RegisterGroupRoutes(router *mux.Router, groupHandler *handlers.GroupHandler) {
+    groupRouter := router.PathPrefix("/groups").Subrouter()
+    groupRouter.Use(middleware.Auth, middleware.Idempotency)
+
+    // Groups can grant permissions, so changing them is an admin task, and
+    // API keys and OAuth tokens need the same scopes as for /users
+    read := func(h http.HandlerFunc) http.Handler {
+        return middleware.RequireScope(models.ScopeUsersRead)(h)
+    }
+    write := func(h http.HandlerFunc) http.Handler {
+        return middleware.RequireScope(models.ScopeUsersWrite)(middleware.RequireAdmin(h))
+    }
+    groupRouter.Handle("", write(groupHandler.CreateGroup)).Methods("POST")
+    groupRouter.Handle("", read(groupHandler.ListGroups)).Methods("GET")
+    groupRouter.Handle("/{id}", read(groupHandler.GetGroup)).Methods("GET")
+    groupRouter.Handle("/{id}", write(groupHandler.UpdateGroup)).Methods("PUT")
+    groupRouter.Handle("/{id}", write(groupHandler.DeleteGroup)).Methods("DELETE")
+    groupRouter.Handle("/{id}/members", write(groupHandler.AddMember)).Methods("POST")
+    groupRouter.Handle("/{id}/members/{userId}", write(groupHandler.RemoveMember)).Methods("DELETE")
+
+    router.Handle("/users/{id}/groups", middleware.Auth(read(groupHandler.ListUserGroups))).Methods("GET")
+    router.Handle("/me/groups", middleware.Auth(read(groupHandler.ListMyGroups))).Methods("GET")
+}
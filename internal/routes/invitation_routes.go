@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"project/internal/handlers"
+	"project/internal/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterInvitationRoutes mounts invitation management for admins and the
+// public accept endpoint, which is authenticated by the invitation token.
+func RegisterInvitationRoutes(router *mux.Router, invitationHandler *handlers.InvitationHandler) {
+    // Before the admin subrouter, which would otherwise claim it
+    router.HandleFunc("/invitations/accept", invitationHandler.AcceptInvitation).Methods("POST")
+
+    invRouter := router.PathPrefix("/invitations").Subrouter()
+    // No Idempotency: responses carry invitation tokens, which must not be
+    // kept in the replay store
+    invRouter.Use(middleware.Auth, middleware.RequireAdmin)
+
+    invRouter.HandleFunc("", invitationHandler.CreateInvitation).Methods("POST")
+    invRouter.HandleFunc("", invitationHandler.ListInvitations).Methods("GET")
+    invRouter.HandleFunc("/{id}/resend", invitationHandler.ResendInvitation).Methods("POST")
+    invRouter.HandleFunc("/{id}", invitationHandler.RevokeInvitation).Methods("DELETE")
+}
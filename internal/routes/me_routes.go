@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"net/http"
+	"project/internal/handlers"
+	"project/internal/middleware"
+	"project/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+func RegisterMeRoutes(router *mux.Router, userHandler *handlers.UserHandler) {
+    // Endpoints for the authenticated user; no ObjectID needed
+    meRouter := router.PathPrefix("/me").Subrouter()
+    meRouter.Use(middleware.Auth, middleware.Idempotency)
+
+    read := middleware.RequireScope(models.ScopeUsersRead)
+    write := middleware.RequireScope(models.ScopeUsersWrite)
+
+    meRouter.Handle("", read(http.HandlerFunc(userHandler.GetMe))).Methods("GET")
+    meRouter.Handle("", write(http.HandlerFunc(userHandler.UpdateMe))).Methods("PATCH")
+    meRouter.HandleFunc("", userHandler.DeleteMe).Methods("DELETE")
+    meRouter.HandleFunc("/password", userHandler.ChangeMyPassword).Methods("POST")
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"project/internal/handlers"
+	"project/internal/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+func RegisterOAuthServerRoutes(router *mux.Router, oauthServerHandler *handlers.OAuthServerHandler) {
+    // Public protocol endpoints; clients authenticate themselves at /oauth/token
+    router.HandleFunc("/.well-known/openid-configuration", oauthServerHandler.Discovery).Methods("GET")
+    router.HandleFunc("/oauth/token", oauthServerHandler.Token).Methods("POST")
+
+    // Endpoints acting on behalf of the signed-in user
+    oauthRouter := router.PathPrefix("/oauth").Subrouter()
+    oauthRouter.Use(middleware.Auth)
+    oauthRouter.HandleFunc("/clients", oauthServerHandler.ListClients).Methods("GET")
+    oauthRouter.HandleFunc("/clients", oauthServerHandler.RegisterClient).Methods("POST")
+    oauthRouter.HandleFunc("/authorize", oauthServerHandler.Authorize).Methods("GET")
+    oauthRouter.HandleFunc("/authorize", oauthServerHandler.AuthorizeDecision).Methods("POST")
+
+    userInfoRouter := router.PathPrefix("/userinfo").Subrouter()
+    userInfoRouter.Use(middleware.Auth)
+    userInfoRouter.HandleFunc("", oauthServerHandler.UserInfo).Methods("GET", "POST")
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"project/internal/handlers"
+	"project/internal/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterOrganizationRoutes mounts organization management. Admins of the
+// default tenant manage all organizations; organization admins their own.
+func RegisterOrganizationRoutes(router *mux.Router, orgHandler *handlers.OrganizationHandler) {
+    orgRouter := router.PathPrefix("/orgs").Subrouter()
+    orgRouter.Use(middleware.Auth, middleware.RequireAdmin)
+
+    orgRouter.HandleFunc("", orgHandler.CreateOrganization).Methods("POST")
+    orgRouter.HandleFunc("", orgHandler.ListOrganizations).Methods("GET")
+    orgRouter.HandleFunc("/{id}", orgHandler.GetOrganization).Methods("GET")
+    orgRouter.HandleFunc("/{id}", orgHandler.UpdateOrganization).Methods("PUT")
+    orgRouter.HandleFunc("/{id}", orgHandler.DeleteOrganization).Methods("DELETE")
+    orgRouter.HandleFunc("/{id}/members", orgHandler.ListMembers).Methods("GET")
+    orgRouter.HandleFunc("/{id}/members", orgHandler.AddMember).Methods("POST")
+    orgRouter.HandleFunc("/{id}/members/{userId}", orgHandler.SetMemberRole).Methods("PUT")
+    orgRouter.HandleFunc("/{id}/members/{userId}", orgHandler.RemoveMember).Methods("DELETE")
+}
@@ -2,39 +2,101 @@ package routes
 
 import (
 	"net/http"
+	"project/internal/config"
 	"project/internal/handlers"
 	"project/internal/middleware"
 
 	"github.com/gorilla/mux"
 )
 
-func RegisterRoutes(router *mux.Router) {
-	// Initialize handlers
-	userHandler := handlers.NewUserHandler()
-    authHandler := handlers.NewAuthHandler()
-	// productHandler := handlers.NewProductHandler(db)
-	
-    // Global middlewares (JSON only here); Auth applied on protected subrouter below
-    router.Use(middleware.JSONMiddleware)
+// Handlers are the HTTP handlers, built once and shared by every API version.
+type Handlers struct {
+    User        *handlers.UserHandler
+    Auth        *handlers.AuthHandler
+    APIKey      *handlers.APIKeyHandler
+    OAuth       *handlers.OAuthHandler
+    OAuthServer *handlers.OAuthServerHandler
+    Admin       *handlers.AdminHandler
+    Org         *handlers.OrganizationHandler
+    Group       *handlers.GroupHandler
+    Invitation  *handlers.InvitationHandler
+    Docs        *handlers.DocsHandler
+}
+
+// NewHandlers wires every handler with cfg, the configuration loaded once at
+// startup.
+func NewHandlers(cfg *config.Config) *Handlers {
+    return &Handlers{
+        User:        handlers.NewUserHandler(cfg),
+        Auth:        handlers.NewAuthHandler(cfg),
+        APIKey:      handlers.NewAPIKeyHandler(),
+        OAuth:       handlers.NewOAuthHandler(cfg),
+        OAuthServer: handlers.NewOAuthServerHandler(cfg),
+        Admin:       handlers.NewAdminHandler(cfg),
+        Org:         handlers.NewOrganizationHandler(),
+        Group:       handlers.NewGroupHandler(),
+        Invitation:  handlers.NewInvitationHandler(cfg),
+        Docs:        handlers.NewDocsHandler(),
+    }
+}
 
+// RegisterRoutes mounts the API resources on router; each version tree calls
+// it with the shared handlers.
+func RegisterRoutes(router *mux.Router, h *Handlers) {
     // Register public auth routes BEFORE applying auth to protected subrouter
-    RegisterAuthRoutes(router, authHandler)
+    RegisterAuthRoutes(router, h.Auth)
+    RegisterOAuthRoutes(router, h.OAuth)
+    RegisterOAuthServerRoutes(router, h.OAuthServer)
 
-    // Protected API subrouter with Auth middleware
+    // Protected API subrouter; each resource router applies Auth itself
     protected := router.PathPrefix("").Subrouter()
-    // protected.Use(middleware.Auth)
 
-    // Register all protected routes
-    RegisterUserRoutes(protected, userHandler)
+    // Register all protected routes; groups first for /users/{id}/groups
+    RegisterGroupRoutes(protected, h.Group)
+    RegisterUserRoutes(protected, h.User)
+    RegisterMeRoutes(protected, h.User)
+    RegisterAdminRoutes(protected, h.Admin)
+    RegisterOrganizationRoutes(protected, h.Org)
+    RegisterInvitationRoutes(protected, h.Invitation)
+    RegisterAPIKeyRoutes(protected, h.APIKey)
 	// RegisterProductRoutes(router, productHandler)
-	
-    // Health check (public route, JSON)
+}
+
+// RegisterAPIRoutes configures the middlewares from cfg and mounts every API
+// version under /api/{version}, plus the unversioned compatibility routes
+// when cfg.API.LegacyRoutes is set. It fails on versions cfg names but the
+// API does not have.
+func RegisterAPIRoutes(router *mux.Router, cfg *config.Config) error {
+    if err := checkVersions(cfg.API); err != nil {
+        return err
+    }
+    h := NewHandlers(cfg)
+    middleware.Configure(cfg)
+    // Global middlewares; Auth applied on protected subrouters below.
+    // RequestInfo comes first so Recover can log the request ID.
+    router.Use(middleware.RequestInfo)
+    router.Use(middleware.Recover)
+    router.Use(middleware.Compress)
+    router.Use(middleware.JSONMiddleware)
+    router.Use(middleware.Negotiate)
+    router.Use(middleware.SecurityHeaders)
+    router.Use(middleware.RateLimit)
+    // Tenant from X-Tenant-ID or the subdomain; Auth checks it against credentials
+    router.Use(middleware.Tenant)
+
+    // Health check (public route, JSON, unversioned)
     router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
         w.WriteHeader(http.StatusOK)
         jsonBody := []byte(`{"success":true,"message":"OK"}`)
         w.Write(jsonBody)
     }).Methods("GET")
 
+    // OpenAPI document and its reference page (public, unversioned)
+    router.HandleFunc("/openapi.json", h.Docs.OpenAPI).Methods("GET")
+    router.HandleFunc("/docs", h.Docs.Page).Methods("GET")
+
+    registerVersions(router, h, cfg.API)
+
     // 404/405 JSON responses
     router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
         w.Header().Set("Content-Type", "application/json")
@@ -46,12 +108,5 @@ func RegisterRoutes(router *mux.Router) {
         w.WriteHeader(http.StatusMethodNotAllowed)
         w.Write([]byte(`{"success":false,"message":"method not allowed"}`))
     })
+    return nil
 }
-
-func RegisterAPIRoutes(router *mux.Router) {
-	// API version 1 routes
-	apiV1 := router.PathPrefix("/").Subrouter()
-	// apiV1.Use(middleware.Auth) // Apply auth middleware to all API routes
-	
-	RegisterRoutes(apiV1)
-}
\ No newline at end of file
@@ -1,7 +1,10 @@
 package routes
 
 import (
+	"net/http"
 	"project/internal/handlers"
+	"project/internal/middleware"
+	"project/internal/models"
 
 	"github.com/gorilla/mux"
 )
@@ -9,12 +12,24 @@ import (
 func RegisterUserRoutes(router *mux.Router, userHandler *handlers.UserHandler) {
 	// User routes
 	userRouter := router.PathPrefix("/users").Subrouter()
+	// Auth also checks that credentials belong to the tenant the request names
+	userRouter.Use(middleware.Auth, middleware.Idempotency)
 	
-	userRouter.HandleFunc("", userHandler.GetUsers).Methods("GET")
-	userRouter.HandleFunc("", userHandler.CreateUser).Methods("POST")
-	userRouter.HandleFunc("/{id}", userHandler.GetUser).Methods("GET")
-	userRouter.HandleFunc("/{id}", userHandler.UpdateUser).Methods("PUT")
-	userRouter.HandleFunc("/{id}", userHandler.DeleteUser).Methods("DELETE")
+	// API keys must carry the matching scope; JWT sessions are unaffected
+	read := middleware.RequireScope(models.ScopeUsersRead)
+	write := middleware.RequireScope(models.ScopeUsersWrite)
+
+	userRouter.Handle("", read(http.HandlerFunc(userHandler.GetUsers))).Methods("GET")
+	userRouter.Handle("", write(http.HandlerFunc(userHandler.CreateUser))).Methods("POST")
+	// Before /{id} so "search" is not taken as an ID
+	userRouter.Handle("/search", read(http.HandlerFunc(userHandler.SearchUsers))).Methods("GET")
+	userRouter.Handle("/{id}", read(http.HandlerFunc(userHandler.GetUser))).Methods("GET")
+	userRouter.Handle("/{id}", write(http.HandlerFunc(userHandler.UpdateUser))).Methods("PUT")
+	userRouter.Handle("/{id}", write(http.HandlerFunc(userHandler.PatchUser))).Methods("PATCH")
+	userRouter.Handle("/{id}", write(http.HandlerFunc(userHandler.DeleteUser))).Methods("DELETE")
+	userRouter.Handle("/{id}/avatar", write(http.HandlerFunc(userHandler.PutAvatar))).Methods("PUT")
+	userRouter.Handle("/{id}/avatar", read(http.HandlerFunc(userHandler.GetAvatar))).Methods("GET")
+	userRouter.Handle("/{id}/avatar", write(http.HandlerFunc(userHandler.DeleteAvatar))).Methods("DELETE")
 	
     // Authentication routes moved to auth routes file
 }
\ No newline at end of file
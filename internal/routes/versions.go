@@ -0,0 +1,116 @@
+package routes
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"project/internal/config"
+	"project/internal/middleware"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// apiVersion is one route tree under /api/{name}. Versions share handlers; a
+// version that changes an endpoint registers its own handler for it.
+type apiVersion struct {
+    name     string
+    register func(*mux.Router, *Handlers)
+}
+
+// apiVersions are oldest first; the last is the current version, named as
+// the successor in deprecation links.
+var apiVersions = []apiVersion{
+    {name: "v1", register: RegisterRoutes},
+    {name: "v2", register: RegisterRoutes},
+}
+
+// APIVersions returns the names of the versions served, oldest first.
+func APIVersions() []string {
+    names := make([]string, len(apiVersions))
+    for i, v := range apiVersions {
+        names[i] = v.name
+    }
+    return names
+}
+
+func knownVersion(name string) bool {
+    for _, v := range apiVersions {
+        if v.name == name {
+            return true
+        }
+    }
+    return false
+}
+
+func checkVersions(cfg config.APIConfig) error {
+    if !knownVersion(cfg.DefaultVersion) {
+        return fmt.Errorf("api.default_version: unknown version %q, have %s", cfg.DefaultVersion, strings.Join(APIVersions(), ", "))
+    }
+    for name := range cfg.Deprecations {
+        if !knownVersion(name) {
+            return fmt.Errorf("api.deprecations: unknown version %q, have %s", name, strings.Join(APIVersions(), ", "))
+        }
+    }
+    return nil
+}
+
+// registerVersions mounts each version at /api/{version} and, if enabled,
+// again without a prefix for the version the Accept header selects.
+func registerVersions(router *mux.Router, h *Handlers, cfg config.APIConfig) {
+    current := "/api/" + apiVersions[len(apiVersions)-1].name
+    mount := func(tree *mux.Router, v apiVersion, basePath string) {
+        var dep *config.APIDeprecation
+        if d, ok := cfg.Deprecations[v.name]; ok {
+            dep = &d
+        }
+        tree.Use(middleware.APIVersion(v.name, basePath, dep, current))
+        v.register(tree, h)
+    }
+    for _, v := range apiVersions {
+        basePath := "/api/" + v.name
+        mount(router.PathPrefix(basePath).Subrouter(), v, basePath)
+    }
+    if !cfg.LegacyRoutes {
+        return
+    }
+    for _, v := range apiVersions {
+        name := v.name
+        legacy := router.MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+            requested := requestedVersion(r)
+            if requested == "" {
+                requested = cfg.DefaultVersion
+            }
+            return requested == name
+        }).Subrouter()
+        mount(legacy, v, "")
+    }
+    // Anything asking for a version we do not have
+    router.MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+        requested := requestedVersion(r)
+        return requested != "" && !knownVersion(requested)
+    }).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusNotAcceptable)
+        w.Write([]byte(`{"success":false,"message":"unsupported API version, use ` + strings.Join(APIVersions(), " or ") + `"}`))
+    })
+}
+
+// requestedVersion reads the version parameter of the Accept header, as in
+// "application/json; version=2"; "2" and "v2" are the same. It returns "" if
+// the header names none.
+func requestedVersion(r *http.Request) string {
+    for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+        _, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+        if err != nil {
+            continue
+        }
+        if v := strings.ToLower(strings.TrimSpace(params["version"])); v != "" {
+            if !strings.HasPrefix(v, "v") {
+                v = "v" + v
+            }
+            return v
+        }
+    }
+    return ""
+}
@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Refresher polls a provider for a set of secrets and reports the ones whose
+// value changed, so rotated credentials can be applied while running.
+type Refresher struct {
+    provider Provider
+    onChange func(name, value string) error
+
+    mu     sync.Mutex
+    values map[string]string
+}
+
+// NewRefresher watches the names in current, starting from their current
+// values. onChange is called from the refreshing goroutine for each change;
+// when it fails the change is offered again on the next refresh.
+func NewRefresher(provider Provider, current map[string]string, onChange func(name, value string) error) *Refresher {
+    values := make(map[string]string, len(current))
+    for name, value := range current {
+        values[name] = value
+    }
+    return &Refresher{provider: provider, onChange: onChange, values: values}
+}
+
+// Refresh reads every watched secret once. Secrets that fail to load keep
+// their previous value; the errors are joined.
+func (r *Refresher) Refresh(ctx context.Context) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    var errs []error
+    for name, old := range r.values {
+        value, err := r.provider.Secret(ctx, name)
+        if errors.Is(err, ErrNotFound) {
+            continue
+        }
+        if err != nil {
+            errs = append(errs, err)
+            continue
+        }
+        if value == old {
+            continue
+        }
+        if err := r.onChange(name, value); err != nil {
+            errs = append(errs, err)
+            continue
+        }
+        r.values[name] = value
+    }
+    return errors.Join(errs...)
+}
+
+// Run refreshes every interval until ctx is done. Failures are logged and
+// retried on the next tick.
+func (r *Refresher) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if err := r.Refresh(ctx); err != nil {
+                log.Printf("secrets: refresh failed: %v", err)
+            }
+        }
+    }
+}
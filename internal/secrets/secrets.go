@@ -0,0 +1,81 @@
+// Package secrets reads credentials from the environment, mounted files or a
+// Vault-compatible server, and refreshes them periodically so rotated values
+// are picked up without a restart.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned by providers that do not hold the named secret.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up secrets by name. Names are environment-style, such as
+// JWT_SECRET or MONGO_URI.
+type Provider interface {
+    Secret(ctx context.Context, name string) (string, error)
+}
+
+// LookupEnv reads name from the environment or, when name_FILE is set, from
+// the file it points to, as with Docker and Kubernetes secrets. Setting both
+// is an error. A trailing newline in the file is ignored.
+func LookupEnv(name string) (string, bool, error) {
+    value := os.Getenv(name)
+    path := os.Getenv(name + "_FILE")
+    if path == "" {
+        return value, value != "", nil
+    }
+    if value != "" {
+        return "", false, fmt.Errorf("%s and %s_FILE are both set", name, name)
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return "", false, fmt.Errorf("%s_FILE: %w", name, err)
+    }
+    return trimNewline(string(data)), true, nil
+}
+
+func trimNewline(s string) string {
+    return strings.TrimRight(s, "\r\n")
+}
+
+// EnvProvider reads secrets with LookupEnv.
+type EnvProvider struct{}
+
+func (EnvProvider) Secret(ctx context.Context, name string) (string, error) {
+    value, ok, err := LookupEnv(name)
+    if err != nil {
+        return "", err
+    }
+    if !ok {
+        return "", ErrNotFound
+    }
+    return value, nil
+}
+
+// FileProvider reads each secret from a file named after it in Dir, e.g.
+// /run/secrets/JWT_SECRET. The lowercase name is tried as well.
+type FileProvider struct {
+    Dir string
+}
+
+func (p FileProvider) Secret(ctx context.Context, name string) (string, error) {
+    if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+        return "", fmt.Errorf("invalid secret name %q", name)
+    }
+    for _, file := range []string{name, strings.ToLower(name)} {
+        data, err := os.ReadFile(filepath.Join(p.Dir, file))
+        if err == nil {
+            return trimNewline(string(data)), nil
+        }
+        if !errors.Is(err, os.ErrNotExist) {
+            return "", err
+        }
+    }
+    return "", ErrNotFound
+}
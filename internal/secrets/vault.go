@@ -0,0 +1,78 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VaultProvider reads secrets from one key/value (v2) secret of a HashiCorp
+// Vault compatible server: GET {Addr}/v1/{Mount}/data/{Path}. Each key of the
+// secret is a secret name.
+type VaultProvider struct {
+    Addr   string
+    Token  string
+    Mount  string // defaults to "secret"
+    Path   string
+    Client *http.Client // defaults to a client with a 10s timeout
+}
+
+type vaultResponse struct {
+    Data struct {
+        Data map[string]interface{} `json:"data"`
+    } `json:"data"`
+    Errors []string `json:"errors"`
+}
+
+func (p *VaultProvider) Secret(ctx context.Context, name string) (string, error) {
+    values, err := p.read(ctx)
+    if err != nil {
+        return "", err
+    }
+    value, ok := values[name]
+    if !ok || value == nil {
+        return "", ErrNotFound
+    }
+    if s, ok := value.(string); ok {
+        return s, nil
+    }
+    return fmt.Sprint(value), nil
+}
+
+// read fetches the whole secret; the versions and metadata are ignored.
+func (p *VaultProvider) read(ctx context.Context) (map[string]interface{}, error) {
+    mount := p.Mount
+    if mount == "" {
+        mount = "secret"
+    }
+    endpoint := strings.TrimRight(p.Addr, "/") + "/v1/" + url.PathEscape(mount) + "/data/" + strings.Trim(p.Path, "/")
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("X-Vault-Token", p.Token)
+    client := p.Client
+    if client == nil {
+        client = &http.Client{Timeout: 10 * time.Second}
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("vault: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode == http.StatusNotFound {
+        return nil, ErrNotFound
+    }
+    var body vaultResponse
+    if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+        return nil, fmt.Errorf("vault: invalid response: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("vault: %s: %s", resp.Status, strings.Join(body.Errors, "; "))
+    }
+    return body.Data.Data, nil
+}
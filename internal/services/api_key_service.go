@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/repositories"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// apiKeyPrefix marks our keys so they are recognisable in logs and secret scanners.
+const apiKeyPrefix = "ak_"
+
+var (
+    ErrAPIKeyNameRequired = errors.New("api key name is required")
+    ErrAPIKeyExpiry       = errors.New("expires_at must be in the future")
+    ErrScopeRequired      = errors.New("at least one scope is required")
+    ErrUnknownScope       = errors.New("unknown scope")
+)
+
+type APIKeyService struct {
+    keyRepo repositories.APIKeyRepositoryInterface
+}
+
+func NewAPIKeyService(keyRepo repositories.APIKeyRepositoryInterface) *APIKeyService {
+    return &APIKeyService{keyRepo: keyRepo}
+}
+
+// hashSecret is used for high-entropy secrets (API keys, OAuth tokens), so a
+// fast unsalted hash is sufficient for lookup and comparison.
+func hashSecret(raw string) string {
+    sum := sha256.Sum256([]byte(raw))
+    return hex.EncodeToString(sum[:])
+}
+
+// generateAPIKey returns a key of the form ak_<prefix>_<secret> and its prefix.
+func generateAPIKey() (string, string, error) {
+    prefixBytes := make([]byte, 6)
+    if _, err := rand.Read(prefixBytes); err != nil {
+        return "", "", err
+    }
+    secretBytes := make([]byte, 32)
+    if _, err := rand.Read(secretBytes); err != nil {
+        return "", "", err
+    }
+    prefix := hex.EncodeToString(prefixBytes)
+    return apiKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes), prefix, nil
+}
+
+// parseAPIKeyPrefix extracts the lookup prefix from a raw key.
+func parseAPIKeyPrefix(raw string) (string, bool) {
+    if !strings.HasPrefix(raw, apiKeyPrefix) {
+        return "", false
+    }
+    parts := strings.SplitN(raw[len(apiKeyPrefix):], "_", 2)
+    if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+        return "", false
+    }
+    return parts[0], true
+}
+
+func validateScopes(scopes []string) error {
+    if len(scopes) == 0 {
+        return ErrScopeRequired
+    }
+    for _, s := range scopes {
+        known := false
+        for _, allowed := range models.APIKeyScopes {
+            if s == allowed {
+                known = true
+                break
+            }
+        }
+        if !known {
+            return fmt.Errorf("%w: %s", ErrUnknownScope, s)
+        }
+    }
+    return nil
+}
+
+// CreateKey issues a new key for the user in the caller's tenant. The plaintext
+// key is only ever returned from here; afterwards only its prefix is visible.
+func (s *APIKeyService) CreateKey(ctx context.Context, userIDStr, name string, scopes []string, expiresAt *time.Time) (*models.CreatedAPIKeyResponse, error) {
+    userID, err := primitive.ObjectIDFromHex(userIDStr)
+    if err != nil {
+        return nil, errors.New("invalid user ID")
+    }
+    name = strings.TrimSpace(name)
+    if name == "" {
+        return nil, ErrAPIKeyNameRequired
+    }
+    if err := validateScopes(scopes); err != nil {
+        return nil, err
+    }
+    if expiresAt != nil && !expiresAt.After(time.Now()) {
+        return nil, ErrAPIKeyExpiry
+    }
+
+    raw, prefix, err := generateAPIKey()
+    if err != nil {
+        return nil, errors.New("failed to generate api key")
+    }
+    key, err := s.keyRepo.Create(models.APIKey{
+        UserID:    userID,
+        TenantID:  authctx.TenantID(ctx),
+        Name:      name,
+        Prefix:    prefix,
+        Hash:      hashSecret(raw),
+        Scopes:    scopes,
+        ExpiresAt: expiresAt,
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &models.CreatedAPIKeyResponse{APIKeyResponse: *key.ToResponse(), Key: raw}, nil
+}
+
+func (s *APIKeyService) ListKeys(userIDStr string) ([]models.APIKeyResponse, error) {
+    keys, err := s.keyRepo.FindByUser(userIDStr)
+    if err != nil {
+        return nil, err
+    }
+    res := make([]models.APIKeyResponse, 0, len(keys))
+    for i := range keys {
+        res = append(res, *keys[i].ToResponse())
+    }
+    return res, nil
+}
+
+func (s *APIKeyService) RevokeKey(userIDStr, idStr string) error {
+    if idStr == "" {
+        return errors.New("api key ID is required")
+    }
+    if err := s.keyRepo.Revoke(userIDStr, idStr); err != nil {
+        return errors.New("api key not found")
+    }
+    return nil
+}
+
+// Authenticate resolves a raw key to its stored record and records its use.
+func (s *APIKeyService) Authenticate(raw string) (*models.APIKey, error) {
+    prefix, ok := parseAPIKeyPrefix(strings.TrimSpace(raw))
+    if !ok {
+        return nil, errors.New("invalid api key")
+    }
+    key, err := s.keyRepo.FindByPrefix(prefix)
+    if err != nil || key == nil {
+        return nil, errors.New("invalid api key")
+    }
+    if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashSecret(strings.TrimSpace(raw)))) != 1 {
+        return nil, errors.New("invalid api key")
+    }
+    if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+        return nil, errors.New("api key expired")
+    }
+    // Failing to record usage must not reject an otherwise valid key.
+    _ = s.keyRepo.TouchLastUsed(key.ID.Hex())
+    return key, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/repositories"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// maxAuditPageSize caps GET /admin/audit; exports stream without a cap.
+const maxAuditPageSize = 500
+
+// AuditService writes the append-only audit log. A nil *AuditService is valid
+// and records nothing, so services can be used without auditing in tests.
+type AuditService struct {
+    auditRepo repositories.AuditRepositoryInterface
+}
+
+func NewAuditService(auditRepo repositories.AuditRepositoryInterface) *AuditService {
+    return &AuditService{auditRepo: auditRepo}
+}
+
+// isSecretField reports whether a field must never appear in the audit log.
+func isSecretField(name string) bool {
+    name = strings.ToLower(name)
+    for _, s := range []string{"password", "secret", "token", "hash"} {
+        if strings.Contains(name, s) {
+            return true
+        }
+    }
+    return false
+}
+
+// toAuditMap converts a value to its JSON field map.
+func toAuditMap(v interface{}) map[string]interface{} {
+    if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+        return nil
+    }
+    raw, err := json.Marshal(v)
+    if err != nil {
+        return nil
+    }
+    m := map[string]interface{}{}
+    if err := json.Unmarshal(raw, &m); err != nil {
+        return nil
+    }
+    return m
+}
+
+// auditDiff keeps only the fields that changed between before and after and
+// replaces secret values with a marker, so a password change is visible
+// without leaking either hash.
+func auditDiff(before, after interface{}) (map[string]interface{}, map[string]interface{}) {
+    b, a := toAuditMap(before), toAuditMap(after)
+    if b != nil && a != nil {
+        for k, bv := range b {
+            if av, ok := a[k]; ok && reflect.DeepEqual(av, bv) {
+                delete(b, k)
+                delete(a, k)
+            }
+        }
+    }
+    for _, m := range []map[string]interface{}{b, a} {
+        for k, v := range m {
+            if isSecretField(k) && v != nil && v != "" {
+                m[k] = redacted
+            }
+        }
+    }
+    if len(b) == 0 { b = nil }
+    if len(a) == 0 { a = nil }
+    return b, a
+}
+
+// Record appends an event attributed to the principal in ctx. Audit failures
+// are logged but never fail the audited operation.
+func (s *AuditService) Record(ctx context.Context, action, targetType, targetID string, before, after interface{}, metadata map[string]interface{}) {
+    if s == nil {
+        return
+    }
+    event := models.AuditEvent{
+        Timestamp:  time.Now().UTC(),
+        Action:     action,
+        TargetType: targetType,
+        TargetID:   targetID,
+        TenantID:   authctx.TenantID(ctx),
+        Metadata:   metadata,
+    }
+    event.Before, event.After = auditDiff(before, after)
+    if p, ok := authctx.PrincipalFrom(ctx); ok {
+        event.ActorID, event.ActorMethod, event.ClientID = p.UserID, p.Method, p.ClientID
+    }
+    info := authctx.RequestInfoFrom(ctx)
+    event.IP, event.ForwardedFor, event.UserAgent, event.RequestID = info.IP, info.ForwardedFor, info.UserAgent, info.RequestID
+    if err := s.auditRepo.Insert(event); err != nil {
+        log.Printf("audit: failed to record %s on %s/%s: %v", action, targetType, targetID, err)
+    }
+}
+
+// Query returns a page of events, newest first.
+func (s *AuditService) Query(filter models.AuditFilter) ([]models.AuditEvent, error) {
+    if filter.Limit <= 0 {
+        filter.Limit = 50
+    }
+    if filter.Limit > maxAuditPageSize {
+        filter.Limit = maxAuditPageSize
+    }
+    return s.auditRepo.Find(filter)
+}
+
+// ExportJSONLines streams matching events to w, one JSON object per line.
+func (s *AuditService) ExportJSONLines(ctx context.Context, filter models.AuditFilter, w io.Writer) error {
+    enc := json.NewEncoder(w)
+    return s.auditRepo.Stream(ctx, filter, func(e models.AuditEvent) error {
+        return enc.Encode(e)
+    })
+}
@@ -1,8 +1,10 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"net/mail"
+	"project/internal/authctx"
 	"project/internal/config"
 	"project/internal/models"
 	"project/internal/repositories"
@@ -14,10 +16,13 @@ import (
 
 type AuthService struct {
     userRepo repositories.UserRepositoryInterface
+    audit    *AuditService
+    jwtCfg   config.JWTConfig
 }
 
-func NewAuthService(userRepo repositories.UserRepositoryInterface) *AuthService {
-    return &AuthService{userRepo: userRepo}
+// NewAuthService creates the service; audit may be nil to skip auditing.
+func NewAuthService(userRepo repositories.UserRepositoryInterface, audit *AuditService, jwtCfg config.JWTConfig) *AuthService {
+    return &AuthService{userRepo: userRepo, audit: audit, jwtCfg: jwtCfg}
 }
 
 // verifyPassword must match the hashing used in UserService
@@ -28,7 +33,7 @@ func verifyPasswordAuth(stored string, plain string) bool {
     return false
 }
 
-func (s *AuthService) Login(email, password string) (string,*models.UserResponse, error) {
+func (s *AuthService) Login(ctx context.Context, email, password string) (string,*models.UserResponse, error) {
     email = strings.TrimSpace(strings.ToLower(email))
     if email == "" || password == "" {
         return "",nil, errors.New("invalid email or password")
@@ -36,18 +41,23 @@ func (s *AuthService) Login(email, password string) (string,*models.UserResponse
     if _, err := mail.ParseAddress(email); err != nil {
         return "",nil, errors.New("invalid email or password")
     }
-    user, err := s.userRepo.FindByEmail(email)
+    users := s.userRepo.ForTenant(authctx.TenantID(ctx))
+    user, err := users.FindByEmail(email)
     if err != nil || user == nil {
+        s.audit.Record(ctx, models.AuditLoginFailed, "user", "", nil, nil, map[string]interface{}{"email": email, "reason": "unknown_email"})
         return "",nil, errors.New("invalid email or password")
     }
     if !verifyPasswordAuth(user.Password, password) {
+        s.audit.Record(ctx, models.AuditLoginFailed, "user", user.ID.Hex(), nil, nil, map[string]interface{}{"email": email, "reason": "wrong_password"})
         return "",nil, errors.New("invalid email or password")
     }
-    cfg := config.LoadConfig()
-    token, err := utils.GenerateJWT(user.ID.Hex(), cfg.JWT.Secret, cfg.JWT.Expiry)
+    token, err := utils.GenerateJWT(user.ID.Hex(), user.TenantID, s.jwtCfg.Secret, s.jwtCfg.Expiry)
     if err != nil {
         return "",nil, errors.New("failed to generate token")
     }
+    // The caller is anonymous; attribute the login to the user it authenticated as
+    actorCtx := authctx.WithPrincipal(ctx, &authctx.Principal{UserID: user.ID.Hex(), Method: authctx.MethodJWT, TenantID: user.TenantID})
+    s.audit.Record(actorCtx, models.AuditLoginSucceeded, "user", user.ID.Hex(), nil, nil, nil)
     return  token, user.ToResponse(), nil
 }
 
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+    MaxAvatarBytes     = 5 << 20
+    maxAvatarDimension = 4096 // per side, checked before decoding
+)
+
+var (
+    ErrAvatarTooLarge    = errors.New("avatar must be at most 5 MB")
+    ErrUnsupportedImage  = errors.New("avatar must be a JPEG, PNG, GIF or WebP image")
+    ErrAvatarDimensions  = errors.New("avatar must be at most 4096×4096 pixels")
+    ErrAvatarNotFound    = errors.New("avatar not found")
+    ErrInvalidAvatarSize = errors.New("unknown avatar size")
+)
+
+// avatarTypes are the image formats accepted for avatars, as sniffed from
+// the content rather than taken from the client.
+var avatarTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true, "image/webp": true}
+
+// AvatarService stores profile pictures with a set of square thumbnails.
+type AvatarService struct {
+    userRepo repositories.UserRepositoryInterface
+    fileRepo repositories.FileRepositoryInterface
+    audit    *AuditService
+}
+
+// NewAvatarService creates the service; audit may be nil to skip auditing.
+func NewAvatarService(userRepo repositories.UserRepositoryInterface, fileRepo repositories.FileRepositoryInterface, audit *AuditService) *AvatarService {
+    return &AvatarService{userRepo: userRepo, fileRepo: fileRepo, audit: audit}
+}
+
+func (s *AvatarService) users(ctx context.Context) repositories.UserRepositoryInterface {
+    return s.userRepo.ForTenant(authctx.TenantID(ctx))
+}
+
+// decodeAvatar sniffs and decodes data, refusing formats we do not serve and
+// images whose dimensions would make decoding expensive.
+func decodeAvatar(data []byte) (image.Image, string, error) {
+    if len(data) > MaxAvatarBytes {
+        return nil, "", ErrAvatarTooLarge
+    }
+    mime := mimetype.Detect(data).String()
+    if !avatarTypes[mime] {
+        return nil, "", ErrUnsupportedImage
+    }
+    cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+    if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+        return nil, "", ErrUnsupportedImage
+    }
+    if cfg.Width > maxAvatarDimension || cfg.Height > maxAvatarDimension {
+        return nil, "", ErrAvatarDimensions
+    }
+    img, _, err := image.Decode(bytes.NewReader(data))
+    if err != nil {
+        return nil, "", ErrUnsupportedImage
+    }
+    return img, mime, nil
+}
+
+// encodeThumbnail keeps photos as JPEG; other formats may have transparency
+// and become PNG.
+func encodeThumbnail(img image.Image, sourceType string) ([]byte, string, error) {
+    var buf bytes.Buffer
+    if sourceType == "image/jpeg" {
+        err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+        return buf.Bytes(), "image/jpeg", err
+    }
+    err := png.Encode(&buf, img)
+    return buf.Bytes(), "image/png", err
+}
+
+// SetAvatar stores data as the user's avatar, replacing any previous one.
+func (s *AvatarService) SetAvatar(ctx context.Context, idStr string, data []byte) (*models.UserResponse, error) {
+    users := s.users(ctx)
+    user, err := users.FindUserByID(idStr)
+    if err != nil {
+        return nil, ErrUserNotFound
+    }
+    previous := user.Avatar
+    img, mime, err := decodeAvatar(data)
+    if err != nil {
+        return nil, err
+    }
+
+    avatar := &models.Avatar{Files: map[string]string{}, UpdatedAt: time.Now()}
+    save := func(size, contentType string, content []byte) error {
+        id, err := s.fileRepo.Save(idStr+"-"+size, contentType, bytes.NewReader(content))
+        if err == nil {
+            avatar.Files[size] = id
+        }
+        return err
+    }
+    err = save(models.AvatarOriginal, mime, data)
+    for _, size := range models.AvatarThumbnailSizes {
+        if err != nil {
+            break
+        }
+        var thumb []byte
+        var thumbType string
+        thumb, thumbType, err = encodeThumbnail(utils.SquareThumbnail(img, size), mime)
+        if err == nil {
+            err = save(strconv.Itoa(size), thumbType, thumb)
+        }
+    }
+    if err == nil {
+        err = users.SetAvatar(idStr, avatar)
+    }
+    if err != nil {
+        s.deleteFiles(avatar)
+        return nil, errors.New("failed to store avatar: " + err.Error())
+    }
+    if previous != nil {
+        s.deleteFiles(previous)
+    }
+    s.audit.Record(ctx, models.AuditAvatarChanged, "user", idStr, nil, nil, map[string]interface{}{"content_type": mime, "bytes": len(data)})
+    return users.FindByID(idStr)
+}
+
+// deleteFiles removes an avatar's files. Failures only leave orphaned files
+// behind, so they are ignored.
+func (s *AvatarService) deleteFiles(avatar *models.Avatar) {
+    for _, id := range avatar.Files {
+        s.fileRepo.Delete(id)
+    }
+}
+
+// Avatar opens the user's avatar in size, a thumbnail edge in pixels or
+// "original". The caller must close the reader.
+func (s *AvatarService) Avatar(ctx context.Context, idStr, size string) (*models.StoredFile, io.ReadSeekCloser, error) {
+    if size == "" {
+        size = models.DefaultAvatarSize
+    }
+    user, err := s.users(ctx).FindUserByID(idStr)
+    if err != nil {
+        return nil, nil, ErrUserNotFound
+    }
+    if user.Avatar == nil {
+        return nil, nil, ErrAvatarNotFound
+    }
+    fileID, ok := user.Avatar.Files[size]
+    if !ok {
+        return nil, nil, ErrInvalidAvatarSize
+    }
+    file, content, err := s.fileRepo.Open(fileID)
+    if err != nil {
+        return nil, nil, ErrAvatarNotFound
+    }
+    return file, content, nil
+}
+
+func (s *AvatarService) DeleteAvatar(ctx context.Context, idStr string) error {
+    users := s.users(ctx)
+    user, err := users.FindUserByID(idStr)
+    if err != nil {
+        return ErrUserNotFound
+    }
+    if user.Avatar == nil {
+        return ErrAvatarNotFound
+    }
+    previous := user.Avatar
+    if err := users.SetAvatar(idStr, nil); err != nil {
+        return err
+    }
+    s.deleteFiles(previous)
+    s.audit.Record(ctx, models.AuditAvatarChanged, "user", idStr, nil, nil, map[string]interface{}{"removed": true})
+    return nil
+}
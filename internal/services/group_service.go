@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/repositories"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+    ErrGroupNotFound     = errors.New("group not found")
+    ErrGroupForbidden    = errors.New("only group owners and admins can manage this group")
+    ErrGroupLastOwner    = errors.New("a group must keep at least one owner")
+    ErrGroupPermissions  = errors.New("only admins can grant permissions to a group")
+    ErrUnknownPermission = errors.New("unknown permission")
+)
+
+// GroupService manages teams of users within a tenant. Permissions granted
+// to a group apply to each of its members; see middleware.Auth.
+type GroupService struct {
+    groupRepo repositories.GroupRepositoryInterface
+    userRepo  repositories.UserRepositoryInterface
+    audit     *AuditService
+}
+
+// NewGroupService creates the service; audit may be nil to skip auditing.
+func NewGroupService(groupRepo repositories.GroupRepositoryInterface, userRepo repositories.UserRepositoryInterface, audit *AuditService) *GroupService {
+    return &GroupService{groupRepo: groupRepo, userRepo: userRepo, audit: audit}
+}
+
+// groups returns the repository scoped to the tenant of the request.
+func (s *GroupService) groups(ctx context.Context) repositories.GroupRepositoryInterface {
+    return s.groupRepo.ForTenant(authctx.TenantID(ctx))
+}
+
+func (s *GroupService) isAdmin(ctx context.Context) bool {
+    return callerIsAdmin(ctx, s.userRepo)
+}
+
+// callerIsAdmin reports whether the caller is an admin of their tenant, by
+// role or through a group granting the admin permission.
+func callerIsAdmin(ctx context.Context, userRepo repositories.UserRepositoryInterface) bool {
+    p, ok := authctx.PrincipalFrom(ctx)
+    if !ok || !p.HasScope(models.ScopeAdmin) {
+        return false
+    }
+    if p.HasPermission(models.ScopeAdmin) {
+        return true
+    }
+    user, err := userRepo.ForTenant(authctx.TenantID(ctx)).FindUserByID(p.UserID)
+    return err == nil && user.Role == models.RoleAdmin
+}
+
+// canManage reports whether the caller owns group or is an admin.
+func (s *GroupService) canManage(ctx context.Context, group *models.Group) bool {
+    return group.RoleOf(authctx.UserID(ctx)) == models.GroupRoleOwner || s.isAdmin(ctx)
+}
+
+func normalizePermissions(perms []string) ([]string, error) {
+    out := []string{}
+    seen := map[string]bool{}
+    for _, p := range perms {
+        p = strings.TrimSpace(p)
+        if seen[p] {
+            continue
+        }
+        if !containsString(models.APIKeyScopes, p) {
+            return nil, fmt.Errorf("%w: %q", ErrUnknownPermission, p)
+        }
+        seen[p] = true
+        out = append(out, p)
+    }
+    return out, nil
+}
+
+func groupError(err error) error {
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return ErrGroupNotFound
+    }
+    return err
+}
+
+// Create makes the caller the group's first owner.
+func (s *GroupService) Create(ctx context.Context, req models.GroupRequest) (*models.Group, error) {
+    userID := authctx.UserID(ctx)
+    if userID == "" {
+        return nil, ErrGroupForbidden
+    }
+    perms, err := normalizePermissions(req.Permissions)
+    if err != nil {
+        return nil, err
+    }
+    if len(perms) > 0 && !s.isAdmin(ctx) {
+        return nil, ErrGroupPermissions
+    }
+    group, err := s.groups(ctx).Create(models.Group{
+        Name:        strings.TrimSpace(req.Name),
+        Description: strings.TrimSpace(req.Description),
+        Permissions: perms,
+        Members:     []models.GroupMember{{UserID: userID, Role: models.GroupRoleOwner, AddedAt: time.Now()}},
+        CreatedBy:   userID,
+    })
+    if err != nil {
+        return nil, err
+    }
+    s.audit.Record(ctx, models.AuditGroupCreated, "group", group.ID.Hex(), nil, group, nil)
+    return group, nil
+}
+
+func (s *GroupService) List(ctx context.Context) ([]models.Group, error) {
+    return s.groups(ctx).FindAll()
+}
+
+func (s *GroupService) Get(ctx context.Context, idStr string) (*models.Group, error) {
+    group, err := s.groups(ctx).FindByID(idStr)
+    if err != nil {
+        return nil, ErrGroupNotFound
+    }
+    return group, nil
+}
+
+// Update renames a group; changing its permissions needs an admin.
+func (s *GroupService) Update(ctx context.Context, idStr string, req models.GroupRequest) (*models.Group, error) {
+    before, err := s.Get(ctx, idStr)
+    if err != nil {
+        return nil, err
+    }
+    if !s.canManage(ctx, before) {
+        return nil, ErrGroupForbidden
+    }
+    perms, err := normalizePermissions(req.Permissions)
+    if err != nil {
+        return nil, err
+    }
+    if !sameStrings(perms, before.Permissions) && !s.isAdmin(ctx) {
+        return nil, ErrGroupPermissions
+    }
+    after, err := s.groups(ctx).Update(idStr, strings.TrimSpace(req.Name), strings.TrimSpace(req.Description), perms)
+    if err != nil {
+        return nil, groupError(err)
+    }
+    s.audit.Record(ctx, models.AuditGroupUpdated, "group", idStr, before, after, nil)
+    return after, nil
+}
+
+func sameStrings(a, b []string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for _, v := range a {
+        if !containsString(b, v) {
+            return false
+        }
+    }
+    return true
+}
+
+func (s *GroupService) Delete(ctx context.Context, idStr string) error {
+    group, err := s.Get(ctx, idStr)
+    if err != nil {
+        return err
+    }
+    if !s.canManage(ctx, group) {
+        return ErrGroupForbidden
+    }
+    if err := s.groups(ctx).Delete(idStr); err != nil {
+        return groupError(err)
+    }
+    s.audit.Record(ctx, models.AuditGroupDeleted, "group", idStr, group, nil, nil)
+    return nil
+}
+
+// AddMember adds a user of the same tenant to the group, or changes the role
+// of an existing member. Role defaults to member.
+func (s *GroupService) AddMember(ctx context.Context, idStr string, req models.GroupMemberRequest) (*models.Group, error) {
+    group, err := s.Get(ctx, idStr)
+    if err != nil {
+        return nil, err
+    }
+    if !s.canManage(ctx, group) {
+        return nil, ErrGroupForbidden
+    }
+    // Members inherit the group's permissions, so only admins may hand them out
+    if len(group.Permissions) > 0 && !s.isAdmin(ctx) {
+        return nil, ErrGroupPermissions
+    }
+    if _, err := s.userRepo.ForTenant(authctx.TenantID(ctx)).FindUserByID(req.UserID); err != nil {
+        return nil, ErrMemberNotFound
+    }
+    role := req.Role
+    if role == "" {
+        role = models.GroupRoleMember
+    }
+    if group.RoleOf(req.UserID) == models.GroupRoleOwner && role != models.GroupRoleOwner && countOwners(group) == 1 {
+        return nil, ErrGroupLastOwner
+    }
+    if err := s.groups(ctx).SetMember(idStr, models.GroupMember{UserID: req.UserID, Role: role, AddedAt: time.Now()}); err != nil {
+        return nil, groupError(err)
+    }
+    s.audit.Record(ctx, models.AuditGroupJoined, "group", idStr, nil, nil, map[string]interface{}{"user_id": req.UserID, "role": role})
+    return s.Get(ctx, idStr)
+}
+
+func countOwners(group *models.Group) int {
+    n := 0
+    for _, m := range group.Members {
+        if m.Role == models.GroupRoleOwner {
+            n++
+        }
+    }
+    return n
+}
+
+// RemoveMember removes a user from the group. Members may always leave; the
+// last owner may not.
+func (s *GroupService) RemoveMember(ctx context.Context, idStr, userID string) error {
+    group, err := s.Get(ctx, idStr)
+    if err != nil {
+        return err
+    }
+    if userID != authctx.UserID(ctx) && !s.canManage(ctx, group) {
+        return ErrGroupForbidden
+    }
+    role := group.RoleOf(userID)
+    if role == "" {
+        return ErrMemberNotFound
+    }
+    if role == models.GroupRoleOwner && countOwners(group) == 1 {
+        return ErrGroupLastOwner
+    }
+    if err := s.groups(ctx).RemoveMember(idStr, userID); err != nil {
+        return groupError(err)
+    }
+    s.audit.Record(ctx, models.AuditGroupLeft, "group", idStr, nil, nil, map[string]interface{}{"user_id": userID})
+    return nil
+}
+
+// UserGroups lists the groups userID belongs to with their role in each.
+func (s *GroupService) UserGroups(ctx context.Context, userID string) ([]models.UserGroup, error) {
+    if _, err := s.userRepo.ForTenant(authctx.TenantID(ctx)).FindUserByID(userID); err != nil {
+        return nil, ErrMemberNotFound
+    }
+    groups, err := s.groups(ctx).FindByMember(userID)
+    if err != nil {
+        return nil, err
+    }
+    res := make([]models.UserGroup, 0, len(groups))
+    for i := range groups {
+        res = append(res, models.UserGroup{
+            ID:          groups[i].ID.Hex(),
+            Name:        groups[i].Name,
+            Role:        groups[i].RoleOf(userID),
+            Permissions: groups[i].Permissions,
+        })
+    }
+    return res, nil
+}
+
+// Memberships returns the IDs of userID's groups in tenantID and the union of
+// the permissions they grant. middleware.Auth puts both on the Principal.
+func (s *GroupService) Memberships(tenantID, userID string) ([]string, []string, error) {
+    groups, err := s.groupRepo.ForTenant(tenantID).FindByMember(userID)
+    if err != nil {
+        return nil, nil, err
+    }
+    ids := make([]string, 0, len(groups))
+    perms := []string{}
+    for _, g := range groups {
+        ids = append(ids, g.ID.Hex())
+        for _, p := range g.Permissions {
+            if !containsString(perms, p) {
+                perms = append(perms, p)
+            }
+        }
+    }
+    return ids, perms, nil
+}
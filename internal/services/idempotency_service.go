@@ -0,0 +1,93 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"project/internal/models"
+	"project/internal/repositories"
+	"time"
+)
+
+// idempotencyLockTimeout bounds how long a pending request holds its key, so a
+// request interrupted by a crash does not block retries until the TTL.
+const idempotencyLockTimeout = time.Minute
+
+var (
+    ErrIdempotencyKeyReused  = errors.New("Idempotency-Key was already used with a different request")
+    ErrIdempotencyInProgress = errors.New("a request with this Idempotency-Key is still in progress")
+)
+
+type IdempotencyService struct {
+    repo repositories.IdempotencyRepositoryInterface
+    ttl  time.Duration
+}
+
+// NewIdempotencyService keeps stored responses for ttl.
+func NewIdempotencyService(repo repositories.IdempotencyRepositoryInterface, ttl time.Duration) *IdempotencyService {
+    return &IdempotencyService{repo: repo, ttl: ttl}
+}
+
+// idempotencyRecordID scopes a key to the user that sent it.
+func idempotencyRecordID(userID, key string) string {
+    sum := sha256.Sum256([]byte(userID + "\x00" + key))
+    return hex.EncodeToString(sum[:])
+}
+
+// Begin reserves key for a request. When the key was already completed with
+// the same request it returns the stored record for replay; otherwise it
+// returns nil and the record ID the caller must Complete or Release.
+func (s *IdempotencyService) Begin(userID, key, requestHash string) (*models.IdempotencyRecord, string, error) {
+    id := idempotencyRecordID(userID, key)
+    now := time.Now()
+    record := models.IdempotencyRecord{
+        ID:          id,
+        UserID:      userID,
+        Key:         key,
+        RequestHash: requestHash,
+        State:       models.IdempotencyPending,
+        CreatedAt:   now,
+        ExpiresAt:   now.Add(s.ttl),
+    }
+    for attempt := 0; attempt < 2; attempt++ {
+        err := s.repo.Create(record)
+        if err == nil {
+            return nil, id, nil
+        }
+        if !errors.Is(err, repositories.ErrIdempotencyKeyExists) {
+            return nil, "", err
+        }
+        existing, err := s.repo.Find(id)
+        if err != nil {
+            // Expired but not yet removed by the TTL index
+            if delErr := s.repo.Delete(id); delErr != nil {
+                return nil, "", delErr
+            }
+            continue
+        }
+        if existing.RequestHash != requestHash {
+            return nil, "", ErrIdempotencyKeyReused
+        }
+        if existing.State == models.IdempotencyCompleted {
+            return existing, id, nil
+        }
+        if now.Sub(existing.CreatedAt) < idempotencyLockTimeout {
+            return nil, "", ErrIdempotencyInProgress
+        }
+        // Abandoned by a request that never finished; take it over
+        if err := s.repo.Delete(id); err != nil {
+            return nil, "", err
+        }
+    }
+    return nil, "", ErrIdempotencyInProgress
+}
+
+// Complete stores the response for replay.
+func (s *IdempotencyService) Complete(id string, status int, headers map[string][]string, body []byte) error {
+    return s.repo.Complete(id, status, headers, body)
+}
+
+// Release frees the key so the request can be retried, e.g. after a 5xx.
+func (s *IdempotencyService) Release(id string) error {
+    return s.repo.Delete(id)
+}
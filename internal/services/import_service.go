@@ -0,0 +1,366 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/pkg/utils"
+	"strings"
+	"time"
+)
+
+const (
+    importBatchSize = 500
+    maxImportRows   = 100000
+    // DefaultInviteTTL is how long invitations from imports stay valid.
+    DefaultInviteTTL = 7 * 24 * time.Hour
+)
+
+var (
+    ErrUnsupportedImportFormat = errors.New("unsupported import format: use csv or ndjson")
+    ErrInvalidImport           = errors.New("invalid import file")
+    ErrImportTooManyRows       = fmt.Errorf("import is limited to %d rows", maxImportRows)
+)
+
+// ImportOptions controls a bulk import.
+type ImportOptions struct {
+    DryRun    bool
+    InviteTTL time.Duration
+}
+
+type ImportService struct {
+    userRepo    repositories.UserRepositoryInterface
+    jobRepo     repositories.ImportJobRepositoryInterface
+    invitations *InvitationService
+    audit       *AuditService
+}
+
+// NewImportService creates the service; jobRepo is only needed for StartJob
+// and audit may be nil. Rows without a password are sent to invitations.
+func NewImportService(userRepo repositories.UserRepositoryInterface, jobRepo repositories.ImportJobRepositoryInterface, invitations *InvitationService, audit *AuditService) *ImportService {
+    return &ImportService{userRepo: userRepo, jobRepo: jobRepo, invitations: invitations, audit: audit}
+}
+
+// importRowReader returns the next row, a per-row parse error, or io.EOF.
+type importRowReader func() (models.ImportRow, error)
+
+func newImportRowReader(format string, r io.Reader) (importRowReader, error) {
+    switch format {
+    case models.ImportFormatCSV:
+        return newCSVRowReader(r)
+    case models.ImportFormatNDJSON:
+        dec := json.NewDecoder(r)
+        dec.DisallowUnknownFields()
+        return func() (models.ImportRow, error) {
+            var row models.ImportRow
+            err := dec.Decode(&row)
+            var syntaxErr *json.SyntaxError
+            if errors.As(err, &syntaxErr) {
+                // The stream cannot be resynchronised after malformed JSON
+                return row, fmt.Errorf("%w: malformed JSON: %v", ErrInvalidImport, err)
+            }
+            if err != nil && err != io.EOF {
+                return row, &rowError{err}
+            }
+            return row, err
+        }, nil
+    default:
+        return nil, ErrUnsupportedImportFormat
+    }
+}
+
+// rowError is a parse failure confined to one row; the import continues.
+type rowError struct{ err error }
+
+func (e *rowError) Error() string { return e.err.Error() }
+
+// newCSVRowReader expects a header naming the name, email and optional
+// password columns in any order.
+func newCSVRowReader(r io.Reader) (importRowReader, error) {
+    cr := csv.NewReader(r)
+    cr.FieldsPerRecord = -1
+    cr.TrimLeadingSpace = true
+    header, err := cr.Read()
+    if err != nil {
+        return nil, fmt.Errorf("%w: cannot read CSV header: %w", ErrInvalidImport, err)
+    }
+    cols := map[string]int{}
+    for i, h := range header {
+        cols[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+    }
+    for _, required := range []string{"name", "email"} {
+        if _, ok := cols[required]; !ok {
+            return nil, fmt.Errorf("%w: CSV header must contain a %q column", ErrInvalidImport, required)
+        }
+    }
+    field := func(rec []string, name string) string {
+        if i, ok := cols[name]; ok && i < len(rec) {
+            return rec[i]
+        }
+        return ""
+    }
+    return func() (models.ImportRow, error) {
+        rec, err := cr.Read()
+        var parseErr *csv.ParseError
+        if errors.As(err, &parseErr) {
+            return models.ImportRow{}, &rowError{err}
+        }
+        if err != nil {
+            return models.ImportRow{}, err
+        }
+        return models.ImportRow{Name: field(rec, "name"), Email: field(rec, "email"), Password: field(rec, "password")}, nil
+    }, nil
+}
+
+// pendingImportRow is a valid row waiting for its batch to be written.
+type pendingImportRow struct {
+    result int // index into report.Rows
+    row    models.ImportRow
+}
+
+// Import validates every row and inserts valid users in batches; rows
+// without a password are invited instead. Duplicate emails, in the file or
+// the database, are reported per row. With DryRun nothing is written.
+func (s *ImportService) Import(ctx context.Context, format string, r io.Reader, opts ImportOptions) (*models.ImportReport, error) {
+    if opts.InviteTTL > MaxInviteTTLHours*time.Hour {
+        return nil, ErrInviteTTL
+    }
+    next, err := newImportRowReader(format, r)
+    if err != nil {
+        return nil, err
+    }
+    if opts.InviteTTL <= 0 {
+        opts.InviteTTL = DefaultInviteTTL
+    }
+    report := &models.ImportReport{DryRun: opts.DryRun, Rows: []models.ImportRowResult{}}
+    seen := map[string]int{}
+    var batch []pendingImportRow
+
+    for rowNum := 1; ; rowNum++ {
+        row, err := next()
+        if err == io.EOF {
+            break
+        }
+        if rowNum > maxImportRows {
+            return nil, ErrImportTooManyRows
+        }
+        result := models.ImportRowResult{Row: rowNum}
+        var rowErr *rowError
+        if errors.As(err, &rowErr) {
+            result.Status, result.Errors = models.ImportRowInvalid, []string{err.Error()}
+            report.Rows = append(report.Rows, result)
+            continue
+        } else if err != nil {
+            return nil, fmt.Errorf("row %d: %w", rowNum, err)
+        }
+
+        row.Name = strings.TrimSpace(row.Name)
+        row.Email = strings.TrimSpace(strings.ToLower(row.Email))
+        result.Email = row.Email
+        if errs, err := utils.ValidateStructDetailed(row); err != nil {
+            return nil, err
+        } else if len(errs) > 0 {
+            result.Status = models.ImportRowInvalid
+            for _, e := range errs {
+                result.Errors = append(result.Errors, e.Message)
+            }
+            report.Rows = append(report.Rows, result)
+            continue
+        }
+        if first, ok := seen[row.Email]; ok {
+            result.Status, result.Errors = models.ImportRowDuplicate, []string{fmt.Sprintf("email already appears in row %d", first)}
+            report.Rows = append(report.Rows, result)
+            continue
+        }
+        seen[row.Email] = rowNum
+
+        report.Rows = append(report.Rows, result)
+        batch = append(batch, pendingImportRow{result: len(report.Rows) - 1, row: row})
+        if len(batch) == importBatchSize {
+            if err := s.flushImportBatch(ctx, report, batch, opts); err != nil {
+                return nil, err
+            }
+            batch = batch[:0]
+        }
+    }
+    if err := s.flushImportBatch(ctx, report, batch, opts); err != nil {
+        return nil, err
+    }
+
+    for _, row := range report.Rows {
+        switch row.Status {
+        case models.ImportRowCreated:
+            report.Created++
+        case models.ImportRowInvited:
+            report.Invited++
+        case models.ImportRowValid:
+            report.Valid++
+        case models.ImportRowInvalid:
+            report.Invalid++
+        case models.ImportRowDuplicate:
+            report.Duplicate++
+        default:
+            report.Failed++
+        }
+    }
+    report.Total = len(report.Rows)
+    if !opts.DryRun {
+        s.audit.Record(ctx, models.AuditUsersImported, "user", "", nil, nil, map[string]interface{}{
+            "format": format, "total": report.Total, "created": report.Created, "invited": report.Invited,
+        })
+    }
+    return report, nil
+}
+
+// flushImportBatch checks a batch against existing users of the caller's
+// tenant and inserts it there, or invites rows that have no password.
+func (s *ImportService) flushImportBatch(ctx context.Context, report *models.ImportReport, batch []pendingImportRow, opts ImportOptions) error {
+    if len(batch) == 0 {
+        return nil
+    }
+    users := s.userRepo.ForTenant(authctx.TenantID(ctx))
+    emails := make([]string, len(batch))
+    for i, p := range batch {
+        emails[i] = p.row.Email
+    }
+    existing, err := users.ExistingEmails(emails)
+    if err != nil {
+        return fmt.Errorf("failed to check existing emails: %v", err)
+    }
+
+    var created []models.User
+    var inserted, invited []pendingImportRow
+    for _, p := range batch {
+        result := &report.Rows[p.result]
+        if existing[p.row.Email] {
+            result.Status, result.Errors = models.ImportRowDuplicate, []string{"email already exists"}
+            continue
+        }
+        if opts.DryRun {
+            result.Status = models.ImportRowValid
+            continue
+        }
+        if p.row.Password == "" {
+            invited = append(invited, p)
+            continue
+        }
+        hashed, err := hashPassword(p.row.Password)
+        if err != nil {
+            result.Status, result.Errors = models.ImportRowFailed, []string{"failed to hash password"}
+            continue
+        }
+        created = append(created, models.User{Name: p.row.Name, Email: p.row.Email, Password: hashed})
+        inserted = append(inserted, p)
+    }
+    s.inviteImportRows(ctx, report, invited, opts)
+    if len(created) == 0 {
+        return nil
+    }
+
+    failed, err := users.CreateMany(created)
+    if err != nil {
+        return fmt.Errorf("failed to insert users: %v", err)
+    }
+    for i, p := range inserted {
+        result := &report.Rows[p.result]
+        if werr, ok := failed[i]; ok {
+            result.Status = models.ImportRowFailed
+            if strings.Contains(werr.Error(), "already exists") {
+                result.Status = models.ImportRowDuplicate
+            }
+            result.Errors = []string{werr.Error()}
+            continue
+        }
+        result.Status, result.UserID = models.ImportRowCreated, created[i].ID.Hex()
+    }
+    return nil
+}
+
+// inviteImportRows creates a pending invitation for each row, so imported
+// invitees can be listed, resent and revoked like any other.
+func (s *ImportService) inviteImportRows(ctx context.Context, report *models.ImportReport, rows []pendingImportRow, opts ImportOptions) {
+    hours := int(opts.InviteTTL / time.Hour)
+    if hours < 1 {
+        hours = 1
+    }
+    for _, p := range rows {
+        result := &report.Rows[p.result]
+        res, err := s.invitations.Create(ctx, models.InvitationRequest{Email: p.row.Email, Name: p.row.Name, ExpiresInHours: hours})
+        switch {
+        case errors.Is(err, ErrInvitedUserExists), errors.Is(err, repositories.ErrInvitationPending):
+            result.Status, result.Errors = models.ImportRowDuplicate, []string{err.Error()}
+        case err != nil:
+            result.Status, result.Errors = models.ImportRowFailed, []string{err.Error()}
+        default:
+            result.Status, result.InvitationID, result.InviteToken = models.ImportRowInvited, res.Invitation.ID.Hex(), res.Token
+        }
+    }
+}
+
+// StartJob records a queued job and runs the import in the background. The
+// job keeps the caller's principal for auditing but not its cancellation.
+func (s *ImportService) StartJob(ctx context.Context, format string, data []byte, opts ImportOptions) (*models.ImportJob, error) {
+    if format != models.ImportFormatCSV && format != models.ImportFormatNDJSON {
+        return nil, ErrUnsupportedImportFormat
+    }
+    if opts.InviteTTL > MaxInviteTTLHours*time.Hour {
+        return nil, ErrInviteTTL
+    }
+    if opts.InviteTTL <= 0 {
+        opts.InviteTTL = DefaultInviteTTL
+    }
+    now := time.Now()
+    job, err := s.jobRepo.Create(models.ImportJob{
+        Status:    models.ImportJobQueued,
+        Format:    format,
+        DryRun:    opts.DryRun,
+        CreatedBy: authctx.UserID(ctx),
+        TenantID:  authctx.TenantID(ctx),
+        CreatedAt: now,
+        ExpiresAt: now.Add(opts.InviteTTL),
+    })
+    if err != nil {
+        return nil, err
+    }
+    queued := *job
+
+    go func(ctx context.Context, job models.ImportJob) {
+        job.Status = models.ImportJobRunning
+        if err := s.jobRepo.Update(job); err != nil {
+            log.Printf("import job %s: failed to update status: %v", job.ID.Hex(), err)
+        }
+        report, err := s.Import(ctx, format, bytes.NewReader(data), opts)
+        finished := time.Now()
+        job.FinishedAt = &finished
+        if err != nil {
+            job.Status, job.Error = models.ImportJobFailed, err.Error()
+        } else {
+            job.Status, job.Report = models.ImportJobCompleted, report
+        }
+        if err := s.jobRepo.Update(job); err != nil {
+            log.Printf("import job %s: failed to store result: %v", job.ID.Hex(), err)
+        }
+    }(context.WithoutCancel(ctx), queued)
+
+    return job, nil
+}
+
+// GetJob returns a job started in the caller's tenant.
+func (s *ImportService) GetJob(ctx context.Context, idStr string) (*models.ImportJob, error) {
+    job, err := s.jobRepo.FindByID(idStr)
+    if err != nil {
+        return nil, err
+    }
+    if job.TenantID != authctx.TenantID(ctx) {
+        return nil, errors.New("import job not found")
+    }
+    return job, nil
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"project/internal/authctx"
+	"project/internal/config"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/pkg/utils"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MaxInviteTTLHours bounds how long any invitation, sent directly or from an
+// import, stays valid.
+const MaxInviteTTLHours = 720
+
+var (
+    ErrInviteTTL          = fmt.Errorf("invitations can be valid for at most %d hours", MaxInviteTTLHours)
+    ErrInvitationNotFound = errors.New("invitation not found")
+    ErrInvitationInvalid  = errors.New("invalid or expired invitation")
+    ErrInvitationUsed     = errors.New("invitation is no longer pending")
+    ErrInvitedUserExists  = errors.New("a user with this email already exists")
+)
+
+// InvitationService invites people by email without choosing a password for
+// them. Invitees accept with a signed token and pick their name and password.
+type InvitationService struct {
+    invRepo  repositories.InvitationRepositoryInterface
+    userRepo repositories.UserRepositoryInterface
+    audit    *AuditService
+    jwtCfg   config.JWTConfig
+}
+
+// NewInvitationService creates the service; audit may be nil to skip auditing.
+func NewInvitationService(invRepo repositories.InvitationRepositoryInterface, userRepo repositories.UserRepositoryInterface, audit *AuditService, jwtCfg config.JWTConfig) *InvitationService {
+    return &InvitationService{invRepo: invRepo, userRepo: userRepo, audit: audit, jwtCfg: jwtCfg}
+}
+
+func (s *InvitationService) invitations(ctx context.Context) repositories.InvitationRepositoryInterface {
+    return s.invRepo.ForTenant(authctx.TenantID(ctx))
+}
+
+func (s *InvitationService) issueToken(ctx context.Context, inv *models.Invitation, ttl time.Duration) (string, error) {
+    return utils.GeneratePurposeToken(inv.ID.Hex(), authctx.TenantID(ctx), utils.PurposeInvitation, s.jwtCfg.Secret, ttl)
+}
+
+func invitationTTL(hours int) time.Duration {
+    if hours <= 0 {
+        return DefaultInviteTTL
+    }
+    return time.Duration(hours) * time.Hour
+}
+
+// Create invites email into the caller's tenant. The returned token is the
+// only copy; Resend issues a new one.
+func (s *InvitationService) Create(ctx context.Context, req models.InvitationRequest) (*models.InvitationResponse, error) {
+    email := strings.TrimSpace(strings.ToLower(req.Email))
+    if !isValidEmail(email) {
+        return nil, errors.New("invalid email format")
+    }
+    if existing, err := s.userRepo.ForTenant(authctx.TenantID(ctx)).FindByEmail(email); err == nil && existing != nil {
+        return nil, ErrInvitedUserExists
+    }
+    role := req.Role
+    if role == models.RoleUser {
+        role = ""
+    }
+    ttl := invitationTTL(req.ExpiresInHours)
+    now := time.Now()
+    inv, err := s.invitations(ctx).Create(models.Invitation{
+        Email:     email,
+        Name:      strings.TrimSpace(req.Name),
+        Role:      role,
+        Status:    models.InvitationPending,
+        InvitedBy: authctx.UserID(ctx),
+        CreatedAt: now,
+        ExpiresAt: now.Add(ttl),
+    })
+    if err != nil {
+        return nil, err
+    }
+    // The token signs the ID, so it is only attached once the invitation exists;
+    // until then the invitation has no token hash and cannot be accepted
+    token, err := s.issueToken(ctx, inv, ttl)
+    if err != nil {
+        return nil, errors.New("failed to issue invitation token")
+    }
+    inv, err = s.invitations(ctx).Resend(inv.ID.Hex(), hashSecret(token), inv.ExpiresAt)
+    if err != nil {
+        return nil, err
+    }
+    s.audit.Record(ctx, models.AuditInvitationSent, "invitation", inv.ID.Hex(), nil, nil, map[string]interface{}{"email": email, "role": role})
+    return &models.InvitationResponse{Invitation: inv, Token: token}, nil
+}
+
+// ListPending returns pending invitations, optionally including expired ones.
+func (s *InvitationService) ListPending(ctx context.Context, includeExpired bool) ([]models.Invitation, error) {
+    invs, err := s.invitations(ctx).FindPending()
+    if err != nil || includeExpired {
+        return invs, err
+    }
+    now := time.Now()
+    res := []models.Invitation{}
+    for i := range invs {
+        if !invs[i].Expired(now) {
+            res = append(res, invs[i])
+        }
+    }
+    return res, nil
+}
+
+// Resend issues a new token for a pending invitation, expired or not, and
+// invalidates the previous one.
+func (s *InvitationService) Resend(ctx context.Context, idStr string, expiresInHours int) (*models.InvitationResponse, error) {
+    inv, err := s.invitations(ctx).FindByID(idStr)
+    if err != nil {
+        return nil, ErrInvitationNotFound
+    }
+    if inv.Status != models.InvitationPending {
+        return nil, ErrInvitationUsed
+    }
+    ttl := invitationTTL(expiresInHours)
+    token, err := s.issueToken(ctx, inv, ttl)
+    if err != nil {
+        return nil, errors.New("failed to issue invitation token")
+    }
+    inv, err = s.invitations(ctx).Resend(idStr, hashSecret(token), time.Now().Add(ttl))
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, ErrInvitationUsed
+    }
+    if err != nil {
+        return nil, err
+    }
+    s.audit.Record(ctx, models.AuditInvitationSent, "invitation", idStr, nil, nil, map[string]interface{}{"email": inv.Email, "send_count": inv.SendCount})
+    return &models.InvitationResponse{Invitation: inv, Token: token}, nil
+}
+
+// Revoke cancels a pending invitation so its token can no longer be accepted.
+func (s *InvitationService) Revoke(ctx context.Context, idStr string) error {
+    inv, err := s.invitations(ctx).FindByID(idStr)
+    if err != nil {
+        return ErrInvitationNotFound
+    }
+    if err := s.invitations(ctx).SetStatus(idStr, models.InvitationPending, models.InvitationRevoked, ""); err != nil {
+        if errors.Is(err, mongo.ErrNoDocuments) {
+            return ErrInvitationUsed
+        }
+        return err
+    }
+    s.audit.Record(ctx, models.AuditInvitationRevoked, "invitation", idStr, nil, nil, map[string]interface{}{"email": inv.Email})
+    return nil
+}
+
+// Accept creates the invitee's account with name and password and signs them
+// in. The token names the tenant, so the request needs no tenant of its own.
+func (s *InvitationService) Accept(ctx context.Context, req models.AcceptInvitationRequest) (string, *models.UserResponse, error) {
+    invID, tenantID, err := utils.ValidatePurposeToken(req.Token, utils.PurposeInvitation, s.jwtCfg.Secret)
+    if err != nil {
+        return "", nil, ErrInvitationInvalid
+    }
+    ctx = authctx.WithTenant(ctx, tenantID)
+    invitations := s.invitations(ctx)
+    inv, err := invitations.FindByID(invID)
+    // Only the latest token of a pending, unexpired invitation is accepted
+    if err != nil || inv.Status != models.InvitationPending || inv.Expired(time.Now()) ||
+        subtle.ConstantTimeCompare([]byte(inv.TokenHash), []byte(hashSecret(req.Token))) != 1 {
+        return "", nil, ErrInvitationInvalid
+    }
+    name := strings.TrimSpace(req.Name)
+    if name == "" {
+        name = inv.Name
+    }
+    if len([]rune(name)) < 2 {
+        return "", nil, errors.New("name must be at least 2 characters")
+    }
+    if len(req.Password) < 8 {
+        return "", nil, ErrPasswordTooShort
+    }
+    hashed, err := hashPassword(req.Password)
+    if err != nil {
+        return "", nil, errors.New("failed to hash password")
+    }
+    users := s.userRepo.ForTenant(tenantID)
+    if existing, err := users.FindByEmail(inv.Email); err == nil && existing != nil {
+        return "", nil, ErrInvitedUserExists
+    }
+
+    // The unique email index lets at most one concurrent accept create the user
+    created, err := users.Create(models.User{Name: name, Email: inv.Email, Password: hashed})
+    if err != nil {
+        if mongo.IsDuplicateKeyError(err) {
+            return "", nil, ErrInvitedUserExists
+        }
+        return "", nil, errors.New("failed to create user")
+    }
+    if err := invitations.SetStatus(invID, models.InvitationPending, models.InvitationAccepted, created.ID); err != nil {
+        return "", nil, errors.New("failed to update invitation")
+    }
+    if inv.Role != "" {
+        if err := users.SetRole(created.ID, inv.Role); err != nil {
+            return "", nil, errors.New("failed to set role")
+        }
+        created.Role = inv.Role
+    }
+
+    token, err := utils.GenerateJWT(created.ID, tenantID, s.jwtCfg.Secret, s.jwtCfg.Expiry)
+    if err != nil {
+        return "", nil, errors.New("failed to generate token")
+    }
+    actorCtx := authctx.WithPrincipal(ctx, &authctx.Principal{UserID: created.ID, Method: authctx.MethodJWT, TenantID: tenantID})
+    s.audit.Record(actorCtx, models.AuditUserCreated, "user", created.ID, nil, created, nil)
+    s.audit.Record(actorCtx, models.AuditInvitationAccepted, "invitation", invID, nil, nil, map[string]interface{}{"user_id": created.ID})
+    return token, created, nil
+}
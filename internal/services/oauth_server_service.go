@@ -0,0 +1,526 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"project/internal/authctx"
+	"project/internal/config"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// authorizationCodeTTL is deliberately short; clients redeem codes immediately.
+const authorizationCodeTTL = 5 * time.Minute
+
+var (
+    ErrClientNameRequired      = errors.New("client name is required")
+    ErrUnsupportedGrantType    = errors.New("unsupported grant type")
+    ErrPublicClientCredentials = errors.New("public clients cannot use client_credentials")
+    ErrRedirectURIRequired     = errors.New("at least one redirect URI is required")
+    ErrInvalidRedirectURI      = errors.New("invalid redirect URI")
+    ErrPrivilegedClient        = errors.New("only admins can register clients with client_credentials or API scopes")
+)
+
+// OAuthError is an RFC 6749 error. Code is the protocol error code returned
+// to clients; Status is the HTTP status used at the token endpoint.
+type OAuthError struct {
+    Code        string
+    Description string
+    Status      int
+}
+
+func (e *OAuthError) Error() string { return e.Code + ": " + e.Description }
+
+func oauthError(code, description string) *OAuthError {
+    status := http.StatusBadRequest
+    if code == "invalid_client" {
+        status = http.StatusUnauthorized
+    }
+    return &OAuthError{Code: code, Description: description, Status: status}
+}
+
+// AuthorizeRequest holds the query parameters of /oauth/authorize.
+type AuthorizeRequest struct {
+    ResponseType        string
+    ClientID            string
+    RedirectURI         string
+    Scope               string
+    State               string
+    CodeChallenge       string
+    CodeChallengeMethod string
+    Nonce               string
+}
+
+// TokenRequest holds the form parameters of /oauth/token.
+type TokenRequest struct {
+    GrantType    string
+    ClientID     string
+    ClientSecret string
+    Code         string
+    RedirectURI  string
+    CodeVerifier string
+    RefreshToken string
+    Scope        string
+}
+
+// OAuthServerService lets our internal apps use this service as their
+// OAuth2/OpenID Connect provider.
+type OAuthServerService struct {
+    repo     repositories.OAuthServerRepositoryInterface
+    userRepo repositories.UserRepositoryInterface
+    cfg      config.OAuthServerConfig
+    jwtCfg   config.JWTConfig
+}
+
+func NewOAuthServerService(repo repositories.OAuthServerRepositoryInterface, userRepo repositories.UserRepositoryInterface, cfg config.OAuthServerConfig, jwtCfg config.JWTConfig) *OAuthServerService {
+    return &OAuthServerService{repo: repo, userRepo: userRepo, cfg: cfg, jwtCfg: jwtCfg}
+}
+
+func containsString(list []string, s string) bool {
+    for _, v := range list {
+        if v == s {
+            return true
+        }
+    }
+    return false
+}
+
+// privilegedClient reports whether a client would be able to act beyond a
+// single consenting user.
+func privilegedClient(grantTypes, scopes []string) bool {
+    if containsString(grantTypes, models.GrantClientCredentials) {
+        return true
+    }
+    for _, sc := range scopes {
+        if containsString(models.APIKeyScopes, sc) {
+            return true
+        }
+    }
+    return false
+}
+
+// RegisterClient registers a new application owned by a user of the caller's
+// tenant. Confidential clients get a secret which is returned only once.
+func (s *OAuthServerService) RegisterClient(ctx context.Context, ownerIDStr, name string, redirectURIs, grantTypes, scopes []string, public bool) (*models.CreatedOAuthClientResponse, error) {
+    ownerID, err := primitive.ObjectIDFromHex(ownerIDStr)
+    if err != nil {
+        return nil, errors.New("invalid user ID")
+    }
+    name = strings.TrimSpace(name)
+    if name == "" {
+        return nil, ErrClientNameRequired
+    }
+    if len(grantTypes) == 0 {
+        grantTypes = []string{models.GrantAuthorizationCode, models.GrantRefreshToken}
+    }
+    for _, g := range grantTypes {
+        if g != models.GrantAuthorizationCode && g != models.GrantClientCredentials && g != models.GrantRefreshToken {
+            return nil, fmt.Errorf("%w: %s", ErrUnsupportedGrantType, g)
+        }
+        if g == models.GrantClientCredentials && public {
+            return nil, ErrPublicClientCredentials
+        }
+    }
+    if containsString(grantTypes, models.GrantAuthorizationCode) && len(redirectURIs) == 0 {
+        return nil, ErrRedirectURIRequired
+    }
+    for _, uri := range redirectURIs {
+        u, err := url.Parse(uri)
+        if err != nil || !u.IsAbs() || u.Host == "" || u.Fragment != "" {
+            return nil, fmt.Errorf("%w: %s", ErrInvalidRedirectURI, uri)
+        }
+    }
+    if len(scopes) == 0 {
+        scopes = []string{models.ScopeOpenID, models.ScopeProfile, models.ScopeEmail, models.ScopeOfflineAccess}
+    }
+    for _, sc := range scopes {
+        if !containsString(models.OAuthScopes, sc) {
+            return nil, fmt.Errorf("%w: %s", ErrUnknownScope, sc)
+        }
+    }
+    // client_credentials tokens act for the whole tenant and API scopes pass
+    // the /users and admin checks, so only admins may hand them out.
+    if privilegedClient(grantTypes, scopes) && !callerIsAdmin(ctx, s.userRepo) {
+        return nil, ErrPrivilegedClient
+    }
+
+    clientID, err := randomURLString(18)
+    if err != nil {
+        return nil, errors.New("failed to generate client credentials")
+    }
+    client := models.OAuthClient{
+        ClientID:     clientID,
+        Name:         name,
+        RedirectURIs: redirectURIs,
+        GrantTypes:   grantTypes,
+        Scopes:       scopes,
+        Public:       public,
+        OwnerID:      ownerID,
+        TenantID:     authctx.TenantID(ctx),
+    }
+    secret := ""
+    if !public {
+        if secret, err = randomURLString(32); err != nil {
+            return nil, errors.New("failed to generate client credentials")
+        }
+        client.SecretHash = hashSecret(secret)
+    }
+    created, err := s.repo.CreateClient(client)
+    if err != nil {
+        return nil, err
+    }
+    return &models.CreatedOAuthClientResponse{OAuthClientResponse: *created.ToResponse(), ClientSecret: secret}, nil
+}
+
+func (s *OAuthServerService) ListClients(ownerIDStr string) ([]models.OAuthClientResponse, error) {
+    clients, err := s.repo.FindClientsByOwner(ownerIDStr)
+    if err != nil {
+        return nil, err
+    }
+    res := make([]models.OAuthClientResponse, 0, len(clients))
+    for i := range clients {
+        res = append(res, *clients[i].ToResponse())
+    }
+    return res, nil
+}
+
+// resolveScope checks requested scopes against the client's registration,
+// defaulting to everything the client is allowed.
+func resolveScope(client *models.OAuthClient, requested string) (string, error) {
+    scopes := strings.Fields(requested)
+    if len(scopes) == 0 {
+        return strings.Join(client.Scopes, " "), nil
+    }
+    for _, sc := range scopes {
+        if !containsString(client.Scopes, sc) {
+            return "", oauthError("invalid_scope", "scope not allowed for client: "+sc)
+        }
+    }
+    return strings.Join(scopes, " "), nil
+}
+
+// ValidateAuthorize checks an authorization request and describes it for the
+// consent screen. Errors are returned to the user agent, never redirected.
+func (s *OAuthServerService) ValidateAuthorize(req AuthorizeRequest) (*models.OAuthConsentResponse, error) {
+    client, err := s.repo.FindClientByClientID(req.ClientID)
+    if err != nil || client == nil {
+        return nil, oauthError("invalid_client", "unknown client")
+    }
+    redirectURI := req.RedirectURI
+    if redirectURI == "" && len(client.RedirectURIs) == 1 {
+        redirectURI = client.RedirectURIs[0]
+    }
+    if !containsString(client.RedirectURIs, redirectURI) {
+        return nil, oauthError("invalid_request", "redirect_uri is not registered for client")
+    }
+    if req.ResponseType != "code" {
+        return nil, oauthError("unsupported_response_type", "only response_type=code is supported")
+    }
+    if !containsString(client.GrantTypes, models.GrantAuthorizationCode) {
+        return nil, oauthError("unauthorized_client", "client may not use the authorization code grant")
+    }
+    scope, err := resolveScope(client, req.Scope)
+    if err != nil {
+        return nil, err
+    }
+    if req.CodeChallenge == "" && client.Public {
+        return nil, oauthError("invalid_request", "public clients must use PKCE")
+    }
+    if req.CodeChallengeMethod != "" && req.CodeChallengeMethod != "S256" && req.CodeChallengeMethod != "plain" {
+        return nil, oauthError("invalid_request", "unsupported code_challenge_method")
+    }
+    return &models.OAuthConsentResponse{
+        ClientID:    client.ClientID,
+        ClientName:  client.Name,
+        RedirectURI: redirectURI,
+        Scopes:      strings.Fields(scope),
+        State:       req.State,
+    }, nil
+}
+
+func appendQuery(uri string, params url.Values) string {
+    sep := "?"
+    if strings.Contains(uri, "?") {
+        sep = "&"
+    }
+    return uri + sep + params.Encode()
+}
+
+// Decide records the user's consent decision and returns the URL the user
+// agent must be sent back to, carrying either a code or access_denied. The
+// code remembers the user's tenant for the tokens it is exchanged for.
+func (s *OAuthServerService) Decide(ctx context.Context, userIDStr string, req AuthorizeRequest, approved bool) (string, error) {
+    consent, err := s.ValidateAuthorize(req)
+    if err != nil {
+        return "", err
+    }
+    userID, err := primitive.ObjectIDFromHex(userIDStr)
+    if err != nil {
+        return "", errors.New("invalid user ID")
+    }
+    params := url.Values{}
+    if req.State != "" {
+        params.Set("state", req.State)
+    }
+    if !approved {
+        params.Set("error", "access_denied")
+        return appendQuery(consent.RedirectURI, params), nil
+    }
+
+    code, err := randomURLString(32)
+    if err != nil {
+        return "", errors.New("failed to generate authorization code")
+    }
+    method := req.CodeChallengeMethod
+    if req.CodeChallenge != "" && method == "" {
+        method = "plain"
+    }
+    now := time.Now()
+    err = s.repo.CreateCode(models.OAuthAuthorizationCode{
+        CodeHash:            hashSecret(code),
+        ClientID:            consent.ClientID,
+        UserID:              userID,
+        TenantID:            authctx.TenantID(ctx),
+        RedirectURI:         consent.RedirectURI,
+        Scope:               strings.Join(consent.Scopes, " "),
+        CodeChallenge:       req.CodeChallenge,
+        CodeChallengeMethod: method,
+        Nonce:               req.Nonce,
+        AuthTime:            now,
+        ExpiresAt:           now.Add(authorizationCodeTTL),
+    })
+    if err != nil {
+        return "", errors.New("failed to store authorization code")
+    }
+    params.Set("code", code)
+    return appendQuery(consent.RedirectURI, params), nil
+}
+
+func (s *OAuthServerService) authenticateClient(clientID, secret string) (*models.OAuthClient, error) {
+    client, err := s.repo.FindClientByClientID(clientID)
+    if err != nil || client == nil {
+        return nil, oauthError("invalid_client", "client authentication failed")
+    }
+    if client.Public {
+        if secret != "" {
+            return nil, oauthError("invalid_client", "public clients must not send a secret")
+        }
+        return client, nil
+    }
+    if secret == "" || subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashSecret(secret))) != 1 {
+        return nil, oauthError("invalid_client", "client authentication failed")
+    }
+    return client, nil
+}
+
+// Token implements the token endpoint for all supported grant types.
+func (s *OAuthServerService) Token(req TokenRequest) (*models.OAuthTokenResponse, error) {
+    client, err := s.authenticateClient(req.ClientID, req.ClientSecret)
+    if err != nil {
+        return nil, err
+    }
+    if !containsString(client.GrantTypes, req.GrantType) {
+        if req.GrantType != models.GrantAuthorizationCode && req.GrantType != models.GrantClientCredentials && req.GrantType != models.GrantRefreshToken {
+            return nil, oauthError("unsupported_grant_type", "unsupported grant_type")
+        }
+        return nil, oauthError("unauthorized_client", "client may not use grant_type "+req.GrantType)
+    }
+    switch req.GrantType {
+    case models.GrantAuthorizationCode:
+        return s.exchangeAuthorizationCode(client, req)
+    case models.GrantClientCredentials:
+        scope, err := resolveScope(client, req.Scope)
+        if err != nil {
+            return nil, err
+        }
+        if containsString(strings.Fields(scope), models.ScopeOpenID) && req.Scope != "" {
+            return nil, oauthError("invalid_scope", "openid requires an end user")
+        }
+        return s.issueTokens(client, "", client.TenantID, scope, "", time.Time{}, req.ClientSecret)
+    default:
+        return s.refresh(client, req)
+    }
+}
+
+func verifyPKCE(code *models.OAuthAuthorizationCode, verifier string) bool {
+    if verifier == "" {
+        return false
+    }
+    expected := verifier
+    if code.CodeChallengeMethod == "S256" {
+        expected = pkceChallenge(verifier)
+    }
+    return subtle.ConstantTimeCompare([]byte(expected), []byte(code.CodeChallenge)) == 1
+}
+
+func (s *OAuthServerService) exchangeAuthorizationCode(client *models.OAuthClient, req TokenRequest) (*models.OAuthTokenResponse, error) {
+    if req.Code == "" {
+        return nil, oauthError("invalid_request", "code is required")
+    }
+    code, err := s.repo.ConsumeCode(hashSecret(req.Code))
+    if err != nil || code == nil {
+        return nil, oauthError("invalid_grant", "authorization code is invalid or already used")
+    }
+    if code.ClientID != client.ClientID || time.Now().After(code.ExpiresAt) {
+        return nil, oauthError("invalid_grant", "authorization code is invalid or expired")
+    }
+    if req.RedirectURI != code.RedirectURI {
+        return nil, oauthError("invalid_grant", "redirect_uri does not match the authorization request")
+    }
+    if code.CodeChallenge != "" {
+        if !verifyPKCE(code, req.CodeVerifier) {
+            return nil, oauthError("invalid_grant", "code_verifier does not match code_challenge")
+        }
+    } else if client.Public {
+        return nil, oauthError("invalid_grant", "public clients must use PKCE")
+    }
+    return s.issueTokens(client, code.UserID.Hex(), code.TenantID, code.Scope, code.Nonce, code.AuthTime, req.ClientSecret)
+}
+
+func (s *OAuthServerService) refresh(client *models.OAuthClient, req TokenRequest) (*models.OAuthTokenResponse, error) {
+    if req.RefreshToken == "" {
+        return nil, oauthError("invalid_request", "refresh_token is required")
+    }
+    stored, err := s.repo.FindRefreshToken(hashSecret(req.RefreshToken))
+    if err != nil || stored == nil || stored.ClientID != client.ClientID || stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+        return nil, oauthError("invalid_grant", "refresh token is invalid or expired")
+    }
+    scope := stored.Scope
+    if req.Scope != "" {
+        for _, sc := range strings.Fields(req.Scope) {
+            if !containsString(strings.Fields(stored.Scope), sc) {
+                return nil, oauthError("invalid_scope", "scope exceeds the original grant: "+sc)
+            }
+        }
+        scope = req.Scope
+    }
+    if _, err := s.userRepo.ForTenant(stored.TenantID).FindByID(stored.UserID.Hex()); err != nil {
+        return nil, oauthError("invalid_grant", "user no longer exists")
+    }
+    // Rotate: the presented token is single use
+    if err := s.repo.RevokeRefreshToken(stored.ID.Hex()); err != nil {
+        return nil, oauthError("invalid_grant", "refresh token is invalid or expired")
+    }
+    return s.issueTokens(client, stored.UserID.Hex(), stored.TenantID, scope, "", stored.AuthTime, req.ClientSecret)
+}
+
+// issueTokens mints the access token plus, where applicable, a rotating
+// refresh token and an ID token signed with the client secret (HS256).
+func (s *OAuthServerService) issueTokens(client *models.OAuthClient, userIDStr, tenantID, scope, nonce string, authTime time.Time, clientSecret string) (*models.OAuthTokenResponse, error) {
+    ttl := time.Duration(s.cfg.AccessTokenMinutes) * time.Minute
+    accessToken, err := utils.GenerateAccessToken(userIDStr, tenantID, client.ClientID, scope, s.cfg.Issuer, s.jwtCfg.Secret, ttl)
+    if err != nil {
+        return nil, errors.New("failed to generate token")
+    }
+    resp := &models.OAuthTokenResponse{
+        AccessToken: accessToken,
+        TokenType:   "Bearer",
+        ExpiresIn:   int(ttl.Seconds()),
+        Scope:       scope,
+    }
+    if userIDStr == "" {
+        return resp, nil
+    }
+    scopes := strings.Fields(scope)
+
+    // Refresh tokens are long-lived, so the user has to have consented to
+    // offline_access for one to be issued.
+    if containsString(client.GrantTypes, models.GrantRefreshToken) && containsString(scopes, models.ScopeOfflineAccess) {
+        refreshToken, err := randomURLString(32)
+        if err != nil {
+            return nil, errors.New("failed to generate token")
+        }
+        userID, _ := primitive.ObjectIDFromHex(userIDStr)
+        err = s.repo.CreateRefreshToken(models.OAuthRefreshToken{
+            TokenHash: hashSecret(refreshToken),
+            ClientID:  client.ClientID,
+            UserID:    userID,
+            TenantID:  tenantID,
+            Scope:     scope,
+            AuthTime:  authTime,
+            ExpiresAt: time.Now().AddDate(0, 0, s.cfg.RefreshTokenDays),
+        })
+        if err != nil {
+            return nil, errors.New("failed to store refresh token")
+        }
+        resp.RefreshToken = refreshToken
+    }
+
+    // Public clients have no secret to sign with; they use /userinfo instead
+    if containsString(scopes, models.ScopeOpenID) && !client.Public {
+        claims := utils.IDTokenClaims{
+            Nonce:    nonce,
+            AuthTime: authTime.Unix(),
+            RegisteredClaims: jwt.RegisteredClaims{
+                Issuer:    s.cfg.Issuer,
+                Subject:   userIDStr,
+                Audience:  jwt.ClaimStrings{client.ClientID},
+                ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+                IssuedAt:  jwt.NewNumericDate(time.Now()),
+            },
+        }
+        if user, err := s.userRepo.ForTenant(tenantID).FindByID(userIDStr); err == nil {
+            if containsString(scopes, models.ScopeProfile) {
+                claims.Name = user.Name
+            }
+            if containsString(scopes, models.ScopeEmail) {
+                claims.Email = user.Email
+            }
+        }
+        if resp.IDToken, err = utils.GenerateIDToken(claims, clientSecret); err != nil {
+            return nil, errors.New("failed to generate token")
+        }
+    }
+    return resp, nil
+}
+
+// UserInfo returns the OpenID Connect claims the granted scopes allow.
+func (s *OAuthServerService) UserInfo(ctx context.Context, userIDStr string, scopes []string) (map[string]interface{}, error) {
+    if !containsString(scopes, models.ScopeOpenID) {
+        return nil, oauthError("insufficient_scope", "the openid scope is required")
+    }
+    user, err := s.userRepo.ForTenant(authctx.TenantID(ctx)).FindByID(userIDStr)
+    if err != nil {
+        return nil, ErrUserNotFound
+    }
+    info := map[string]interface{}{"sub": user.ID}
+    if containsString(scopes, models.ScopeProfile) {
+        info["name"] = user.Name
+    }
+    if containsString(scopes, models.ScopeEmail) {
+        info["email"] = user.Email
+    }
+    return info, nil
+}
+
+// Discovery returns the OpenID Provider metadata document. Endpoints point at
+// the version tree the document was requested from.
+func (s *OAuthServerService) Discovery(ctx context.Context) map[string]interface{} {
+    issuer := s.cfg.Issuer
+    base := issuer + authctx.APIVersionFrom(ctx).BasePath
+    return map[string]interface{}{
+        "issuer":                                issuer,
+        "authorization_endpoint":                base + "/oauth/authorize",
+        "token_endpoint":                        base + "/oauth/token",
+        "userinfo_endpoint":                     base + "/userinfo",
+        "registration_endpoint":                 base + "/oauth/clients",
+        "scopes_supported":                      models.OAuthScopes,
+        "response_types_supported":              []string{"code"},
+        "grant_types_supported":                 []string{models.GrantAuthorizationCode, models.GrantClientCredentials, models.GrantRefreshToken},
+        "subject_types_supported":               []string{"public"},
+        "id_token_signing_alg_values_supported": []string{"HS256"},
+        "token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+        "code_challenge_methods_supported":      []string{"S256", "plain"},
+        "claims_supported":                      []string{"sub", "name", "email", "nonce", "auth_time"},
+    }
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"project/internal/authctx"
+	"project/internal/config"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/pkg/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// oauthStateTTL bounds how long a user may take at the provider's login page.
+const oauthStateTTL = 10 * time.Minute
+
+// OAuthService implements the authorization-code + PKCE flow against external
+// identity providers and maps the resulting identity onto a local user.
+type OAuthService struct {
+    userRepo    repositories.UserRepositoryInterface
+    audit       *AuditService
+    cfg         config.OAuthConfig
+    jwtCfg      config.JWTConfig
+    httpClient  *http.Client
+    mu          sync.Mutex
+    discoveries map[string]*oidcDiscovery
+}
+
+type oidcDiscovery struct {
+    AuthorizationEndpoint string `json:"authorization_endpoint"`
+    TokenEndpoint         string `json:"token_endpoint"`
+    UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// externalProfile is the normalized identity returned by any provider kind.
+type externalProfile struct {
+    Subject       string
+    Email         string
+    EmailVerified bool
+    Name          string
+}
+
+func NewOAuthService(userRepo repositories.UserRepositoryInterface, audit *AuditService, cfg config.OAuthConfig, jwtCfg config.JWTConfig) *OAuthService {
+    return &OAuthService{
+        userRepo:    userRepo,
+        audit:       audit,
+        cfg:         cfg,
+        jwtCfg:      jwtCfg,
+        httpClient:  &http.Client{Timeout: 10 * time.Second},
+        discoveries: map[string]*oidcDiscovery{},
+    }
+}
+
+func randomURLString(n int) (string, error) {
+    b := make([]byte, n)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func pkceChallenge(verifier string) string {
+    sum := sha256.Sum256([]byte(verifier))
+    return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (s *OAuthService) provider(name string) (config.OAuthProviderConfig, error) {
+    p, ok := s.cfg.Providers[name]
+    if !ok {
+        return p, errors.New("unknown oauth provider: " + name)
+    }
+    return p, nil
+}
+
+// redirectURL is the callback under the version tree serving the request, so
+// the provider returns the browser to the same routes that set the state cookie.
+func (s *OAuthService) redirectURL(ctx context.Context, name string) string {
+    return s.cfg.RedirectBaseURL + authctx.APIVersionFrom(ctx).BasePath + "/auth/oauth/" + url.PathEscape(name) + "/callback"
+}
+
+// endpoints returns the authorization, token and userinfo URLs, discovering
+// them from the issuer for OIDC providers without explicit endpoints.
+func (s *OAuthService) endpoints(name string, p config.OAuthProviderConfig) (string, string, string, error) {
+    if p.AuthURL != "" && p.TokenURL != "" && p.UserInfoURL != "" {
+        return p.AuthURL, p.TokenURL, p.UserInfoURL, nil
+    }
+    if p.Kind != "oidc" || p.Issuer == "" {
+        return "", "", "", errors.New("oauth provider " + name + " is misconfigured")
+    }
+    s.mu.Lock()
+    d := s.discoveries[name]
+    s.mu.Unlock()
+    if d == nil {
+        resp, err := s.httpClient.Get(strings.TrimRight(p.Issuer, "/") + "/.well-known/openid-configuration")
+        if err != nil {
+            return "", "", "", errors.New("failed to fetch provider discovery document")
+        }
+        defer resp.Body.Close()
+        if resp.StatusCode != http.StatusOK {
+            return "", "", "", fmt.Errorf("failed to fetch provider discovery document: status %d", resp.StatusCode)
+        }
+        d = &oidcDiscovery{}
+        if err := json.NewDecoder(resp.Body).Decode(d); err != nil {
+            return "", "", "", errors.New("failed to decode provider discovery document")
+        }
+        s.mu.Lock()
+        s.discoveries[name] = d
+        s.mu.Unlock()
+    }
+    authURL, tokenURL, userInfoURL := d.AuthorizationEndpoint, d.TokenEndpoint, d.UserinfoEndpoint
+    if p.AuthURL != "" { authURL = p.AuthURL }
+    if p.TokenURL != "" { tokenURL = p.TokenURL }
+    if p.UserInfoURL != "" { userInfoURL = p.UserInfoURL }
+    return authURL, tokenURL, userInfoURL, nil
+}
+
+// StartAuth returns the provider URL to redirect the browser to, and a signed
+// state token that the caller must hand back on the callback (as a cookie).
+// The state remembers the tenant the sign-in started in.
+func (s *OAuthService) StartAuth(ctx context.Context, providerName string) (string, string, error) {
+    p, err := s.provider(providerName)
+    if err != nil {
+        return "", "", err
+    }
+    authURL, _, _, err := s.endpoints(providerName, p)
+    if err != nil {
+        return "", "", err
+    }
+    state, err := randomURLString(24)
+    if err != nil {
+        return "", "", errors.New("failed to generate oauth state")
+    }
+    verifier, err := randomURLString(48)
+    if err != nil {
+        return "", "", errors.New("failed to generate oauth state")
+    }
+    stateToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+        "purpose":  utils.PurposeOAuthState, // so Auth never takes it for a session
+        "provider": providerName,
+        "state":    state,
+        "verifier": verifier,
+        "tenant":   authctx.TenantID(ctx),
+        "exp":      time.Now().Add(oauthStateTTL).Unix(),
+    }).SignedString([]byte(s.jwtCfg.Secret))
+    if err != nil {
+        return "", "", errors.New("failed to generate oauth state")
+    }
+
+    q := url.Values{}
+    q.Set("response_type", "code")
+    q.Set("client_id", p.ClientID)
+    q.Set("redirect_uri", s.redirectURL(ctx, providerName))
+    q.Set("scope", strings.Join(p.Scopes, " "))
+    q.Set("state", state)
+    q.Set("code_challenge", pkceChallenge(verifier))
+    q.Set("code_challenge_method", "S256")
+    sep := "?"
+    if strings.Contains(authURL, "?") {
+        sep = "&"
+    }
+    return authURL + sep + q.Encode(), stateToken, nil
+}
+
+// HandleCallback completes the flow and returns our own JWT for the linked user.
+func (s *OAuthService) HandleCallback(ctx context.Context, providerName, code, state, stateToken string) (string, *models.UserResponse, error) {
+    p, err := s.provider(providerName)
+    if err != nil {
+        return "", nil, err
+    }
+    claims := jwt.MapClaims{}
+    _, err = jwt.ParseWithClaims(stateToken, claims, func(t *jwt.Token) (interface{}, error) {
+        if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+            return nil, errors.New("unexpected signing method")
+        }
+        return []byte(s.jwtCfg.Secret), nil
+    })
+    if err != nil || claims["purpose"] != utils.PurposeOAuthState || claims["provider"] != providerName || claims["state"] != state || state == "" {
+        return "", nil, errors.New("invalid oauth state")
+    }
+    verifier, _ := claims["verifier"].(string)
+    tenantID, _ := claims["tenant"].(string)
+    if code == "" {
+        return "", nil, errors.New("authorization code is required")
+    }
+
+    _, tokenURL, userInfoURL, err := s.endpoints(providerName, p)
+    if err != nil {
+        return "", nil, err
+    }
+    accessToken, err := s.exchangeCode(p, tokenURL, code, verifier, s.redirectURL(ctx, providerName))
+    if err != nil {
+        return "", nil, err
+    }
+    var profile *externalProfile
+    if p.Kind == "github" {
+        profile, err = s.fetchGitHubProfile(userInfoURL, accessToken)
+    } else {
+        profile, err = s.fetchOIDCProfile(userInfoURL, accessToken)
+    }
+    if err != nil {
+        return "", nil, err
+    }
+
+    user, err := s.linkUser(s.userRepo.ForTenant(tenantID), providerName, profile)
+    if err != nil {
+        return "", nil, err
+    }
+    token, err := utils.GenerateJWT(user.ID.Hex(), tenantID, s.jwtCfg.Secret, s.jwtCfg.Expiry)
+    if err != nil {
+        return "", nil, errors.New("failed to generate token")
+    }
+    ctx = authctx.WithTenant(ctx, tenantID)
+    actorCtx := authctx.WithPrincipal(ctx, &authctx.Principal{UserID: user.ID.Hex(), Method: authctx.MethodJWT, TenantID: tenantID})
+    s.audit.Record(actorCtx, models.AuditLoginSucceeded, "user", user.ID.Hex(), nil, nil, map[string]interface{}{"provider": providerName})
+    return token, user.ToResponse(), nil
+}
+
+func (s *OAuthService) exchangeCode(p config.OAuthProviderConfig, tokenURL, code, verifier, redirectURI string) (string, error) {
+    form := url.Values{}
+    form.Set("grant_type", "authorization_code")
+    form.Set("code", code)
+    form.Set("redirect_uri", redirectURI)
+    form.Set("client_id", p.ClientID)
+    form.Set("client_secret", p.ClientSecret)
+    form.Set("code_verifier", verifier)
+    req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+    if err != nil {
+        return "", errors.New("failed to exchange authorization code")
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.Header.Set("Accept", "application/json")
+    resp, err := s.httpClient.Do(req)
+    if err != nil {
+        return "", errors.New("failed to exchange authorization code")
+    }
+    defer resp.Body.Close()
+    var body struct {
+        AccessToken string `json:"access_token"`
+        Error       string `json:"error"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+        if body.Error != "" {
+            return "", errors.New("authorization code rejected by provider: " + body.Error)
+        }
+        return "", errors.New("authorization code rejected by provider")
+    }
+    return body.AccessToken, nil
+}
+
+func (s *OAuthService) getJSON(endpoint, accessToken string, out interface{}) error {
+    req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Authorization", "Bearer "+accessToken)
+    req.Header.Set("Accept", "application/json")
+    resp, err := s.httpClient.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("status %d", resp.StatusCode)
+    }
+    return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (s *OAuthService) fetchOIDCProfile(userInfoURL, accessToken string) (*externalProfile, error) {
+    var info struct {
+        Sub           string      `json:"sub"`
+        Email         string      `json:"email"`
+        EmailVerified interface{} `json:"email_verified"`
+        Name          string      `json:"name"`
+    }
+    if err := s.getJSON(userInfoURL, accessToken, &info); err != nil || info.Sub == "" {
+        return nil, errors.New("failed to fetch user info from provider")
+    }
+    // Some providers encode email_verified as a string
+    verified := info.EmailVerified == true || info.EmailVerified == "true"
+    return &externalProfile{Subject: info.Sub, Email: info.Email, EmailVerified: verified, Name: info.Name}, nil
+}
+
+func (s *OAuthService) fetchGitHubProfile(userInfoURL, accessToken string) (*externalProfile, error) {
+    var info struct {
+        ID    int64  `json:"id"`
+        Login string `json:"login"`
+        Name  string `json:"name"`
+    }
+    if err := s.getJSON(userInfoURL, accessToken, &info); err != nil || info.ID == 0 {
+        return nil, errors.New("failed to fetch user info from provider")
+    }
+    var emails []struct {
+        Email    string `json:"email"`
+        Primary  bool   `json:"primary"`
+        Verified bool   `json:"verified"`
+    }
+    if err := s.getJSON(strings.TrimRight(userInfoURL, "/")+"/emails", accessToken, &emails); err != nil {
+        return nil, errors.New("failed to fetch user emails from provider")
+    }
+    profile := &externalProfile{Subject: fmt.Sprint(info.ID), Name: info.Name}
+    if profile.Name == "" {
+        profile.Name = info.Login
+    }
+    for _, e := range emails {
+        if e.Primary {
+            profile.Email, profile.EmailVerified = e.Email, e.Verified
+        }
+    }
+    return profile, nil
+}
+
+// linkUser finds the local user for an external identity among users, linking
+// by verified email on first sign-in and creating a password-less user if none exists.
+func (s *OAuthService) linkUser(users repositories.UserRepositoryInterface, providerName string, profile *externalProfile) (*models.User, error) {
+    if user, err := users.FindByIdentity(providerName, profile.Subject); err == nil && user != nil {
+        return user, nil
+    }
+    email := strings.TrimSpace(strings.ToLower(profile.Email))
+    if email == "" || !profile.EmailVerified {
+        return nil, errors.New("provider did not return a verified email")
+    }
+    identity := models.ExternalIdentity{Provider: providerName, Subject: profile.Subject, Email: email, LinkedAt: time.Now()}
+
+    if user, err := users.FindByEmail(email); err == nil && user != nil {
+        if err := users.LinkIdentity(user.ID.Hex(), identity); err != nil {
+            return nil, errors.New("failed to link external identity")
+        }
+        return user, nil
+    }
+
+    name := strings.TrimSpace(profile.Name)
+    if len([]rune(name)) < 2 {
+        name = strings.Split(email, "@")[0]
+    }
+    // No password is set: verifyPassword never matches an empty hash, so the
+    // account can only sign in through its linked providers.
+    if _, err := users.Create(models.User{Name: name, Email: email, Identities: []models.ExternalIdentity{identity}}); err != nil {
+        return nil, errors.New("failed to create user")
+    }
+    return users.FindByIdentity(providerName, profile.Subject)
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/repositories"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+    ErrOrganizationNotFound  = errors.New("organization not found")
+    ErrOrganizationNotEmpty  = errors.New("organization still has members")
+    ErrOrganizationForbidden = errors.New("organization is managed by its own admins or platform admins only")
+    ErrMemberNotFound        = errors.New("member not found")
+    ErrMemberEmailTaken      = errors.New("a user with this email already exists in the target organization")
+)
+
+// slugPattern keeps slugs usable as a DNS label.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// OrganizationService manages tenants and moves users between them. Admins
+// of the default tenant are platform admins and may manage every
+// organization; organization admins only their own.
+type OrganizationService struct {
+    orgRepo  repositories.OrganizationRepositoryInterface
+    userRepo repositories.UserRepositoryInterface
+    audit    *AuditService
+}
+
+// NewOrganizationService creates the service; audit may be nil to skip auditing.
+func NewOrganizationService(orgRepo repositories.OrganizationRepositoryInterface, userRepo repositories.UserRepositoryInterface, audit *AuditService) *OrganizationService {
+    return &OrganizationService{orgRepo: orgRepo, userRepo: userRepo, audit: audit}
+}
+
+func isPlatformRequest(ctx context.Context) bool {
+    return authctx.TenantID(ctx) == ""
+}
+
+// canManage reports whether the caller may manage orgIDStr: platform admins
+// may manage any organization, organization admins only their own.
+func canManage(ctx context.Context, orgIDStr string) bool {
+    return isPlatformRequest(ctx) || authctx.TenantID(ctx) == orgIDStr
+}
+
+func normalizeOrganization(req models.OrganizationRequest) (string, string, error) {
+    name := strings.TrimSpace(req.Name)
+    slug := strings.ToLower(strings.TrimSpace(req.Slug))
+    if len([]rune(name)) < 2 {
+        return "", "", errors.New("organization name must be at least 2 characters")
+    }
+    if len(slug) > 63 || !slugPattern.MatchString(slug) {
+        return "", "", errors.New("slug may only contain lowercase letters, digits and inner hyphens")
+    }
+    if primitive.IsValidObjectID(slug) {
+        return "", "", errors.New("slug must not look like an organization ID")
+    }
+    return name, slug, nil
+}
+
+// ResolveTenant finds the organization a request names by slug or ID.
+func (s *OrganizationService) ResolveTenant(ref string) (*models.Organization, error) {
+    ref = strings.ToLower(strings.TrimSpace(ref))
+    var org *models.Organization
+    var err error
+    if primitive.IsValidObjectID(ref) {
+        org, err = s.orgRepo.FindByID(ref)
+    } else {
+        org, err = s.orgRepo.FindBySlug(ref)
+    }
+    if err != nil {
+        return nil, ErrOrganizationNotFound
+    }
+    return org, nil
+}
+
+func (s *OrganizationService) Create(ctx context.Context, req models.OrganizationRequest) (*models.Organization, error) {
+    if !isPlatformRequest(ctx) {
+        return nil, ErrOrganizationForbidden
+    }
+    name, slug, err := normalizeOrganization(req)
+    if err != nil {
+        return nil, err
+    }
+    org, err := s.orgRepo.Create(models.Organization{Name: name, Slug: slug})
+    if err != nil {
+        return nil, err
+    }
+    s.audit.Record(ctx, models.AuditOrgCreated, "organization", org.ID.Hex(), nil, org, nil)
+    return org, nil
+}
+
+// List returns every organization to platform admins and their own to
+// organization admins.
+func (s *OrganizationService) List(ctx context.Context) ([]models.Organization, error) {
+    if !isPlatformRequest(ctx) {
+        org, err := s.Get(ctx, authctx.TenantID(ctx))
+        if err != nil {
+            return nil, err
+        }
+        return []models.Organization{*org}, nil
+    }
+    return s.orgRepo.FindAll()
+}
+
+func (s *OrganizationService) Get(ctx context.Context, idStr string) (*models.Organization, error) {
+    if !canManage(ctx, idStr) {
+        return nil, ErrOrganizationForbidden
+    }
+    org, err := s.orgRepo.FindByID(idStr)
+    if err != nil {
+        return nil, ErrOrganizationNotFound
+    }
+    return org, nil
+}
+
+func (s *OrganizationService) Update(ctx context.Context, idStr string, req models.OrganizationRequest) (*models.Organization, error) {
+    if !isPlatformRequest(ctx) {
+        return nil, ErrOrganizationForbidden
+    }
+    name, slug, err := normalizeOrganization(req)
+    if err != nil {
+        return nil, err
+    }
+    before, err := s.orgRepo.FindByID(idStr)
+    if err != nil {
+        return nil, ErrOrganizationNotFound
+    }
+    after, err := s.orgRepo.Update(idStr, name, slug)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, ErrOrganizationNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    s.audit.Record(ctx, models.AuditOrgUpdated, "organization", idStr, before, after, nil)
+    return after, nil
+}
+
+// Delete removes an organization that has no members left.
+func (s *OrganizationService) Delete(ctx context.Context, idStr string) error {
+    if !isPlatformRequest(ctx) {
+        return ErrOrganizationForbidden
+    }
+    org, err := s.orgRepo.FindByID(idStr)
+    if err != nil {
+        return ErrOrganizationNotFound
+    }
+    members, err := s.userRepo.ForTenant(idStr).FindAll(models.UserFilter{Limit: 1})
+    if err != nil {
+        return err
+    }
+    if len(members) > 0 {
+        return ErrOrganizationNotEmpty
+    }
+    if err := s.orgRepo.Delete(idStr); err != nil {
+        return err
+    }
+    s.audit.Record(ctx, models.AuditOrgDeleted, "organization", idStr, org, nil, nil)
+    return nil
+}
+
+func (s *OrganizationService) ListMembers(ctx context.Context, orgIDStr string, filter models.UserFilter) ([]models.UserResponse, error) {
+    if _, err := s.Get(ctx, orgIDStr); err != nil {
+        return nil, err
+    }
+    return s.userRepo.ForTenant(orgIDStr).FindAll(filter)
+}
+
+// AddMember moves a user of the default tenant into the organization with
+// role. Only platform admins can add members; the user's existing sessions
+// and API keys stay bound to the default tenant and stop working.
+func (s *OrganizationService) AddMember(ctx context.Context, orgIDStr, userIDStr, role string) (*models.UserResponse, error) {
+    if !isPlatformRequest(ctx) {
+        return nil, ErrOrganizationForbidden
+    }
+    if _, err := s.Get(ctx, orgIDStr); err != nil {
+        return nil, err
+    }
+    if role == models.RoleUser {
+        role = ""
+    }
+    platform := s.userRepo.ForTenant("")
+    user, err := platform.FindUserByID(userIDStr)
+    if err != nil {
+        return nil, ErrMemberNotFound
+    }
+    members := s.userRepo.ForTenant(orgIDStr)
+    if existing, err := members.FindByEmail(user.Email); err == nil && existing != nil {
+        return nil, ErrMemberEmailTaken
+    }
+    // Clear the role first so a platform admin never becomes an org admin by accident
+    if err := platform.SetRole(userIDStr, ""); err != nil {
+        return nil, err
+    }
+    if err := platform.SetTenant(userIDStr, orgIDStr); err != nil {
+        if mongo.IsDuplicateKeyError(err) {
+            return nil, ErrMemberEmailTaken
+        }
+        return nil, err
+    }
+    if role != "" {
+        if err := members.SetRole(userIDStr, role); err != nil {
+            return nil, err
+        }
+    }
+    after, err := members.FindUserByID(userIDStr)
+    if err != nil {
+        return nil, ErrMemberNotFound
+    }
+    s.audit.Record(ctx, models.AuditMemberAdded, "user", userIDStr, nil, nil, map[string]interface{}{"organization_id": orgIDStr, "role": after.Role})
+    return after.ToResponse(), nil
+}
+
+// SetMemberRole makes a member an organization admin or a regular member.
+func (s *OrganizationService) SetMemberRole(ctx context.Context, orgIDStr, userIDStr, role string) (*models.UserResponse, error) {
+    if _, err := s.Get(ctx, orgIDStr); err != nil {
+        return nil, err
+    }
+    if role != models.RoleUser && role != models.RoleAdmin {
+        return nil, errors.New("invalid role: " + role)
+    }
+    if role == models.RoleUser {
+        role = ""
+    }
+    members := s.userRepo.ForTenant(orgIDStr)
+    before, err := members.FindUserByID(userIDStr)
+    if err != nil {
+        return nil, ErrMemberNotFound
+    }
+    snapshot := *before
+    if err := members.SetRole(userIDStr, role); err != nil {
+        return nil, err
+    }
+    after, err := members.FindUserByID(userIDStr)
+    if err != nil {
+        return nil, ErrMemberNotFound
+    }
+    s.audit.Record(ctx, models.AuditRoleChanged, "user", userIDStr, &snapshot, after, map[string]interface{}{"organization_id": orgIDStr})
+    return after.ToResponse(), nil
+}
+
+// RemoveMember moves a member back to the default tenant as a regular user.
+func (s *OrganizationService) RemoveMember(ctx context.Context, orgIDStr, userIDStr string) error {
+    if _, err := s.Get(ctx, orgIDStr); err != nil {
+        return err
+    }
+    members := s.userRepo.ForTenant(orgIDStr)
+    user, err := members.FindUserByID(userIDStr)
+    if err != nil {
+        return ErrMemberNotFound
+    }
+    if existing, err := s.userRepo.ForTenant("").FindByEmail(user.Email); err == nil && existing != nil {
+        return ErrMemberEmailTaken
+    }
+    // Org admins must not become platform admins
+    if err := members.SetRole(userIDStr, ""); err != nil {
+        return err
+    }
+    if err := members.SetTenant(userIDStr, ""); err != nil {
+        if mongo.IsDuplicateKeyError(err) {
+            return ErrMemberEmailTaken
+        }
+        return err
+    }
+    s.audit.Record(ctx, models.AuditMemberRemoved, "user", userIDStr, nil, nil, map[string]interface{}{"organization_id": orgIDStr})
+    return nil
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"project/internal/models"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// User export formats.
+const (
+    ExportFormatCSV    = "csv"
+    ExportFormatNDJSON = "ndjson"
+    ExportFormatXLSX   = "xlsx"
+)
+
+var (
+    ErrUnsupportedExportFormat = errors.New("unsupported export format: use csv, ndjson or xlsx")
+    ErrUnknownExportColumn     = errors.New("unknown export column")
+)
+
+// UserExportColumns lists the columns an export may select, in default order.
+// Secrets such as password hashes are never exportable.
+var UserExportColumns = []string{"id", "name", "email", "role", "version", "created_at", "updated_at"}
+
+var defaultUserExportColumns = []string{"id", "name", "email", "role", "created_at"}
+
+// ExportContentTypes maps each export format to its media type and extension.
+var ExportContentTypes = map[string][2]string{
+    ExportFormatCSV:    {"text/csv; charset=utf-8", "csv"},
+    ExportFormatNDJSON: {"application/x-ndjson", "ndjson"},
+    ExportFormatXLSX:   {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx"},
+}
+
+// ResolveExportColumns validates format and the requested columns before any
+// output is written; no columns selects the defaults. A repeated column is
+// kept once, at its first position.
+func ResolveExportColumns(format string, columns []string) ([]string, error) {
+    if _, ok := ExportContentTypes[format]; !ok {
+        return nil, ErrUnsupportedExportFormat
+    }
+    if len(columns) == 0 {
+        return defaultUserExportColumns, nil
+    }
+    resolved := make([]string, 0, len(columns))
+    for _, c := range columns {
+        if !containsString(UserExportColumns, c) {
+            return nil, fmt.Errorf("%w: %s", ErrUnknownExportColumn, c)
+        }
+        if !containsString(resolved, c) {
+            resolved = append(resolved, c)
+        }
+    }
+    return resolved, nil
+}
+
+func userExportValue(u models.User, column string) interface{} {
+    switch column {
+    case "id":
+        return u.ID.Hex()
+    case "name":
+        return u.Name
+    case "email":
+        return u.Email
+    case "role":
+        if u.Role == "" {
+            return models.RoleUser
+        }
+        return u.Role
+    case "version":
+        return u.Version
+    case "created_at":
+        return u.CreatedAt.UTC().Format(time.RFC3339)
+    case "updated_at":
+        return u.UpdatedAt.UTC().Format(time.RFC3339)
+    }
+    return nil
+}
+
+// csvSafe stops spreadsheet apps from evaluating user-controlled cells as formulas.
+func csvSafe(v string) string {
+    if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+        return "'" + v
+    }
+    return v
+}
+
+// ExportUsers writes the users matching filter to w as they are read from the
+// database. CSV and NDJSON are streamed; XLSX rows are streamed into the
+// workbook, which is written out once complete.
+func (s *UserService) ExportUsers(ctx context.Context, filter models.UserFilter, format string, columns []string, w io.Writer) error {
+    columns, err := ResolveExportColumns(format, columns)
+    if err != nil {
+        return err
+    }
+    s.audit.Record(ctx, models.AuditUsersExported, "user", "", nil, nil, map[string]interface{}{
+        "format": format, "columns": columns,
+    })
+
+    switch format {
+    case ExportFormatCSV:
+        cw := csv.NewWriter(w)
+        if err := cw.Write(columns); err != nil {
+            return err
+        }
+        record := make([]string, len(columns))
+        err := s.users(ctx).Stream(ctx, filter, func(u models.User) error {
+            for i, c := range columns {
+                record[i] = csvSafe(fmt.Sprint(userExportValue(u, c)))
+            }
+            return cw.Write(record)
+        })
+        cw.Flush()
+        if err != nil {
+            return err
+        }
+        return cw.Error()
+
+    case ExportFormatNDJSON:
+        // Objects are written by hand so keys follow columns; a map would sort them
+        keys := make([][]byte, len(columns))
+        for i, c := range columns {
+            keys[i], _ = json.Marshal(c)
+        }
+        var line bytes.Buffer
+        return s.users(ctx).Stream(ctx, filter, func(u models.User) error {
+            line.Reset()
+            line.WriteByte('{')
+            for i, c := range columns {
+                if i > 0 {
+                    line.WriteByte(',')
+                }
+                v, err := json.Marshal(userExportValue(u, c))
+                if err != nil {
+                    return err
+                }
+                line.Write(keys[i])
+                line.WriteByte(':')
+                line.Write(v)
+            }
+            line.WriteString("}\n")
+            _, err := w.Write(line.Bytes())
+            return err
+        })
+
+    default:
+        f := excelize.NewFile()
+        defer f.Close()
+        sheet := f.GetSheetName(0)
+        sw, err := f.NewStreamWriter(sheet)
+        if err != nil {
+            return err
+        }
+        header := make([]interface{}, len(columns))
+        for i, c := range columns {
+            header[i] = c
+        }
+        if err := sw.SetRow("A1", header); err != nil {
+            return err
+        }
+        rowNum := 1
+        err = s.users(ctx).Stream(ctx, filter, func(u models.User) error {
+            rowNum++
+            values := make([]interface{}, len(columns))
+            for i, c := range columns {
+                values[i] = userExportValue(u, c)
+            }
+            cell, err := excelize.CoordinatesToCellName(1, rowNum)
+            if err != nil {
+                return err
+            }
+            return sw.SetRow(cell, values)
+        })
+        if err != nil {
+            return err
+        }
+        if err := sw.Flush(); err != nil {
+            return err
+        }
+        return f.Write(w)
+    }
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"project/internal/models"
+	"project/internal/repositories"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Patch document media types accepted by PATCH /users/{id}.
+const (
+    MergePatchMediaType = "application/merge-patch+json" // RFC 7396
+    JSONPatchMediaType  = "application/json-patch+json"  // RFC 6902
+)
+
+var (
+    ErrUnsupportedPatchType = errors.New("unsupported patch media type")
+    ErrInvalidPatch         = errors.New("invalid patch document")
+    ErrPatchConflict        = errors.New("patch cannot be applied to the current user")
+)
+
+// applyUserPatch applies a patch document of the given media type to doc.
+func applyUserPatch(doc []byte, mediaType string, patch []byte) ([]byte, error) {
+    switch mediaType {
+    case MergePatchMediaType:
+        if !json.Valid(patch) {
+            return nil, ErrInvalidPatch
+        }
+        out, err := jsonpatch.MergePatch(doc, patch)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+        }
+        return out, nil
+    case JSONPatchMediaType:
+        ops, err := jsonpatch.DecodePatch(patch)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+        }
+        out, err := ops.Apply(doc)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrPatchConflict, err)
+        }
+        return out, nil
+    default:
+        return nil, ErrUnsupportedPatchType
+    }
+}
+
+// PatchUser applies a merge patch or JSON Patch to the user's writable
+// representation and stores the result as a full replacement. Removing a
+// required field therefore fails validation instead of being ignored. The
+// result is written only if the user is still at the version it was read at.
+func (s *UserService) PatchUser(ctx context.Context, idStr, mediaType string, patch []byte, expectedVersion int64) (*models.UserResponse, error) {
+    if idStr == "" {
+        return nil, errors.New("user ID is required")
+    }
+    current, err := s.users(ctx).FindUserByID(idStr)
+    if err != nil {
+        return nil, ErrUserNotFound
+    }
+    if expectedVersion != repositories.AnyVersion && current.Version != expectedVersion {
+        return nil, repositories.ErrVersionConflict
+    }
+    doc, err := json.Marshal(models.UserReplacement{Name: current.Name, Email: current.Email})
+    if err != nil {
+        return nil, err
+    }
+    patched, err := applyUserPatch(doc, mediaType, patch)
+    if err != nil {
+        return nil, err
+    }
+
+    var in models.UserReplacement
+    dec := json.NewDecoder(bytes.NewReader(patched))
+    dec.DisallowUnknownFields()
+    if err := dec.Decode(&in); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+    }
+    return s.ReplaceUser(ctx, idStr, in, current.Version)
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"project/internal/models"
+	"project/pkg/utils"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+    defaultSearchLimit = 20
+    maxSearchLimit     = 50
+    maxSearchQueryLen  = 100
+)
+
+// Bonuses added to the text score so typeahead matches rank sensibly.
+const (
+    emailExactBonus  = 5.0
+    emailPrefixBonus = 2.0
+    namePrefixBonus  = 1.0
+)
+
+// SearchUsers ranks users by text relevance on name and email, adding
+// prefix matches on the email and on each name word so partial input finds
+// users while typing. Matching ignores case, diacritics and Arabic letter
+// variants.
+func (s *UserService) SearchUsers(ctx context.Context, q string, limit int) ([]models.UserSearchResult, error) {
+    q = strings.TrimSpace(q)
+    if q == "" {
+        return nil, errors.New("search query is required")
+    }
+    if utf8.RuneCountInString(q) > maxSearchQueryLen {
+        return nil, errors.New("search query is too long")
+    }
+    if limit <= 0 {
+        limit = defaultSearchLimit
+    }
+    if limit > maxSearchLimit {
+        limit = maxSearchLimit
+    }
+    terms := strings.Fields(utils.NormalizeSearchText(q))
+    if len(terms) == 0 {
+        return []models.UserSearchResult{}, nil
+    }
+
+    textHits, err := s.users(ctx).SearchText(strings.Join(terms, " "), int64(limit))
+    if err != nil {
+        return nil, err
+    }
+    prefixHits, err := s.users(ctx).SearchPrefix(q, int64(limit))
+    if err != nil {
+        return nil, err
+    }
+
+    byID := map[string]*models.UserSearchResult{}
+    users := map[string]models.User{}
+    add := func(u models.User, score float64) {
+        id := u.ID.Hex()
+        if r, ok := byID[id]; ok {
+            r.Score += score
+            return
+        }
+        users[id] = u
+        byID[id] = &models.UserSearchResult{User: u.ToResponse(), Score: score}
+    }
+    for _, hit := range textHits {
+        add(hit.User, hit.Score)
+    }
+    lowerQ := strings.ToLower(q)
+    for _, u := range prefixHits {
+        bonus := 0.0
+        switch {
+        case u.Email == lowerQ:
+            bonus = emailExactBonus
+        case strings.HasPrefix(u.Email, lowerQ):
+            bonus = emailPrefixBonus
+        }
+        if utils.HighlightTerms(u.Name, terms[:1]) != "" {
+            bonus += namePrefixBonus
+        }
+        add(u, bonus)
+    }
+
+    results := make([]models.UserSearchResult, 0, len(byID))
+    for id, r := range byID {
+        u := users[id]
+        r.Highlights = map[string]string{}
+        if h := utils.HighlightTerms(u.Name, terms); h != "" {
+            r.Highlights["name"] = h
+        }
+        if h := utils.HighlightTerms(u.Email, append([]string{lowerQ}, terms...)); h != "" {
+            r.Highlights["email"] = h
+        }
+        results = append(results, *r)
+    }
+    sort.Slice(results, func(i, j int) bool {
+        if results[i].Score != results[j].Score {
+            return results[i].Score > results[j].Score
+        }
+        return results[i].User.Name < results[j].User.Name
+    })
+    if len(results) > limit {
+        results = results[:limit]
+    }
+    return results, nil
+}
@@ -1,11 +1,13 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/mail"
+	"project/internal/authctx"
 	"project/internal/models"
 	"project/internal/repositories"
-	"strconv"
 	"strings"
 
 	"project/pkg/utils"
@@ -13,24 +15,39 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrUserNotFound is returned when the user does not exist in the caller's tenant.
+var (
+    ErrUserNotFound             = errors.New("user not found")
+    ErrPasswordTooShort         = errors.New("password must be at least 8 characters")
+    ErrCurrentPasswordIncorrect = errors.New("current password is incorrect")
+)
+
 type UserService struct {
 	userRepo repositories.UserRepositoryInterface
+	audit    *AuditService
+}
+
+// NewUserService creates the service; audit may be nil to skip auditing.
+func NewUserService(userRepo repositories.UserRepositoryInterface, audit *AuditService) *UserService {
+	return &UserService{userRepo: userRepo, audit: audit}
 }
 
-func NewUserService(userRepo repositories.UserRepositoryInterface) *UserService {
-	return &UserService{userRepo: userRepo}
+// users returns the repository scoped to the tenant of the request. Always go
+// through it rather than s.userRepo so no query can cross tenants.
+func (s *UserService) users(ctx context.Context) repositories.UserRepositoryInterface {
+	return s.userRepo.ForTenant(authctx.TenantID(ctx))
 }
 
-func (s *UserService) GetAllUsers() ([]models.UserResponse, error) {
-	return s.userRepo.FindAll()
+func (s *UserService) GetAllUsers(ctx context.Context, filter models.UserFilter) ([]models.UserResponse, error) {
+	return s.users(ctx).FindAll(filter)
 }
 
-func (s *UserService) GetUserByID(idStr string) (*models.UserResponse, error) {
+func (s *UserService) GetUserByID(ctx context.Context, idStr string) (*models.UserResponse, error) {
 	if idStr == "" {
 		return nil, errors.New("user ID is required")
 	}
 	
-	return s.userRepo.FindByID(idStr)
+	return s.users(ctx).FindByID(idStr)
 }
 
 // sanitizeUserInputs trims whitespace and normalizes fields like email.
@@ -65,7 +82,10 @@ func verifyPassword(stored string, plain string) bool {
 
 // Login moved to AuthService; intentionally removed from UserService.
 
-func (s *UserService) CreateUser(user models.User) (*models.UserResponse, error) {
+// CreateUser registers a user from the client-writable fields in req. Roles
+// are only granted through SetRole.
+func (s *UserService) CreateUser(ctx context.Context, req models.CreateUserRequest) (*models.UserResponse, error) {
+    user := models.User{Name: req.Name, Email: req.Email, Password: req.Password}
     // Normalize
     sanitizeUserInputs(&user)
 
@@ -75,7 +95,7 @@ func (s *UserService) CreateUser(user models.User) (*models.UserResponse, error)
     }
 	
 	// التحقق من عدم وجود email مكرر
-	existingUser, err := s.userRepo.FindByEmail(user.Email)
+	existingUser, err := s.users(ctx).FindByEmail(user.Email)
 	if err == nil && existingUser != nil {
 		return nil, errors.New("email already exists")
 	}
@@ -87,62 +107,161 @@ func (s *UserService) CreateUser(user models.User) (*models.UserResponse, error)
     }
     user.Password = hashed
 	
-	return s.userRepo.Create(user)
-}
-
-func (s *UserService) UpdateUser(idStr string, user models.User) (*models.UserResponse, error) {
-	if idStr == "" {
-		return nil, errors.New("user ID is required")
-	}
-	
-	// التحقق من وجود المستخدم أولاً
-	_, err := s.userRepo.FindByID(idStr)
+	created, err := s.users(ctx).Create(user)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, err
 	}
-	
-    // Normalize inputs for update as well
+	s.audit.Record(ctx, models.AuditUserCreated, "user", created.ID, nil, created, nil)
+	return created, nil
+}
+
+// ValidationErrors reports field-level failures found by the service, e.g.
+// after applying a PATCH document.
+type ValidationErrors struct {
+    Errors []utils.ValidationError
+}
+
+func (e *ValidationErrors) Error() string {
+    return "validation failed"
+}
+
+// ReplaceUser overwrites the user's writable fields with in (PUT semantics).
+// An omitted password keeps the current one, since passwords are never read back.
+// The write only succeeds at expectedVersion unless it is repositories.AnyVersion.
+func (s *UserService) ReplaceUser(ctx context.Context, idStr string, in models.UserReplacement, expectedVersion int64) (*models.UserResponse, error) {
+    if idStr == "" {
+        return nil, errors.New("user ID is required")
+    }
+    before, err := s.users(ctx).FindUserByID(idStr)
+    if err != nil {
+        return nil, ErrUserNotFound
+    }
+    if expectedVersion != repositories.AnyVersion && before.Version != expectedVersion {
+        return nil, repositories.ErrVersionConflict
+    }
+
+    user := models.User{Name: in.Name, Email: in.Email, Password: in.Password}
     sanitizeUserInputs(&user)
+    in.Name, in.Email = user.Name, user.Email
+    if errs, err := utils.ValidateStructDetailed(in); err != nil {
+        return nil, err
+    } else if len(errs) > 0 {
+        return nil, &ValidationErrors{Errors: errs}
+    }
 
-	// إذا كان هناك email جديد، التحقق من عدم التكرار
-	if user.Email != "" {
-        if !isValidEmail(user.Email) {
-            return nil, errors.New("invalid email format")
-        }
-        existingUser, err := s.userRepo.FindByEmail(user.Email)
-		if err == nil && existingUser != nil {
-			// تحقق أن Email الجديد لا ينتمي لمستخدم آخر
-			currentID, _ := strconv.ParseUint(idStr, 10, 32)
-			_ = currentID // for Mongo, repository will ensure uniqueness; keep placeholder
-			return nil, errors.New("email already exists for another user")
-		}
-	}
-	
-    // If password provided, validate and hash
-    if user.Password != "" {
-        if len(user.Password) < 8 {
-            return nil, errors.New("password must be at least 8 characters")
-        }
+    existingUser, err := s.users(ctx).FindByEmail(user.Email)
+    if err == nil && existingUser != nil && existingUser.ID.Hex() != idStr {
+        return nil, errors.New("email already exists for another user")
+    }
+
+    if user.Password == "" {
+        user.Password = before.Password
+    } else {
         hashed, err := hashPassword(user.Password)
         if err != nil {
             return nil, errors.New("failed to hash password")
         }
         user.Password = hashed
     }
+    // Snapshot before the write; repositories may hand out shared pointers
+    snapshot := *before
 
-	return s.userRepo.Update(idStr, user)
+    updated, err := s.users(ctx).Replace(idStr, user, expectedVersion)
+    if err != nil {
+        return nil, err
+    }
+    after, _ := s.users(ctx).FindUserByID(idStr)
+    s.audit.Record(ctx, models.AuditUserUpdated, "user", idStr, &snapshot, after, nil)
+    return updated, nil
 }
 
-func (s *UserService) DeleteUser(idStr string) error {
+// ChangePassword replaces the user's password after checking the current one.
+func (s *UserService) ChangePassword(ctx context.Context, idStr, currentPassword, newPassword string) error {
+    user, err := s.users(ctx).FindUserByID(idStr)
+    if err != nil {
+        return ErrUserNotFound
+    }
+    if !verifyPassword(user.Password, currentPassword) {
+        return ErrCurrentPasswordIncorrect
+    }
+    if len(newPassword) < 8 {
+        return ErrPasswordTooShort
+    }
+    hashed, err := hashPassword(newPassword)
+    if err != nil {
+        return errors.New("failed to hash password")
+    }
+    oldHash := user.Password
+    if _, err := s.users(ctx).Update(idStr, models.User{Password: hashed}); err != nil {
+        return err
+    }
+    // Both hashes are redacted; the entry only shows that the password changed
+    s.audit.Record(ctx, models.AuditPasswordChanged, "user", idStr,
+        map[string]string{"password": oldHash}, map[string]string{"password": hashed}, nil)
+    return nil
+}
+
+// SetRole grants or revokes the admin role. Only admins reach this.
+func (s *UserService) SetRole(ctx context.Context, idStr, role string) (*models.UserResponse, error) {
+    if role != models.RoleUser && role != models.RoleAdmin {
+        return nil, errors.New("invalid role: " + role)
+    }
+    before, err := s.users(ctx).FindUserByID(idStr)
+    if err != nil {
+        return nil, ErrUserNotFound
+    }
+    if role == models.RoleUser {
+        role = ""
+    }
+    if err := s.users(ctx).SetRole(idStr, role); err != nil {
+        return nil, err
+    }
+    after, err := s.users(ctx).FindUserByID(idStr)
+    if err != nil {
+        return nil, ErrUserNotFound
+    }
+    s.audit.Record(ctx, models.AuditRoleChanged, "user", idStr, before, after, nil)
+    return after.ToResponse(), nil
+}
+
+// BootstrapAdmin gives the admin role to the user with email in ctx's tenant
+// (the default tenant at startup), so a fresh deployment has an admin to
+// grant it to others. It reports whether the role changed; an existing admin
+// is left alone.
+func (s *UserService) BootstrapAdmin(ctx context.Context, email string) (bool, error) {
+    user, err := s.users(ctx).FindByEmail(email)
+    if err != nil {
+        return false, fmt.Errorf("%w: %s", ErrUserNotFound, email)
+    }
+    if user.Role == models.RoleAdmin {
+        return false, nil
+    }
+    before := *user
+    if err := s.users(ctx).SetRole(user.ID.Hex(), models.RoleAdmin); err != nil {
+        return false, err
+    }
+    user.Role = models.RoleAdmin
+    s.audit.Record(ctx, models.AuditRoleChanged, "user", user.ID.Hex(), &before, user, map[string]interface{}{"bootstrap": true})
+    return true, nil
+}
+
+func (s *UserService) DeleteUser(ctx context.Context, idStr string, expectedVersion int64) error {
 	if idStr == "" {
 		return errors.New("user ID is required")
 	}
 	
 	// التحقق من وجود المستخدم أولاً
-	_, err := s.userRepo.FindByID(idStr)
+	before, err := s.users(ctx).FindUserByID(idStr)
 	if err != nil {
-		return errors.New("user not found")
+		return ErrUserNotFound
+	}
+	if expectedVersion != repositories.AnyVersion && before.Version != expectedVersion {
+		return repositories.ErrVersionConflict
 	}
 	
-	return s.userRepo.Delete(idStr)
+	if err := s.users(ctx).Delete(idStr, expectedVersion); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditUserDeleted, "user", idStr, before, nil, nil)
+	return nil
 }
\ No newline at end of file
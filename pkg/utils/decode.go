@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MaxJSONBodyBytes bounds JSON request bodies; uploads use their own limits.
+const MaxJSONBodyBytes = 1 << 20
+
+// DecodeError explains why a request body was rejected. Status is the HTTP
+// status to answer with; Field and Offset locate the problem when known.
+type DecodeError struct {
+    Status  int
+    Message string
+    Field   string // JSON path, e.g. "members.0.role"
+    Offset  int64  // bytes into the body
+}
+
+func (e *DecodeError) Error() string { return e.Message }
+
+// DecodeJSON reads exactly one JSON value from the request body into dst.
+// The body must be sent as JSON, be at most maxBytes long, hold no fields dst
+// does not declare and carry nothing after the value. Failures are returned
+// as *DecodeError.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}, maxBytes int64) error {
+    if !IsJSONMediaType(r.Header.Get("Content-Type")) {
+        return &DecodeError{Status: http.StatusUnsupportedMediaType, Message: "Content-Type must be application/json"}
+    }
+    // Buffered so unknown fields can be located afterwards
+    body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBytes))
+    if err != nil {
+        return describeDecodeError(body, err, 0)
+    }
+    dec := json.NewDecoder(bytes.NewReader(body))
+    dec.DisallowUnknownFields()
+    if err := dec.Decode(dst); err != nil {
+        return describeDecodeError(body, err, dec.InputOffset())
+    }
+    end := dec.InputOffset()
+    var extra json.RawMessage
+    if err := dec.Decode(&extra); !errors.Is(err, io.EOF) {
+        return &DecodeError{
+            Status:  http.StatusBadRequest,
+            Message: fmt.Sprintf("Request body must contain a single JSON value, found more data at offset %d", end),
+            Offset:  end,
+        }
+    }
+    return nil
+}
+
+// IsJSONMediaType reports whether contentType is application/json or a
+// +json structured syntax type.
+func IsJSONMediaType(contentType string) bool {
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+func describeDecodeError(body []byte, err error, offset int64) *DecodeError {
+    var (
+        syntaxErr *json.SyntaxError
+        typeErr   *json.UnmarshalTypeError
+        tooLarge  *http.MaxBytesError
+    )
+    bad := func(format string, args ...interface{}) *DecodeError {
+        return &DecodeError{Status: http.StatusBadRequest, Message: fmt.Sprintf(format, args...), Offset: offset}
+    }
+    switch {
+    case errors.As(err, &tooLarge):
+        return &DecodeError{Status: http.StatusRequestEntityTooLarge, Message: fmt.Sprintf("Request body must not exceed %d bytes", tooLarge.Limit)}
+    case errors.Is(err, io.EOF):
+        return bad("Request body must not be empty")
+    case errors.Is(err, io.ErrUnexpectedEOF):
+        return bad("Malformed JSON: body ends unexpectedly at offset %d", offset)
+    case errors.As(err, &syntaxErr):
+        offset = syntaxErr.Offset
+        return bad("Malformed JSON at offset %d: %s", offset, syntaxErr.Error())
+    case errors.As(err, &typeErr):
+        offset = typeErr.Offset
+        if typeErr.Field == "" {
+            return bad("Request body must be %s, got %s", jsonKind(typeErr.Type), typeErr.Value)
+        }
+        e := bad("Field %q must be %s, got %s (offset %d)", typeErr.Field, jsonKind(typeErr.Type), typeErr.Value, offset)
+        e.Field = typeErr.Field
+        return e
+    case strings.HasPrefix(err.Error(), "json: unknown field "):
+        name := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+        field, at := locateKey(body, name)
+        e := bad("Unknown field %q at offset %d", field, at)
+        e.Field, e.Offset = field, at
+        return e
+    default:
+        // e.g. a time.Time or custom UnmarshalJSON rejecting its value
+        return bad("Invalid value at offset %d: %s", offset, strings.TrimPrefix(err.Error(), "json: "))
+    }
+}
+
+// locateKey finds the first object key called name in body and returns its
+// dotted path, with array indexes, and offset. It falls back to the bare name.
+func locateKey(body []byte, name string) (string, int64) {
+    type level struct {
+        path    string
+        array   bool
+        index   int
+        wantKey bool
+    }
+    dec := json.NewDecoder(bytes.NewReader(body))
+    var stack []level
+    // child returns the path of the value about to be read
+    child := func(key string) string {
+        if len(stack) == 0 {
+            return ""
+        }
+        top := &stack[len(stack)-1]
+        if top.array {
+            key = strconv.Itoa(top.index)
+            top.index++
+        }
+        if top.path == "" {
+            return key
+        }
+        return top.path + "." + key
+    }
+    key := ""
+    for {
+        start := dec.InputOffset()
+        tok, err := dec.Token()
+        if err != nil {
+            return name, 0
+        }
+        if n := len(stack); n > 0 && !stack[n-1].array && stack[n-1].wantKey {
+            if tok != json.Delim('}') {
+                key = tok.(string)
+                stack[n-1].wantKey = false
+                if key == name {
+                    return child(key), start + int64(bytes.IndexByte(body[start:], '"'))
+                }
+                continue
+            }
+        }
+        switch tok {
+        case json.Delim('{'), json.Delim('['):
+            stack = append(stack, level{path: child(key), array: tok == json.Delim('['), wantKey: tok == json.Delim('{')})
+        case json.Delim('}'), json.Delim(']'):
+            stack = stack[:len(stack)-1]
+        default:
+            child(key)
+        }
+        if n := len(stack); n > 0 && !stack[n-1].array {
+            stack[n-1].wantKey = true
+        }
+    }
+}
+
+// jsonKind names the JSON type a Go type decodes from.
+func jsonKind(t reflect.Type) string {
+    for t.Kind() == reflect.Ptr {
+        t = t.Elem()
+    }
+    switch t.Kind() {
+    case reflect.String:
+        return "a string"
+    case reflect.Bool:
+        return "a boolean"
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+        return "an integer"
+    case reflect.Float32, reflect.Float64:
+        return "a number"
+    case reflect.Slice, reflect.Array:
+        return "an array"
+    default:
+        return "an object"
+    }
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// SquareThumbnail crops the centre square of src and scales it to size×size.
+// Images smaller than size are cropped but not enlarged.
+func SquareThumbnail(src image.Image, size int) image.Image {
+    b := src.Bounds()
+    side := b.Dx()
+    if b.Dy() < side {
+        side = b.Dy()
+    }
+    crop := image.Rect(0, 0, side, side).Add(image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2))
+    if side < size {
+        size = side
+    }
+    dst := image.NewRGBA(image.Rect(0, 0, size, size))
+    draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+    return dst
+}
@@ -1,19 +1,38 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
 
 type Claims struct {
-    UserID string `json:"user_id"`
+    UserID   string `json:"user_id"`
+    TenantID string `json:"tenant_id,omitempty"` // organization the user belongs to
+    ClientID string `json:"client_id,omitempty"`
+    Scope    string `json:"scope,omitempty"`
+    Purpose  string `json:"purpose,omitempty"` // set on single-purpose tokens only
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(userID string, secret string, expiry int) (string, error) {
+// IDTokenClaims are the OpenID Connect claims we put in ID tokens.
+type IDTokenClaims struct {
+    Name     string `json:"name,omitempty"`
+    Email    string `json:"email,omitempty"`
+    Nonce    string `json:"nonce,omitempty"`
+    AuthTime int64  `json:"auth_time,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateJWT issues a login session token. tenantID is "" for users of the
+// default tenant.
+func GenerateJWT(userID, tenantID string, secret string, expiry int) (string, error) {
 	claims := Claims{
-		UserID: userID,
+		UserID:   userID,
+		TenantID: tenantID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(expiry))),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -24,6 +43,75 @@ func GenerateJWT(userID string, secret string, expiry int) (string, error) {
 	return token.SignedString([]byte(secret))
 }
 
+// GenerateAccessToken issues an OAuth2 access token on behalf of a client.
+// userID is empty for client_credentials tokens, which act in the tenant of
+// the client's owner.
+func GenerateAccessToken(userID, tenantID, clientID, scope, issuer, secret string, ttl time.Duration) (string, error) {
+    subject := userID
+    if subject == "" {
+        subject = clientID
+    }
+    claims := Claims{
+        UserID:   userID,
+        TenantID: tenantID,
+        ClientID: clientID,
+        Scope:    scope,
+        RegisteredClaims: jwt.RegisteredClaims{
+            Issuer:    issuer,
+            Subject:   subject,
+            ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+            IssuedAt:  jwt.NewNumericDate(time.Now()),
+        },
+    }
+    return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// GenerateIDToken signs an OpenID Connect ID token with key (the client secret).
+func GenerateIDToken(claims IDTokenClaims, key string) (string, error) {
+    return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+}
+
+// Purposes of single-purpose tokens. Invitation tokens name an Invitation;
+// OAuth state tokens carry a social login in progress.
+const (
+    PurposeInvitation = "invitation"
+    PurposeOAuthState = "oauth_state"
+)
+
+// GeneratePurposeToken signs a token that is only valid for purpose. It has
+// no user_id claim, so it cannot authenticate API requests. tenantID is the
+// tenant the subject belongs to. A random ID makes every token unique, so a
+// reissued token never equals an earlier one.
+func GeneratePurposeToken(subject, tenantID, purpose, secret string, ttl time.Duration) (string, error) {
+    jti := make([]byte, 16)
+    if _, err := rand.Read(jti); err != nil {
+        return "", err
+    }
+    claims := Claims{
+        TenantID: tenantID,
+        Purpose:  purpose,
+        RegisteredClaims: jwt.RegisteredClaims{
+            ID:        hex.EncodeToString(jti),
+            Subject:   subject,
+            ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+            IssuedAt:  jwt.NewNumericDate(time.Now()),
+        },
+    }
+    return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ValidatePurposeToken returns the subject and tenant of a token issued for purpose.
+func ValidatePurposeToken(tokenString, purpose, secret string) (string, string, error) {
+    claims, err := ValidateJWTWithSecret(tokenString, secret)
+    if err != nil {
+        return "", "", err
+    }
+    if claims.Purpose != purpose || claims.Subject == "" {
+        return "", "", errors.New("token not valid for " + purpose)
+    }
+    return claims.Subject, claims.TenantID, nil
+}
+
 func ValidateJWT(tokenString string) (*Claims, error) {
     // Backwards-compat. Prefer ValidateJWTWithSecret with configured secret
     token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
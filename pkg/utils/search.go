@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// arabicLetterFolds maps Arabic letter variants that users type
+// interchangeably to one form.
+var arabicLetterFolds = map[rune]rune{
+    'أ': 'ا', 'إ': 'ا', 'آ': 'ا', 'ٱ': 'ا',
+    'ى': 'ي', 'ئ': 'ي',
+    'ؤ': 'و',
+    'ة': 'ه',
+}
+
+// foldRune lowercases r and strips its diacritics (Latin accents, Arabic
+// harakat, tatweel). It may return no runes.
+func foldRune(r rune) []rune {
+    if r == 'ـ' {
+        return nil
+    }
+    var out []rune
+    for _, d := range norm.NFD.String(string(r)) {
+        if unicode.Is(unicode.Mn, d) {
+            continue
+        }
+        if f, ok := arabicLetterFolds[d]; ok {
+            d = f
+        }
+        out = append(out, unicode.ToLower(d))
+    }
+    return out
+}
+
+// foldWithOffsets folds s and records, for each folded rune, the index of
+// the original rune it came from.
+func foldWithOffsets(s []rune) ([]rune, []int) {
+    var folded []rune
+    var offsets []int
+    for i, r := range s {
+        for _, f := range foldRune(r) {
+            folded = append(folded, f)
+            offsets = append(offsets, i)
+        }
+    }
+    return folded, offsets
+}
+
+// NormalizeSearchText folds case, diacritics and Arabic letter variants so
+// "José", "jose", "أحمد" and "احمد" compare equal.
+func NormalizeSearchText(s string) string {
+    folded, _ := foldWithOffsets([]rune(s))
+    return strings.Join(strings.Fields(string(folded)), " ")
+}
+
+func isWordRune(r rune) bool {
+    return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// HighlightTerms HTML-escapes text and wraps every word starting with one of
+// the normalized terms in <mark>. It returns "" when nothing matched.
+func HighlightTerms(text string, terms []string) string {
+    orig := []rune(text)
+    folded, offsets := foldWithOffsets(orig)
+    marked := make([]bool, len(orig))
+    found := false
+    for _, term := range terms {
+        t := []rune(term)
+        if len(t) == 0 {
+            continue
+        }
+        for i := 0; i+len(t) <= len(folded); i++ {
+            if i > 0 && isWordRune(folded[i-1]) || string(folded[i:i+len(t)]) != term {
+                continue
+            }
+            end := offsets[i+len(t)-1]
+            // Keep trailing combining marks inside the highlight
+            for end+1 < len(orig) && len(foldRune(orig[end+1])) == 0 {
+                end++
+            }
+            for j := offsets[i]; j <= end; j++ {
+                marked[j] = true
+            }
+            found = true
+        }
+    }
+    if !found {
+        return ""
+    }
+    var b strings.Builder
+    for i, r := range orig {
+        if marked[i] && (i == 0 || !marked[i-1]) {
+            b.WriteString("<mark>")
+        }
+        b.WriteString(html.EscapeString(string(r)))
+        if marked[i] && (i == len(orig)-1 || !marked[i+1]) {
+            b.WriteString("</mark>")
+        }
+    }
+    return b.String()
+}
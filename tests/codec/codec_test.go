@@ -0,0 +1,125 @@
+package codec_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"project/internal/codec"
+	"project/internal/middleware"
+	"project/internal/models"
+	"project/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]codec.Codec{
+		"":                                    codec.JSON,
+		"*/*":                                 codec.JSON,
+		"text/html,application/xhtml+xml,*/*;q=0.8": codec.JSON,
+		"application/msgpack":                 codec.MsgPack,
+		"application/x-msgpack, application/json;q=0.5": codec.MsgPack,
+		"application/cbor;q=0.9, application/json;q=0.8": codec.CBOR,
+		"application/json;q=0, */*":           codec.MsgPack,
+		"application/*":                       codec.JSON,
+	}
+	for accept, want := range cases {
+		got, ok := codec.Negotiate(accept)
+		require.True(t, ok, accept)
+		assert.Equal(t, want.MediaType(), got.MediaType(), accept)
+	}
+	_, ok := codec.Negotiate("image/png")
+	assert.False(t, ok, "لا يوجد ترميز مقبول")
+}
+
+func TestForContentType(t *testing.T) {
+	for ct, want := range map[string]codec.Codec{
+		"application/json; charset=utf-8": codec.JSON,
+		"Application/JSON":                codec.JSON,
+		"application/merge-patch+json":    codec.JSON,
+		"application/vnd.msgpack":         codec.MsgPack,
+		"application/cbor":                codec.CBOR,
+	} {
+		got, ok := codec.ForContentType(ct)
+		require.True(t, ok, ct)
+		assert.Equal(t, want.MediaType(), got.MediaType(), ct)
+	}
+	_, ok := codec.ForContentType("text/plain")
+	assert.False(t, ok)
+}
+
+func TestBinaryCodecsUseJSONFieldNames(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	resp := models.SuccessResponse{Success: true, Message: "ok", Data: models.UserResponse{ID: "abc", Name: "Sara", CreatedAt: created}}
+
+	data, err := codec.MsgPack.Marshal(resp)
+	require.NoError(t, err)
+	var m map[string]interface{}
+	require.NoError(t, msgpack.Unmarshal(data, &m))
+	user := m["data"].(map[string]interface{})
+	assert.Equal(t, "Sara", user["name"], "يجب استخدام أسماء حقول JSON")
+	assert.True(t, created.Equal(user["created_at"].(time.Time)), "يجب ترميز الوقت كطابع زمني")
+	assert.NotContains(t, user, "role", "يجب احترام omitempty")
+
+	data, err = codec.CBOR.Marshal(resp)
+	require.NoError(t, err)
+	var c map[string]interface{}
+	require.NoError(t, cbor.Unmarshal(data, &c))
+	assert.Equal(t, "abc", c["data"].(map[interface{}]interface{})["id"])
+}
+
+func decodeRequest(body []byte, contentType string, dst interface{}) *utils.DecodeError {
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	err := codec.DecodeRequest(httptest.NewRecorder(), req, dst, utils.MaxJSONBodyBytes)
+	if err == nil {
+		return nil
+	}
+	var derr *utils.DecodeError
+	if !errors.As(err, &derr) {
+		panic(err)
+	}
+	return derr
+}
+
+func TestDecodeRequest(t *testing.T) {
+	body, _ := msgpack.Marshal(map[string]string{"name": "Sara", "email": "sara@example.com", "password": "password123"})
+	var in models.CreateUserRequest
+	require.Nil(t, decodeRequest(body, "application/msgpack", &in))
+	assert.Equal(t, "sara@example.com", in.Email)
+
+	body, _ = cbor.Marshal(map[string]string{"name": "Sara", "role": "admin"})
+	err := decodeRequest(body, "application/cbor", &models.CreateUserRequest{})
+	require.NotNil(t, err, "يجب رفض الحقول غير المعروفة في CBOR")
+	assert.Equal(t, http.StatusBadRequest, err.Status)
+
+	body, _ = msgpack.Marshal(map[string]string{"name": "Sara"})
+	err = decodeRequest(append(body, 0x01), "application/msgpack", &models.CreateUserRequest{})
+	require.NotNil(t, err, "يجب رفض البيانات الزائدة")
+
+	err = decodeRequest([]byte(`name=Sara`), "application/x-www-form-urlencoded", &in)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusUnsupportedMediaType, err.Status)
+}
+
+func TestNegotiateMiddleware_ReachesThroughWrappers(t *testing.T) {
+	var got codec.Codec
+	handler := middleware.Negotiate(middleware.JSONMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = codec.FromWriter(w)
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Accept", "application/cbor")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.NotNil(t, got)
+	assert.Equal(t, codec.CBORMediaType, got.MediaType())
+	assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+
+	assert.Equal(t, codec.JSON, codec.FromWriter(httptest.NewRecorder()), "JSON هو الافتراضي")
+}
@@ -0,0 +1,182 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"project/internal/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv blanks the variables Load reads so the host environment does not
+// leak into a test; empty values count as unset.
+func clearEnv(t *testing.T) {
+	for _, key := range []string{"APP_ENV", "CONFIG_FILE", "PORT", "JWT_SECRET", "JWT_EXPIRY", "MONGO_URI", "MONGO_DB",
+		"DB_PASSWORD", "OAUTH_ISSUER", "OAUTH_REDIRECT_BASE_URL", "IDEMPOTENCY_TTL_HOURS", "TENANT_BASE_DOMAIN",
+		"OAUTH_GOOGLE_CLIENT_ID", "OAUTH_GITHUB_CLIENT_ID", "OAUTH_OIDC_CLIENT_ID",
+		"JWT_SECRET_FILE", "MONGO_URI_FILE", "SECRETS_PROVIDER", "SECRETS_DIR", "SECRETS_REFRESH_SECONDS", "LOG_LEVEL", "RATE_LIMIT_PER_MINUTE", "RATE_LIMIT_BURST", "CORS_ALLOWED_ORIGINS",
+		"COMPRESSION_ENCODINGS", "COMPRESSION_MIN_BYTES", "BOOTSTRAP_ADMIN_EMAIL"} {
+		t.Setenv(key, "")
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Layers(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	path := writeFile(t, dir, "app.yaml", `
+server:
+  port: "9000"
+mongo:
+  db: fromfile
+jwt:
+  expiry_hours: 12
+tenancy:
+  base_domain: Example.COM
+cors:
+  allowed_origins: ["https://*.example.com"]
+  allow_credentials: true
+`)
+	writeFile(t, dir, "app.test.yaml", `
+mongo:
+  db: fromprofile
+`)
+	t.Setenv("APP_ENV", "test")
+	t.Setenv("JWT_EXPIRY", "6")
+
+	cfg, err := config.Load(&config.Flags{ConfigFile: path, Port: "9100"})
+	require.NoError(t, err)
+	assert.Equal(t, "test", cfg.Env)
+	assert.Equal(t, "9100", cfg.Server.Port, "يجب أن تتغلب الأعلام على الملف")
+	assert.Equal(t, "fromprofile", cfg.Mongo.DBName, "يجب أن يتغلب ملف البيئة على الملف الأساسي")
+	assert.Equal(t, 6, cfg.JWT.Expiry, "يجب أن تتغلب متغيرات البيئة على الملفات")
+	assert.Equal(t, "mongodb://localhost:27017", cfg.Mongo.URI, "يجب الإبقاء على القيم الافتراضية غير المحددة")
+	assert.Equal(t, "example.com", cfg.Tenancy.BaseDomain)
+	assert.Equal(t, []string{"https://*.example.com"}, cfg.CORS.AllowedOrigins)
+	assert.True(t, cfg.CORS.AllowCredentials)
+	assert.Equal(t, "http://localhost:9100", cfg.OAuthServer.Issuer, "يجب اشتقاق المُصدر من المنفذ النهائي")
+}
+
+func TestLoad_TOMLAndUnknownKeys(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	path := writeFile(t, dir, "app.toml", `
+[mongo]
+db = "tomldb"
+
+[cors]
+allowed_origins = ["https://app.example.com"]
+
+[oauth.providers.corp]
+kind = "oidc"
+client_id = "abc"
+issuer = "https://id.example.com"
+`)
+	cfg, err := config.Load(&config.Flags{ConfigFile: path})
+	require.NoError(t, err)
+	assert.Equal(t, "tomldb", cfg.Mongo.DBName)
+	assert.Equal(t, []string{"https://app.example.com"}, cfg.CORS.AllowedOrigins)
+	assert.Equal(t, 600, cfg.CORS.MaxAgeSeconds, "يجب الإبقاء على القيم الافتراضية للسياسة")
+	assert.Equal(t, "abc", cfg.OAuth.Providers["corp"].ClientID)
+
+	bad := writeFile(t, dir, "bad.yaml", "jwt:\n  secrt: typo\n")
+	_, err = config.Load(&config.Flags{ConfigFile: bad})
+	assert.Error(t, err, "يجب رفض المفاتيح غير المعروفة")
+
+	_, err = config.Load(&config.Flags{ConfigFile: filepath.Join(dir, "missing.yaml")})
+	assert.Error(t, err, "يجب أن يفشل التحميل عند غياب ملف الإعدادات المحدد")
+}
+
+func TestLoad_ProductionRefusesDefaultSecrets(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("OAUTH_ISSUER", "https://api.example.com")
+
+	_, err := config.Load(nil)
+	require.Error(t, err, "يجب رفض السر الافتراضي في الإنتاج")
+	assert.Contains(t, err.Error(), "jwt.secret")
+
+	t.Setenv("JWT_SECRET", "too-short-but-not-default")
+	_, err = config.Load(nil)
+	assert.Error(t, err, "يجب رفض الأسرار القصيرة في الإنتاج")
+
+	t.Setenv("JWT_SECRET", "0123456789abcdef0123456789abcdef")
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.IsProduction())
+
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("JWT_SECRET", "")
+	_, err = config.Load(nil)
+	assert.NoError(t, err, "يُسمح بالسر الافتراضي في التطوير")
+}
+
+func TestLoad_InvalidValues(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("JWT_EXPIRY", "soon")
+	_, err := config.Load(nil)
+	assert.Error(t, err)
+
+	t.Setenv("JWT_EXPIRY", "")
+	_, err = config.Load(&config.Flags{Port: "70000"})
+	assert.Error(t, err, "يجب رفض المنفذ خارج النطاق")
+
+	t.Setenv("COMPRESSION_ENCODINGS", "GZIP, lz4")
+	_, err = config.Load(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown encoding "lz4"`, "يجب رفض الترميزات غير المدعومة")
+
+	t.Setenv("COMPRESSION_ENCODINGS", "")
+	t.Setenv("BOOTSTRAP_ADMIN_EMAIL", "admin")
+	_, err = config.Load(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "admin.bootstrap_email")
+
+	t.Setenv("BOOTSTRAP_ADMIN_EMAIL", " Admin@Example.com ")
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "admin@example.com", cfg.Admin.BootstrapEmail)
+}
+
+func TestLoad_SecretsFromFilesAndProvider(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, dir, "jwt", "file-secret\n"))
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "file-secret", cfg.JWT.Secret, "يجب قراءة السر من ملف *_FILE")
+
+	secretsDir := t.TempDir()
+	writeFile(t, secretsDir, "MONGO_URI", "mongodb://db.internal:27017")
+	t.Setenv("SECRETS_PROVIDER", "file")
+	t.Setenv("SECRETS_DIR", secretsDir)
+	cfg, err = config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "mongodb://db.internal:27017", cfg.Mongo.URI)
+	assert.Equal(t, "file-secret", cfg.JWT.Secret, "يجب الإبقاء على الأسرار التي لا يملكها المزود")
+	assert.Equal(t, "mongodb://db.internal:27017", cfg.SecretValues()["MONGO_URI"])
+
+	t.Setenv("SECRETS_DIR", "")
+	_, err = config.Load(nil)
+	assert.Error(t, err, "يتطلب مزود الملفات مجلداً")
+}
+
+func TestValidate_CORS(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.CORS.AllowedOrigins = []string{"https://*.example.com", "http://localhost:3000", "*"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.CORS.AllowCredentials = true
+	assert.Error(t, cfg.Validate(), "يجب رفض الاعتماد مع أي مصدر")
+
+	cfg.CORS.AllowedOrigins = []string{"example.com"}
+	cfg.CORS.AllowCredentials = false
+	assert.Error(t, cfg.Validate(), "يجب أن يتضمن المصدر المخطط")
+}
@@ -0,0 +1,72 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"project/internal/config"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_ReloadSwapsReloadableSections(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	path := writeFile(t, dir, "app.yaml", "log:\n  level: info\nmongo:\n  db: first\n")
+	flags := &config.Flags{ConfigFile: path}
+	cfg, err := config.Load(flags)
+	require.NoError(t, err)
+
+	store := config.NewStore(cfg, flags)
+	var seen []string
+	store.Subscribe(func(c *config.Config) { seen = append(seen, c.Log.Level) })
+	assert.Equal(t, []string{"info"}, seen, "يجب استدعاء المشترك بالإعدادات الحالية فوراً")
+
+	writeFile(t, dir, "app.yaml", "log:\n  level: debug\nrate_limit:\n  requests_per_minute: 120\nmongo:\n  db: second\n")
+	require.NoError(t, store.Reload())
+	current := store.Current()
+	assert.Equal(t, "debug", current.Log.Level)
+	assert.Equal(t, 120, current.RateLimit.RequestsPerMinute)
+	assert.Equal(t, 120, current.RateLimit.Burst)
+	assert.Equal(t, "first", current.Mongo.DBName, "يجب ألا تتغير الأقسام غير القابلة لإعادة التحميل")
+	assert.Equal(t, []string{"info", "debug"}, seen)
+	assert.Equal(t, "info", cfg.Log.Level, "يجب ألا تُعدَّل الإعدادات السابقة في مكانها")
+
+	writeFile(t, dir, "app.yaml", "log:\n  level: loud\n")
+	assert.Error(t, store.Reload(), "يجب رفض الإعدادات غير الصالحة")
+	assert.Equal(t, "debug", store.Current().Log.Level, "يجب الإبقاء على الإعدادات السابقة")
+	assert.Len(t, seen, 2)
+}
+
+func TestStore_WatchReloadsOnFileChange(t *testing.T) {
+	clearEnv(t)
+	dir := t.TempDir()
+	path := writeFile(t, dir, "app.yaml", "log:\n  level: info\n")
+	flags := &config.Flags{ConfigFile: path}
+	cfg, err := config.Load(flags)
+	require.NoError(t, err)
+	store := config.NewStore(cfg, flags)
+	var reloads atomic.Int32
+	store.Subscribe(func(*config.Config) { reloads.Add(1) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		store.Watch(ctx)
+	}()
+	time.Sleep(100 * time.Millisecond) // let the watcher start
+
+	// Replace the file as editors do
+	tmp := writeFile(t, dir, ".app.yaml.tmp", "log:\n  level: warn\n")
+	require.NoError(t, os.Rename(tmp, path))
+	require.Eventually(t, func() bool { return store.Current().Log.Level == "warn" }, 3*time.Second, 20*time.Millisecond,
+		"يجب إعادة التحميل عند تغيّر الملف")
+	cancel()
+	<-done
+	assert.GreaterOrEqual(t, reloads.Load(), int32(2))
+}
@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"project/internal/database"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/internal/routes"
+	"project/internal/services"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUsers_APIKeyScopes(t *testing.T) {
+	setupTest(t)
+	defer teardownTest(t)
+	keys := database.GetMongoDB().Collection("api_keys")
+	defer keys.DeleteMany(context.Background(), bson.M{})
+
+	owner, err := repositories.NewUserRepositoryMongo().Create(models.User{Name: "مالك المفتاح", Email: "key-owner@example.com", Password: "hash"})
+	require.NoError(t, err)
+	key, err := services.NewAPIKeyService(repositories.NewAPIKeyRepositoryMongo()).
+		CreateKey(context.Background(), owner.ID, "قراءة فقط", []string{models.ScopeUsersRead}, nil)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	require.NoError(t, routes.RegisterAPIRoutes(router, testConfig))
+	send := func(method, body string) int {
+		req := httptest.NewRequest(method, "/api/v1/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key.Key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, ""), "يكفي نطاق users:read للقراءة")
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPost, `{"name":"مستخدم جديد","email":"new@example.com","password":"password123"}`),
+		"يجب رفض الإنشاء بمفتاح لا يملك users:write")
+}
@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"project/internal/database"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/internal/routes"
+	"project/pkg/utils"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestImportUsers_LargeGzipRunsAsJob(t *testing.T) {
+	setupTest(t)
+	defer teardownTest(t)
+	defer database.GetMongoDB().Collection("import_jobs").DeleteMany(context.Background(), bson.M{})
+
+	users := repositories.NewUserRepositoryMongo()
+	admin, err := users.Create(models.User{Name: "المشرف", Email: "admin@example.com", Password: "hash"})
+	require.NoError(t, err)
+	require.NoError(t, users.SetRole(admin.ID, models.RoleAdmin))
+	token, err := utils.GenerateJWT(admin.ID, "", testConfig.JWT.Secret, testConfig.JWT.Expiry)
+	require.NoError(t, err)
+
+	// Well over the async threshold once decompressed, but small on the wire
+	var csv bytes.Buffer
+	csv.WriteString("name,email\n")
+	for i := 0; csv.Len() <= 2<<20; i++ {
+		fmt.Fprintf(&csv, "مستخدم رقم %d,user%d@example.com\n", i, i)
+	}
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(csv.Bytes())
+	zw.Close()
+
+	router := mux.NewRouter()
+	require.NoError(t, routes.RegisterAPIRoutes(router, testConfig))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/import?dry_run=true", bytes.NewReader(gz.Bytes()))
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusAccepted, rr.Code, "يجب أن يُقرَّر التشغيل في الخلفية على الحجم بعد فك الضغط")
+	assert.NotEmpty(t, rr.Header().Get("Location"))
+}
@@ -0,0 +1,56 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/internal/routes"
+	"project/pkg/utils"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMe_ConditionalUpdate(t *testing.T) {
+	setupTest(t)
+	defer teardownTest(t)
+
+	user, err := repositories.NewUserRepositoryMongo().Create(models.User{Name: "ليلى", Email: "layla@example.com", Password: "hash"})
+	require.NoError(t, err)
+	token, err := utils.GenerateJWT(user.ID, "", testConfig.JWT.Secret, testConfig.JWT.Expiry)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	require.NoError(t, routes.RegisterAPIRoutes(router, testConfig))
+	send := func(method, ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/me", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send(http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	etag := rr.Header().Get("ETag")
+	require.NotEmpty(t, etag, "يجب أن يحمل GET /me وسم ETag")
+
+	rr = send(http.MethodPatch, `"999"`, `{"name":"ليلى حسن"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code, "يجب رفض التعديل على نسخة قديمة")
+	assert.Equal(t, etag, rr.Header().Get("ETag"))
+
+	rr = send(http.MethodPatch, etag, `{"name":"ليلى حسن"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, etag, rr.Header().Get("ETag"), "يتغير الوسم بعد التعديل")
+
+	rr = send(http.MethodPatch, etag, `{"name":"ليلى"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code, "الوسم القديم لم يعد صالحاً")
+}
@@ -0,0 +1,43 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"project/internal/database"
+	"project/internal/models"
+	"project/internal/routes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUsers_OtherTenantWithoutCredentials(t *testing.T) {
+	orgs := database.GetMongoDB().Collection("organizations")
+	now := time.Now()
+	res, err := orgs.InsertOne(context.Background(), models.Organization{Name: "Other", Slug: "other-tenant", CreatedAt: now, UpdatedAt: now})
+	require.NoError(t, err)
+	defer orgs.DeleteOne(context.Background(), bson.M{"_id": res.InsertedID})
+
+	router := mux.NewRouter()
+	require.NoError(t, routes.RegisterAPIRoutes(router, testConfig))
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, "/api/v1/users", ""},
+		{http.MethodGet, "/api/v1/users/search?q=a", ""},
+		{http.MethodPost, "/api/v1/users", `{"name":"مهاجم","email":"x@example.com","password":"password123"}`},
+		{http.MethodDelete, "/api/v1/users/000000000000000000000001", ""},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant-ID", "other-tenant")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "يجب ألا يصل طلب بلا بيانات اعتماد إلى مستخدمي منظمة أخرى: %s %s", tc.method, tc.path)
+	}
+}
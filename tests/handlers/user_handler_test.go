@@ -21,9 +21,12 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// testConfig is loaded once from the environment, like the API does at startup.
+var testConfig *config.Config
+
 func TestMain(m *testing.M) {
-	cfg := config.LoadConfig()
-	err := database.InitializeMongo(cfg.Mongo)
+	testConfig = config.LoadConfig()
+	err := database.InitializeMongo(testConfig.Mongo)
 	if err != nil {
 		panic("Failed to initialize test database: " + err.Error())
 	}
@@ -81,7 +84,7 @@ func TestCreateUser_InvalidData(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 	
-	handler := handlers.NewUserHandler()
+	handler := handlers.NewUserHandler(testConfig)
 	router := mux.NewRouter()
 	router.HandleFunc("/users", handler.CreateUser).Methods("POST")
 	
@@ -148,7 +151,7 @@ func TestCreateUser_DuplicateEmail(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 	
-	handler := handlers.NewUserHandler()
+	handler := handlers.NewUserHandler(testConfig)
 	router := mux.NewRouter()
 	router.HandleFunc("/users", handler.CreateUser).Methods("POST")
 	
@@ -183,7 +186,7 @@ func TestCreateUser_ValidData(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rr := httptest.NewRecorder()
 	
-	handler := handlers.NewUserHandler()
+	handler := handlers.NewUserHandler(testConfig)
 	router := mux.NewRouter()
 	router.HandleFunc("/users", handler.CreateUser).Methods("POST")
 	
@@ -230,7 +233,7 @@ func TestGetUserByID(t *testing.T) {
 	assert.NoError(t, err)
 	
 	rr := httptest.NewRecorder()
-	handler := handlers.NewUserHandler()
+	handler := handlers.NewUserHandler(testConfig)
 	router := mux.NewRouter()
 	router.HandleFunc("/users/{id}", handler.GetUser).Methods("GET")
 	
@@ -257,7 +260,7 @@ func TestGetUserByID_NotFound(t *testing.T) {
 	assert.NoError(t, err)
 	
 	rr := httptest.NewRecorder()
-	handler := handlers.NewUserHandler()
+	handler := handlers.NewUserHandler(testConfig)
 	router := mux.NewRouter()
 	router.HandleFunc("/users/{id}", handler.GetUser).Methods("GET")
 	
@@ -0,0 +1,37 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"project/internal/config"
+	"project/internal/middleware"
+	"project/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth_RejectsTokensWithoutSubject(t *testing.T) {
+	secret := config.Defaults().JWT.Secret
+	handler := middleware.Auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("يجب ألا يصل الطلب إلى المعالج")
+	}))
+
+	state, err := utils.GeneratePurposeToken("", "", utils.PurposeOAuthState, secret, time.Minute)
+	require.NoError(t, err)
+	anonymous, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte(secret))
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{"oauth state": state, "no user or client": anonymous} {
+		req := httptest.NewRequest(http.MethodGet, "/groups", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "يجب رفض الرمز: %s", name)
+	}
+}
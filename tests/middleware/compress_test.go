@@ -0,0 +1,177 @@
+package middleware_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"project/internal/config"
+	"project/internal/middleware"
+	"project/internal/models"
+	"project/internal/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveCompressed(t *testing.T, acceptEncoding, contentType, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := middleware.Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", "999")
+		io.WriteString(w, body)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCompress_Negotiation(t *testing.T) {
+	body := strings.Repeat(`{"name":"Sara","email":"sara@example.com"},`, 100)
+
+	rec := serveCompressed(t, "gzip, deflate", "application/json", body)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Empty(t, rec.Header().Get("Content-Length"), "يجب حذف الطول الأصلي")
+	assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	zr, err := gzip.NewReader(rec.Body)
+	require.NoError(t, err)
+	plain, _ := io.ReadAll(zr)
+	assert.Equal(t, body, string(plain))
+
+	rec = serveCompressed(t, "gzip;q=0.5, br", "application/json; charset=utf-8", body)
+	require.Equal(t, "br", rec.Header().Get("Content-Encoding"), "الجودة الأعلى تفوز")
+	plain, _ = io.ReadAll(brotli.NewReader(rec.Body))
+	assert.Equal(t, body, string(plain))
+
+	rec = serveCompressed(t, "*", "application/json", body)
+	require.Equal(t, "zstd", rec.Header().Get("Content-Encoding"), "عند التعادل يُفضَّل ترتيب الخادم")
+	zd, err := zstd.NewReader(rec.Body)
+	require.NoError(t, err)
+	plain, _ = io.ReadAll(zd)
+	assert.Equal(t, body, string(plain))
+
+	rec = serveCompressed(t, "*, zstd;q=0, br;q=0", "application/json", body)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+
+	rec = serveCompressed(t, "", "application/json", body)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"), "بدون Accept-Encoding لا ضغط")
+}
+
+func TestCompress_ThresholdAndTypes(t *testing.T) {
+	rec := serveCompressed(t, "gzip", "application/json", `{"success":true}`)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"), "الردود الصغيرة تُرسل كما هي")
+	assert.Equal(t, `{"success":true}`, rec.Body.String())
+	assert.Equal(t, "999", rec.Header().Get("Content-Length"))
+
+	large := strings.Repeat("x", 4096)
+	rec = serveCompressed(t, "gzip", "image/png", large)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"), "الأنواع خارج القائمة لا تُضغط")
+	assert.Equal(t, large, rec.Body.String())
+
+	middleware.ConfigureCompression(config.CompressionConfig{Encodings: []string{"gzip"}, MinBytes: 0, ContentTypes: []string{"image/*"}})
+	defer middleware.ConfigureCompression(config.Defaults().Compression)
+	rec = serveCompressed(t, "gzip", "image/png", large)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+
+	middleware.ConfigureCompression(config.CompressionConfig{})
+	rec = serveCompressed(t, "gzip", "application/json", large)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"), "قائمة فارغة تعطّل الضغط")
+	assert.Empty(t, rec.Header().Get("Vary"))
+}
+
+func TestDecompressRequest(t *testing.T) {
+	var got string
+	handler := middleware.DecompressRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got = string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	io.WriteString(zw, "email,name\nsara@example.com,Sara\n")
+	zw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/admin/users/import", &buf)
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "email,name\nsara@example.com,Sara\n", got, "يجب فك ضغط الجسم")
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/users/import", strings.NewReader("not gzip"))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/users/import", strings.NewReader("data"))
+	req.Header.Set("Content-Encoding", "br")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	assert.Equal(t, "gzip", rec.Header().Get("Accept-Encoding"))
+}
+
+func TestCompress_IdempotentReplay(t *testing.T) {
+	repo := &memoryIdempotencyRepo{records: map[string]*models.IdempotencyRecord{}}
+	body := `{"success":true,"data":"` + strings.Repeat("x", 4096) + `"}`
+	handler := middleware.Compress(middleware.IdempotencyWith(services.NewIdempotencyService(repo, time.Hour))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, body)
+	})))
+	send := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := authenticated(httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`)), "user-1")
+		req.Header.Set("Idempotency-Key", "k1")
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("gzip")
+	require.Equal(t, "gzip", first.Header().Get("Content-Encoding"))
+
+	plain := send("")
+	assert.Equal(t, "true", plain.Header().Get("Idempotent-Replayed"))
+	assert.Empty(t, plain.Header().Get("Content-Encoding"), "يجب ألا يُعاد ترميز الاستجابة الأولى مع عميل لا يقبله")
+	assert.Equal(t, body, plain.Body.String())
+	assert.Equal(t, []string{"Accept-Encoding"}, plain.Header().Values("Vary"), "يجب ألا يتكرر Vary")
+
+	encoded := send("br")
+	require.Equal(t, "br", encoded.Header().Get("Content-Encoding"))
+	decoded, err := io.ReadAll(brotli.NewReader(encoded.Body))
+	require.NoError(t, err)
+	assert.Equal(t, body, string(decoded), "يجب ضغط الاستجابة المعادة من جديد")
+}
+
+func TestCompress_WeakensETag(t *testing.T) {
+	body := strings.Repeat(`{"name":"Sara"},`, 100)
+	serve := func(etag, acceptEncoding, body string) *httptest.ResponseRecorder {
+		handler := middleware.Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", etag)
+			io.WriteString(w, body)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, `W/"3"`, serve(`"3"`, "gzip", body).Header().Get("ETag"), "التمثيل المضغوط يختلف بايتياً فيصبح الوسم ضعيفاً")
+	assert.Equal(t, `W/"3"`, serve(`W/"3"`, "gzip", body).Header().Get("ETag"))
+	assert.Equal(t, `"3"`, serve(`"3"`, "identity", body).Header().Get("ETag"), "بلا ضغط يبقى الوسم قوياً")
+	assert.Equal(t, `"3"`, serve(`"3"`, "gzip", "{}").Header().Get("ETag"), "الردود الصغيرة تُرسل كما هي")
+}
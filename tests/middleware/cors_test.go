@@ -0,0 +1,112 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"project/internal/config"
+	"project/internal/middleware"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// corsRouter mirrors the API: routes on a subrouter with JSON 404/405 handlers.
+func corsRouter() *mux.Router {
+	root := mux.NewRouter()
+	sub := root.PathPrefix("/").Subrouter()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	sub.HandleFunc("/users/{id}", ok).Methods("GET")
+	sub.HandleFunc("/users/{id}", ok).Methods("DELETE")
+	sub.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"success":false,"message":"route not found"}`))
+	})
+	sub.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write([]byte(`{"success":false,"message":"method not allowed"}`))
+	})
+	return root
+}
+
+func corsRequest(handler http.Handler, method, path, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORS_ActualRequests(t *testing.T) {
+	cfg := config.Defaults().CORS
+	cfg.AllowedOrigins = []string{"https://app.example.com", "https://*.example.org"}
+	middleware.ConfigureCORS(cfg)
+	defer middleware.ConfigureCORS(config.CORSConfig{})
+	handler := middleware.CORS(corsRouter())
+
+	rec := corsRequest(handler, "GET", "/users/1", "https://app.example.com", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, rec.Header().Get("Access-Control-Expose-Headers"), "ETag")
+	assert.Contains(t, rec.Header().Values("Vary"), "Origin")
+
+	rec = corsRequest(handler, "GET", "/users/1", "https://a.b.example.org", nil)
+	assert.Equal(t, "https://a.b.example.org", rec.Header().Get("Access-Control-Allow-Origin"), "يجب قبول النطاقات الفرعية")
+	rec = corsRequest(handler, "GET", "/users/1", "https://example.org", nil)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"), "لا يشمل النمط النطاق نفسه")
+	rec = corsRequest(handler, "GET", "/users/1", "https://evil.com", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"), "يجب ألا يُسمح بالمصادر الأخرى")
+
+	rec = corsRequest(handler, "GET", "/users/1", "", nil)
+	assert.Contains(t, rec.Header().Values("Vary"), "Origin", "يجب إضافة Vary: Origin حتى دون مصدر")
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	cfg := config.Defaults().CORS
+	cfg.AllowedOrigins = []string{"https://app.example.com"}
+	cfg.Policies = []config.CORSPolicy{{
+		AllowedOrigins:   []string{"https://admin.example.com"},
+		AllowedMethods:   []string{"GET"},
+		AllowCredentials: true,
+	}}
+	middleware.ConfigureCORS(cfg)
+	defer middleware.ConfigureCORS(config.CORSConfig{})
+	handler := middleware.CORS(corsRouter())
+	preflight := func(path, origin, method, headers string) *httptest.ResponseRecorder {
+		return corsRequest(handler, http.MethodOptions, path, origin, map[string]string{
+			"Access-Control-Request-Method":  method,
+			"Access-Control-Request-Headers": headers,
+		})
+	}
+
+	rec := preflight("/users/1", "https://app.example.com", "DELETE", "Authorization, Content-Type")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, DELETE", rec.Header().Get("Access-Control-Allow-Methods"), "يجب إدراج الطرق التي يخدمها المسار فقط")
+	assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	rec = preflight("/users/1", "https://app.example.com", "PUT", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code, "يجب أن يجيب الموجّه بـ 405")
+	assert.JSONEq(t, `{"success":false,"message":"method not allowed"}`, rec.Body.String())
+	rec = preflight("/nope", "https://app.example.com", "GET", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = preflight("/users/1", "https://app.example.com", "GET", "X-Unknown")
+	assert.Equal(t, http.StatusForbidden, rec.Code, "يجب رفض الترويسات غير المسموح بها")
+	rec = preflight("/users/1", "https://evil.com", "GET", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = preflight("/users/1", "https://admin.example.com", "GET", "Authorization")
+	assert.Equal(t, http.StatusNoContent, rec.Code, "يجب أن ترث السياسة الترويسات الافتراضية")
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET", rec.Header().Get("Access-Control-Allow-Methods"))
+	rec = preflight("/users/1", "https://admin.example.com", "DELETE", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code, "يجب تطبيق طرق السياسة الخاصة بالمصدر")
+}
@@ -0,0 +1,124 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"project/internal/authctx"
+	"project/internal/middleware"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/internal/services"
+	"project/pkg/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryIdempotencyRepo is an in-memory IdempotencyRepositoryInterface for tests.
+type memoryIdempotencyRepo struct {
+	records map[string]*models.IdempotencyRecord
+}
+
+func (m *memoryIdempotencyRepo) Create(record models.IdempotencyRecord) error {
+	if _, ok := m.records[record.ID]; ok {
+		return repositories.ErrIdempotencyKeyExists
+	}
+	m.records[record.ID] = &record
+	return nil
+}
+
+func (m *memoryIdempotencyRepo) Find(id string) (*models.IdempotencyRecord, error) {
+	if r, ok := m.records[id]; ok && r.ExpiresAt.After(time.Now()) {
+		copy := *r
+		return &copy, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryIdempotencyRepo) Complete(id string, status int, headers map[string][]string, body []byte) error {
+	r := m.records[id]
+	r.State, r.ResponseStatus, r.ResponseHeaders, r.ResponseBody = models.IdempotencyCompleted, status, headers, body
+	return nil
+}
+
+func (m *memoryIdempotencyRepo) Delete(id string) error {
+	delete(m.records, id)
+	return nil
+}
+
+func TestIdempotency_ReplaysFirstResponse(t *testing.T) {
+	repo := &memoryIdempotencyRepo{records: map[string]*models.IdempotencyRecord{}}
+	calls := 0
+	status := http.StatusCreated
+	handler := middleware.IdempotencyWith(services.NewIdempotencyService(repo, time.Hour))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"success":true}`))
+	}))
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := authenticated(httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body)), "user-1")
+		req.Header.Set("Idempotency-Key", key)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := send("k1", `{"email":"a@example.com"}`)
+	retry := send("k1", `{"email":"a@example.com"}`)
+	assert.Equal(t, 1, calls, "يجب عدم تنفيذ الطلب مرة ثانية")
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+
+	reused := send("k1", `{"email":"b@example.com"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code, "يجب رفض إعادة استخدام المفتاح لطلب مختلف")
+	assert.Equal(t, 1, calls)
+
+	// Server errors are not stored, so the retry runs again
+	status = http.StatusInternalServerError
+	send("k2", `{}`)
+	status = http.StatusCreated
+	assert.Equal(t, http.StatusCreated, send("k2", `{}`).Code)
+	assert.Equal(t, 3, calls)
+}
+
+// authenticated runs r as the user, as Auth would.
+func authenticated(r *http.Request, userID string) *http.Request {
+	return r.WithContext(authctx.WithPrincipal(r.Context(), &authctx.Principal{UserID: userID, Method: authctx.MethodJWT}))
+}
+
+func TestIdempotency_RequiresCallerAndBoundsBody(t *testing.T) {
+	repo := &memoryIdempotencyRepo{records: map[string]*models.IdempotencyRecord{}}
+	handler := middleware.IdempotencyWith(services.NewIdempotencyService(repo, time.Hour))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	send := func(r *http.Request) int {
+		r.Header.Set("Idempotency-Key", "k1")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+		return rr.Code
+	}
+
+	anonymous := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`))
+	assert.Equal(t, http.StatusUnauthorized, send(anonymous), "يجب رفض المفتاح من طلب غير مصادق")
+	assert.Empty(t, repo.records, "يجب ألا يُخزن شيء لطلب غير مصادق")
+
+	huge := strings.NewReader(`{"name":"` + strings.Repeat("a", utils.MaxJSONBodyBytes) + `"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send(authenticated(httptest.NewRequest(http.MethodPost, "/users", huge), "user-1")))
+	assert.Empty(t, repo.records)
+
+	assert.Equal(t, http.StatusCreated, send(authenticated(httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`)), "user-1")))
+}
+
+func TestIdempotency_RejectsConcurrentRetry(t *testing.T) {
+	repo := &memoryIdempotencyRepo{records: map[string]*models.IdempotencyRecord{}}
+	svc := services.NewIdempotencyService(repo, time.Hour)
+	_, _, err := svc.Begin("anonymous", "k1", "hash")
+	assert.NoError(t, err)
+	_, _, err = svc.Begin("anonymous", "k1", "hash")
+	assert.ErrorIs(t, err, services.ErrIdempotencyInProgress, "يجب رفض الطلب المتزامن بنفس المفتاح")
+}
@@ -0,0 +1,36 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"project/internal/config"
+	"project/internal/middleware"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit_PerClientAndReconfigurable(t *testing.T) {
+	handler := middleware.RequestInfo(middleware.RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	call := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	middleware.ConfigureRateLimit(config.RateLimitConfig{RequestsPerMinute: 60, Burst: 2})
+	defer middleware.ConfigureRateLimit(config.RateLimitConfig{})
+	assert.Equal(t, http.StatusNoContent, call("10.0.0.1").Code)
+	assert.Equal(t, http.StatusNoContent, call("10.0.0.1").Code)
+	rec := call("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "يجب رفض الطلبات بعد استنفاد الرصيد")
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusNoContent, call("10.0.0.2").Code, "لكل عميل رصيده الخاص")
+
+	middleware.ConfigureRateLimit(config.RateLimitConfig{})
+	assert.Equal(t, http.StatusNoContent, call("10.0.0.1").Code, "يجب تعطيل التحديد عند ضبطه على صفر")
+}
@@ -0,0 +1,72 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"project/internal/errorsink"
+	"project/internal/middleware"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	events []errorsink.Event
+}
+
+func (s *recordingSink) Report(ctx context.Context, event errorsink.Event) {
+	s.events = append(s.events, event)
+}
+
+func TestRecover_ReportsPanicWithRequestID(t *testing.T) {
+	sink := &recordingSink{}
+	middleware.SetErrorSink(sink)
+	defer middleware.SetErrorSink(nil)
+	handler := middleware.RequestInfo(middleware.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "req-123", body["request_id"], "يجب إرجاع معرّف الطلب للعميل")
+	require.Len(t, sink.events, 1)
+	assert.Equal(t, "boom", sink.events[0].Message)
+	assert.Equal(t, "req-123", sink.events[0].RequestID)
+	assert.Contains(t, sink.events[0].Stack, "recover_test.go", "يجب تضمين مكدس الاستدعاء")
+}
+
+func TestRecover_AbortsStartedResponse(t *testing.T) {
+	handler := middleware.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"partial":`))
+		panic("boom")
+	}))
+	rec := httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	}, "يجب قطع الاتصال بدل كتابة ترويسة ثانية")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRequestInfo_RequestID(t *testing.T) {
+	handler := middleware.RequestInfo(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, rec.Header().Get(middleware.RequestIDHeader), 32, "يجب توليد معرّف عند غيابه")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middleware.RequestIDHeader, "bad id\n")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.NotEqual(t, "bad id\n", rec.Header().Get(middleware.RequestIDHeader), "يجب رفض المعرّفات غير الصالحة")
+}
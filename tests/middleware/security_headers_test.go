@@ -0,0 +1,54 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"project/internal/config"
+	"project/internal/middleware"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders_PerRoute(t *testing.T) {
+	cfg := config.Defaults().Security
+	cfg.Routes = map[string]config.SecurityHeaders{
+		"/users/{id}/avatar": {ContentSecurityPolicy: "default-src 'none'; sandbox", FrameOptions: "off"},
+	}
+	middleware.ConfigureSecurityHeaders(cfg)
+	defer middleware.ConfigureSecurityHeaders(config.Defaults().Security)
+
+	router := mux.NewRouter()
+	router.Use(middleware.SecurityHeaders, middleware.JSONMiddleware)
+	users := router.PathPrefix("/users").Subrouter()
+	users.HandleFunc("/{id}", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{}`)) })
+	users.HandleFunc("/{id}/avatar", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	})
+	get := func(path string, https bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if https {
+			req.Header.Set("X-Forwarded-Proto", "https")
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/users/1", false)
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "no-referrer", rec.Header().Get("Referrer-Policy"))
+	assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", rec.Header().Get("Content-Security-Policy"))
+	assert.Empty(t, rec.Header().Get("Strict-Transport-Security"), "لا يُرسل HSTS عبر HTTP")
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	rec = get("/users/1/avatar", true)
+	assert.Equal(t, "default-src 'none'; sandbox", rec.Header().Get("Content-Security-Policy"), "يجب تطبيق إعدادات المسار")
+	assert.Empty(t, rec.Header().Get("X-Frame-Options"), "تعطّل القيمة off الترويسة")
+	assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"), "يجب وراثة القيم غير المحددة")
+	assert.Contains(t, rec.Header().Get("Strict-Transport-Security"), "max-age=")
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"), "يجب ألا يُفرض JSON على المحتوى الآخر")
+}
@@ -0,0 +1,146 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"project/internal/config"
+	"project/internal/models"
+	"project/pkg/utils"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type openAPIDoc struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+// fetchSpec reads the document the way clients do, from /openapi.json.
+func fetchSpec(t *testing.T, router http.Handler) openAPIDoc {
+	t.Helper()
+	rec := get(router, "/openapi.json", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var doc openAPIDoc
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc), "يجب أن يكون المستند JSON صالحاً")
+	return doc
+}
+
+var versionPrefix = regexp.MustCompile(`^/api/v[0-9]+`)
+
+// registeredOperations lists "METHOD /path" for every route with a handler,
+// with the /api/{version} prefix removed as the document's servers add it.
+func registeredOperations(t *testing.T, router *mux.Router) map[string]bool {
+	t.Helper()
+	ops := map[string]bool{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil // the Accept version matcher has no path
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		path := versionPrefix.ReplaceAllString(template, "")
+		for _, m := range methods {
+			ops[m+" "+path] = true
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return ops
+}
+
+func TestOpenAPI_CoversEveryRoute(t *testing.T) {
+	router := newRouter(t, config.Defaults())
+	doc := fetchSpec(t, router)
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+
+	documented := map[string]bool{}
+	for path, item := range doc.Paths {
+		for method := range item {
+			switch method {
+			case "get", "put", "post", "patch", "delete", "head", "options":
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+
+	registered := registeredOperations(t, router)
+	require.NotEmpty(t, registered)
+	var missing, stale []string
+	for op := range registered {
+		if !documented[op] {
+			missing = append(missing, op)
+		}
+	}
+	for op := range documented {
+		if !registered[op] {
+			stale = append(stale, op)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(stale)
+	assert.Empty(t, missing, "مسارات مسجلة غير موثقة في api/swagger.yaml")
+	assert.Empty(t, stale, "عمليات موثقة في api/swagger.yaml لا يقابلها مسار مسجل")
+}
+
+func TestOpenAPI_SchemasMatchModels(t *testing.T) {
+	doc := fetchSpec(t, newRouter(t, config.Defaults()))
+	for name, model := range map[string]interface{}{
+		"UserResponse":    models.UserResponse{},
+		"SuccessResponse": models.SuccessResponse{},
+		"ErrorResponse":   models.ErrorResponse{},
+		"LoginResponse":   models.LoginResponse{},
+		"ValidationError": utils.ValidationError{},
+	} {
+		schema, ok := doc.Components.Schemas[name]
+		if !assert.True(t, ok, "المخطط %s مفقود", name) {
+			continue
+		}
+		var want, got []string
+		typ := reflect.TypeOf(model)
+		for i := 0; i < typ.NumField(); i++ {
+			tag, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			if tag != "" && tag != "-" {
+				want = append(want, tag)
+			}
+		}
+		for prop := range schema.Properties {
+			got = append(got, prop)
+		}
+		assert.ElementsMatch(t, want, got, "خصائص المخطط %s تختلف عن النموذج", name)
+	}
+}
+
+func TestOpenAPI_DocsPage(t *testing.T) {
+	router := newRouter(t, config.Defaults())
+	rec := get(router, "/docs", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	assert.NotContains(t, body, "<script", "صفحة التوثيق لا تحمّل أي سكربت")
+	assert.Contains(t, body, `<code>/users/{id}</code>`)
+	assert.Contains(t, body, `<a href="#schema-UserReplacement">UserReplacement</a>`, "يجب ربط المخططات المرجعية")
+	assert.Contains(t, body, `<code>If-Match</code>`, "يجب حل المعاملات المرجعية")
+	assert.Equal(t, "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'", rec.Header().Get("Content-Security-Policy"))
+
+	rec = get(router, "/openapi.json", "")
+	assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", rec.Header().Get("Content-Security-Policy"))
+}
@@ -0,0 +1,114 @@
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"project/internal/config"
+	"project/internal/routes"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRouter(t *testing.T, cfg *config.Config) *mux.Router {
+	t.Helper()
+	router := mux.NewRouter()
+	require.NoError(t, routes.RegisterAPIRoutes(router, cfg))
+	return router
+}
+
+// get calls an authenticated route without credentials; Auth answers 401
+// without touching the database, after the version middleware ran.
+func get(router http.Handler, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestVersions_PathAndDeprecationHeaders(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.API.Deprecations = map[string]config.APIDeprecation{
+		"v1": {Date: "2026-01-01", Sunset: "2027-01-01", Link: "https://docs.example.com/migrate-v2"},
+	}
+	router := newRouter(t, cfg)
+
+	rec := get(router, "/api/v1/me", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "@1767225600", rec.Header().Get("Deprecation"))
+	assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+	assert.ElementsMatch(t, []string{
+		`<https://docs.example.com/migrate-v2>; rel="deprecation"; type="text/html"`,
+		`</api/v2/me>; rel="successor-version"`,
+	}, rec.Header().Values("Link"))
+
+	rec = get(router, "/api/v2/me", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Empty(t, rec.Header().Get("Deprecation"), "الإصدار الحالي ليس مهملاً")
+
+	assert.Equal(t, http.StatusOK, get(router, "/health", "").Code, "فحص الصحة بلا إصدار")
+	assert.Equal(t, http.StatusNotFound, get(router, "/api/v3/me", "").Code)
+}
+
+func TestVersions_LegacyRoutesFollowAccept(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.API.Deprecations = map[string]config.APIDeprecation{"v1": {Date: "2026-10-19"}}
+	router := newRouter(t, cfg)
+
+	rec := get(router, "/me", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "المسارات القديمة تبقى متاحة")
+	assert.NotEmpty(t, rec.Header().Get("Deprecation"), "الإصدار الافتراضي v1 مهمل")
+	assert.Equal(t, `</api/v2/me>; rel="successor-version"`, rec.Header().Get("Link"))
+
+	rec = get(router, "/me", "application/json; version=2")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Empty(t, rec.Header().Get("Deprecation"), "يجب اختيار v2 من Accept")
+
+	rec = get(router, "/api/v1/me", "application/json; version=2")
+	assert.NotEmpty(t, rec.Header().Get("Deprecation"), "المسار يتقدم على Accept")
+
+	rec = get(router, "/me", "application/msgpack; version=9")
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+
+	rec = get(newRouter(t, config.Defaults()), "/me", "")
+	assert.Empty(t, rec.Header().Get("Deprecation"), "لا يُهمل أي إصدار افتراضياً")
+
+	cfg = config.Defaults()
+	cfg.API.LegacyRoutes = false
+	assert.Equal(t, http.StatusNotFound, get(newRouter(t, cfg), "/me", "").Code, "يمكن تعطيل المسارات القديمة")
+}
+
+func TestVersions_UnknownVersionInConfig(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.API.DefaultVersion = "v7"
+	assert.Error(t, routes.RegisterAPIRoutes(mux.NewRouter(), cfg))
+}
+
+func TestUsersRequireCredentials(t *testing.T) {
+	router := newRouter(t, config.Defaults())
+	for _, path := range []string{"/api/v1/users", "/api/v2/users/search?q=a", "/users"} {
+		rec := get(router, path, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, "يجب رفض الطلب بلا بيانات اعتماد: %s", path)
+	}
+}
+
+func TestVersions_OAuthStateCookieFollowsBasePath(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.API.LegacyRoutes = false
+	cfg.OAuth.Providers = map[string]config.OAuthProviderConfig{
+		"mock": {Kind: "oidc", ClientID: "client", AuthURL: "https://idp.example.com/authorize",
+			TokenURL: "https://idp.example.com/token", UserInfoURL: "https://idp.example.com/userinfo"},
+	}
+	router := newRouter(t, cfg)
+
+	rec := get(router, "/api/v1/auth/oauth/mock/start", "")
+	require.Equal(t, http.StatusFound, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "/api/v1/auth/oauth/mock", cookies[0].Path, "يجب أن يصل الكوكي إلى مسار الاستدعاء الراجع")
+}
@@ -0,0 +1,111 @@
+package secrets_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"project/internal/secrets"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupEnv_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt")
+	require.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+	t.Setenv("TEST_SECRET", "")
+	t.Setenv("TEST_SECRET_FILE", path)
+
+	value, ok, err := secrets.LookupEnv("TEST_SECRET")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "from-file", value, "يجب إزالة السطر الجديد في نهاية الملف")
+
+	t.Setenv("TEST_SECRET", "from-env")
+	_, _, err = secrets.LookupEnv("TEST_SECRET")
+	assert.Error(t, err, "يجب رفض تعيين المتغير وملفه معاً")
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "jwt_secret"), []byte("s3cret"), 0o600))
+	p := secrets.FileProvider{Dir: dir}
+
+	value, err := p.Secret(context.Background(), "JWT_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value, "يجب قبول اسم الملف بأحرف صغيرة")
+	_, err = p.Secret(context.Background(), "MONGO_URI")
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+	_, err = p.Secret(context.Background(), "../etc/passwd")
+	assert.Error(t, err, "يجب رفض الأسماء التي تحتوي على مسارات")
+}
+
+// vaultStub serves one KV v2 secret at secret/app for token "root".
+func vaultStub(t *testing.T, data map[string]interface{}) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/app" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": data, "metadata": map[string]interface{}{"version": 3}}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVaultProvider(t *testing.T) {
+	data := map[string]interface{}{"JWT_SECRET": "from-vault"}
+	srv := vaultStub(t, data)
+	ctx := context.Background()
+
+	p := &secrets.VaultProvider{Addr: srv.URL, Token: "root", Path: "app"}
+	value, err := p.Secret(ctx, "JWT_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "from-vault", value)
+	_, err = p.Secret(ctx, "MONGO_URI")
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+
+	_, err = (&secrets.VaultProvider{Addr: srv.URL, Token: "wrong", Path: "app"}).Secret(ctx, "JWT_SECRET")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+	_, err = (&secrets.VaultProvider{Addr: srv.URL, Token: "root", Path: "other"}).Secret(ctx, "JWT_SECRET")
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+}
+
+func TestRefresher_ReportsRotation(t *testing.T) {
+	data := map[string]interface{}{"MONGO_URI": "mongodb://old"}
+	srv := vaultStub(t, data)
+	p := &secrets.VaultProvider{Addr: srv.URL, Token: "root", Path: "app"}
+
+	var changes []string
+	fail := true
+	r := secrets.NewRefresher(p, map[string]string{"MONGO_URI": "mongodb://old"}, func(name, value string) error {
+		if fail {
+			return errors.New("reconnect failed")
+		}
+		changes = append(changes, name+"="+value)
+		return nil
+	})
+	ctx := context.Background()
+	require.NoError(t, r.Refresh(ctx))
+	assert.Empty(t, changes, "يجب ألا يُبلَّغ عن تغيير دون تدوير")
+
+	data["MONGO_URI"] = "mongodb://new"
+	assert.Error(t, r.Refresh(ctx))
+	fail = false
+	require.NoError(t, r.Refresh(ctx))
+	assert.Equal(t, []string{"MONGO_URI=mongodb://new"}, changes, "يجب إعادة محاولة التغيير الفاشل")
+	require.NoError(t, r.Refresh(ctx))
+	assert.Len(t, changes, 1)
+}
@@ -0,0 +1,58 @@
+package services_test
+
+import (
+	"context"
+	"project/internal/models"
+	"project/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	repo := &memoryAPIKeyRepo{}
+	svc := services.NewAPIKeyService(repo)
+	userID := primitive.NewObjectID().Hex()
+
+	created, err := svc.CreateKey(context.Background(), userID, "ci script", []string{models.ScopeUsersRead}, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.Key, "يجب إرجاع المفتاح مرة واحدة عند الإنشاء")
+	assert.Contains(t, created.Key, created.Prefix)
+	assert.NotContains(t, repo.keys[0].Hash, created.Key, "يجب ألا يُخزَّن المفتاح كنص صريح")
+
+	key, err := svc.Authenticate(created.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, key.UserID.Hex())
+	assert.NotNil(t, repo.keys[0].LastUsedAt, "يجب تسجيل وقت آخر استخدام")
+
+	_, err = svc.Authenticate(created.Key + "x")
+	assert.Error(t, err, "مفتاح معدّل يجب أن يُرفض")
+
+	keys, err := svc.ListKeys(userID)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	assert.NoError(t, svc.RevokeKey(userID, created.ID))
+	_, err = svc.Authenticate(created.Key)
+	assert.Error(t, err, "المفتاح الملغى يجب أن يُرفض")
+}
+
+func TestAPIKeyValidation(t *testing.T) {
+	svc := services.NewAPIKeyService(&memoryAPIKeyRepo{})
+	userID := primitive.NewObjectID().Hex()
+
+	_, err := svc.CreateKey(context.Background(), userID, "bad", []string{"admin:everything"}, nil)
+	assert.ErrorIs(t, err, services.ErrUnknownScope, "نطاق غير معروف يجب أن يُرفض")
+
+	_, err = svc.CreateKey(context.Background(), userID, "empty", nil, nil)
+	assert.ErrorIs(t, err, services.ErrScopeRequired)
+
+	_, err = svc.CreateKey(context.Background(), userID, "  ", []string{models.ScopeUsersRead}, nil)
+	assert.ErrorIs(t, err, services.ErrAPIKeyNameRequired)
+
+	past := time.Now().Add(-time.Hour)
+	_, err = svc.CreateKey(context.Background(), userID, "expired", []string{models.ScopeUsersRead}, &past)
+	assert.ErrorIs(t, err, services.ErrAPIKeyExpiry, "تاريخ انتهاء في الماضي يجب أن يُرفض")
+}
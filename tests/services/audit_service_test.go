@@ -0,0 +1,42 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/services"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit_PasswordChangeIsRedactedAndAttributed(t *testing.T) {
+	auditRepo := &memoryAuditRepo{}
+	userRepo := &memoryUserRepo{}
+	svc := services.NewUserService(userRepo, services.NewAuditService(auditRepo))
+
+	created, err := svc.CreateUser(context.Background(), models.CreateUserRequest{Name: "Sara", Email: "sara@example.com", Password: "old-password"})
+	require.NoError(t, err)
+
+	ctx := authctx.WithPrincipal(context.Background(), &authctx.Principal{UserID: created.ID, Method: authctx.MethodJWT})
+	ctx = authctx.WithRequestInfo(ctx, authctx.RequestInfo{IP: "10.0.0.1", UserAgent: "test-agent"})
+	require.NoError(t, svc.ChangePassword(ctx, created.ID, "old-password", "new-password"))
+
+	events, err := services.NewAuditService(auditRepo).Query(models.AuditFilter{Action: models.AuditPasswordChanged})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	e := events[0]
+	assert.Equal(t, created.ID, e.ActorID, "يجب تسجيل هوية المنفّذ")
+	assert.Equal(t, "10.0.0.1", e.IP)
+	assert.Equal(t, "test-agent", e.UserAgent)
+	assert.Equal(t, "[REDACTED]", e.After["password"], "يجب حجب كلمة المرور")
+	assert.NotContains(t, e.After, "email", "يجب تسجيل الحقول المتغيرة فقط")
+
+	var buf bytes.Buffer
+	require.NoError(t, services.NewAuditService(auditRepo).ExportJSONLines(context.Background(), models.AuditFilter{}, &buf))
+	assert.Len(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), 2)
+	assert.NotContains(t, buf.String(), "new-password")
+}
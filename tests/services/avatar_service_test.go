@@ -0,0 +1,86 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"project/internal/models"
+	"project/internal/services"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, h/2, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestAvatar_StoresOriginalAndThumbnails(t *testing.T) {
+	users := &memoryUserRepo{}
+	files := &memoryFileRepo{}
+	svc := services.NewAvatarService(users, files, nil)
+	ctx := context.Background()
+	u, _ := users.Create(models.User{Name: "Sara", Email: "sara@example.com"})
+
+	res, err := svc.SetAvatar(ctx, u.ID, testPNG(t, 400, 300))
+	require.NoError(t, err)
+	require.NotEmpty(t, res.AvatarVersion)
+	res.LinkAvatar("/api/v2")
+	assert.Equal(t, "/api/v2/users/"+u.ID+"/avatar?v="+res.AvatarVersion, res.AvatarURL, "الرابط يتبع مسار الإصدار")
+	assert.Len(t, files.files, 1+len(models.AvatarThumbnailSizes))
+
+	file, content, err := svc.Avatar(ctx, u.ID, "64")
+	require.NoError(t, err)
+	defer content.Close()
+	assert.Equal(t, "image/png", file.ContentType)
+	thumb, _, err := image.DecodeConfig(content)
+	require.NoError(t, err)
+	assert.Equal(t, 64, thumb.Width)
+	assert.Equal(t, 64, thumb.Height, "يجب أن تكون الصورة المصغرة مربعة")
+
+	_, content, err = svc.Avatar(ctx, u.ID, models.AvatarOriginal)
+	require.NoError(t, err)
+	_, err = content.Seek(1, io.SeekStart)
+	require.NoError(t, err, "يجب دعم التنقل لطلبات النطاق")
+	content.Close()
+	_, _, err = svc.Avatar(ctx, u.ID, "999")
+	assert.ErrorIs(t, err, services.ErrInvalidAvatarSize)
+
+	_, err = svc.SetAvatar(ctx, u.ID, testPNG(t, 50, 50))
+	require.NoError(t, err)
+	assert.Len(t, files.files, 1+len(models.AvatarThumbnailSizes), "يجب حذف ملفات الصورة السابقة")
+
+	require.NoError(t, svc.DeleteAvatar(ctx, u.ID))
+	assert.Empty(t, files.files)
+	_, _, err = svc.Avatar(ctx, u.ID, "")
+	assert.ErrorIs(t, err, services.ErrAvatarNotFound)
+}
+
+func TestAvatar_RejectsNonImagesAndOversizedUploads(t *testing.T) {
+	users := &memoryUserRepo{}
+	files := &memoryFileRepo{}
+	svc := services.NewAvatarService(users, files, nil)
+	ctx := context.Background()
+	u, _ := users.Create(models.User{Name: "Sara", Email: "sara@example.com"})
+
+	_, err := svc.SetAvatar(ctx, u.ID, []byte("<html><script>alert(1)</script></html>"))
+	assert.ErrorIs(t, err, services.ErrUnsupportedImage, "يجب فحص نوع المحتوى الفعلي")
+	_, err = svc.SetAvatar(ctx, u.ID, make([]byte, services.MaxAvatarBytes+1))
+	assert.ErrorIs(t, err, services.ErrAvatarTooLarge)
+	_, err = svc.SetAvatar(ctx, u.ID, testPNG(t, 5000, 1))
+	assert.ErrorIs(t, err, services.ErrAvatarDimensions)
+	_, err = svc.SetAvatar(ctx, primitive.NewObjectID().Hex(), testPNG(t, 10, 10))
+	assert.ErrorIs(t, err, services.ErrUserNotFound)
+	assert.Empty(t, files.files, "يجب ألا يُخزن أي ملف عند الرفض")
+}
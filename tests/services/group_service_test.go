@@ -0,0 +1,79 @@
+package services_test
+
+import (
+	"context"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/services"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func asUser(userID string) context.Context {
+	return authctx.WithPrincipal(context.Background(), &authctx.Principal{UserID: userID, Method: authctx.MethodJWT})
+}
+
+func TestGroups_OwnersManageMembers(t *testing.T) {
+	users := &memoryUserRepo{}
+	svc := services.NewGroupService(&memoryGroupRepo{}, users, nil)
+	owner, _ := users.Create(models.User{Name: "Owner", Email: "owner@example.com"})
+	member, _ := users.Create(models.User{Name: "Member", Email: "member@example.com"})
+	outsider, _ := users.Create(models.User{Name: "Outsider", Email: "outsider@example.com"})
+
+	group, err := svc.Create(asUser(owner.ID), models.GroupRequest{Name: "Support"})
+	require.NoError(t, err)
+	assert.Equal(t, models.GroupRoleOwner, group.RoleOf(owner.ID), "يجب أن يصبح المنشئ مالكاً للمجموعة")
+	_, err = svc.Create(asUser(owner.ID), models.GroupRequest{Name: "Support"})
+	assert.Error(t, err, "يجب أن يكون اسم المجموعة فريداً")
+
+	_, err = svc.AddMember(asUser(outsider.ID), group.ID.Hex(), models.GroupMemberRequest{UserID: outsider.ID})
+	assert.ErrorIs(t, err, services.ErrGroupForbidden, "فقط المالك أو المدير يضيف الأعضاء")
+	updated, err := svc.AddMember(asUser(owner.ID), group.ID.Hex(), models.GroupMemberRequest{UserID: member.ID})
+	require.NoError(t, err)
+	assert.Equal(t, models.GroupRoleMember, updated.RoleOf(member.ID))
+
+	groups, err := svc.UserGroups(asUser(member.ID), member.ID)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "Support", groups[0].Name)
+
+	assert.ErrorIs(t, svc.RemoveMember(asUser(owner.ID), group.ID.Hex(), owner.ID), services.ErrGroupLastOwner)
+	assert.ErrorIs(t, svc.RemoveMember(asUser(outsider.ID), group.ID.Hex(), member.ID), services.ErrGroupForbidden)
+	require.NoError(t, svc.RemoveMember(asUser(member.ID), group.ID.Hex(), member.ID), "يجب أن يتمكن العضو من المغادرة")
+	groups, err = svc.UserGroups(asUser(member.ID), member.ID)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func TestGroups_PermissionsAreGrantedToMembers(t *testing.T) {
+	users := &memoryUserRepo{}
+	svc := services.NewGroupService(&memoryGroupRepo{}, users, nil)
+	user, _ := users.Create(models.User{Name: "User", Email: "user@example.com"})
+	admin, _ := users.Create(models.User{Name: "Admin", Email: "admin@example.com", Role: models.RoleAdmin})
+
+	_, err := svc.Create(asUser(user.ID), models.GroupRequest{Name: "Escalation", Permissions: []string{models.ScopeAdmin}})
+	assert.ErrorIs(t, err, services.ErrGroupPermissions, "يجب ألا يمنح المستخدم العادي صلاحيات")
+	_, err = svc.Create(asUser(admin.ID), models.GroupRequest{Name: "Bogus", Permissions: []string{"root"}})
+	assert.ErrorIs(t, err, services.ErrUnknownPermission)
+
+	group, err := svc.Create(asUser(admin.ID), models.GroupRequest{Name: "Admins", Permissions: []string{models.ScopeAdmin}})
+	require.NoError(t, err)
+	_, err = svc.AddMember(asUser(admin.ID), group.ID.Hex(), models.GroupMemberRequest{UserID: user.ID, Role: models.GroupRoleOwner})
+	require.NoError(t, err)
+	outsider, _ := users.Create(models.User{Name: "Outsider", Email: "outsider@example.com"})
+	_, err = svc.AddMember(asUser(user.ID), group.ID.Hex(), models.GroupMemberRequest{UserID: outsider.ID, Role: models.GroupRoleOwner})
+	assert.ErrorIs(t, err, services.ErrGroupPermissions, "يجب ألا يمنح مالك غير مدير صلاحيات المجموعة لغيره")
+	_, err = svc.AddMember(asUser(user.ID), group.ID.Hex(), models.GroupMemberRequest{UserID: outsider.ID})
+	assert.ErrorIs(t, err, services.ErrGroupPermissions)
+
+	ids, perms, err := svc.Memberships("", user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{group.ID.Hex()}, ids)
+	assert.Equal(t, []string{models.ScopeAdmin}, perms, "يجب أن يرث العضو صلاحيات المجموعة")
+
+	_, perms, err = svc.Memberships("other-tenant", user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, perms, "يجب ألا تعبر الصلاحيات حدود المستأجر")
+}
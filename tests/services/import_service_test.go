@@ -0,0 +1,119 @@
+package services_test
+
+import (
+	"context"
+	"project/internal/config"
+	"project/internal/models"
+	"project/internal/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const importCSV = `email,name,password
+sara@example.com,Sara,password123
+bad-email,Ali,password123
+SARA@example.com,Sara Again,password123
+taken@example.com,Taken,password123
+invitee@example.com,Invitee,
+`
+
+func newImportTestService(repo *memoryUserRepo) *services.ImportService {
+	invitations := services.NewInvitationService(&memoryInvitationRepo{}, repo, nil, config.LoadConfig().JWT)
+	return services.NewImportService(repo, nil, invitations, nil)
+}
+
+func TestImport_DryRunWritesNothing(t *testing.T) {
+	repo := &memoryUserRepo{}
+	repo.Create(models.User{Name: "Taken", Email: "taken@example.com"})
+
+	report, err := newImportTestService(repo).Import(context.Background(), models.ImportFormatCSV, strings.NewReader(importCSV), services.ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Len(t, repo.users, 1, "يجب ألا ينشئ التشغيل التجريبي أي مستخدم")
+	assert.Equal(t, 5, report.Total)
+	assert.Equal(t, 2, report.Valid)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Equal(t, 2, report.Duplicate)
+	assert.Equal(t, models.ImportRowDuplicate, report.Rows[2].Status, "يجب كشف البريد المكرر داخل الملف بغض النظر عن حالة الأحرف")
+}
+
+func TestImport_CreatesUsersAndInvitations(t *testing.T) {
+	repo := &memoryUserRepo{}
+	repo.Create(models.User{Name: "Taken", Email: "taken@example.com"})
+
+	invitations := services.NewInvitationService(&memoryInvitationRepo{}, repo, nil, config.LoadConfig().JWT)
+	svc := services.NewImportService(repo, nil, invitations, nil)
+	report, err := svc.Import(context.Background(), models.ImportFormatCSV, strings.NewReader(importCSV), services.ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Invited)
+	assert.Len(t, repo.users, 2, "لا يُنشأ حساب للمدعو قبل قبول الدعوة")
+
+	sara := report.Rows[0]
+	assert.Equal(t, models.ImportRowCreated, sara.Status)
+	assert.Empty(t, sara.InviteToken)
+	assert.True(t, strings.HasPrefix(repo.users[1].Password, "bcrypt$"), "يجب تشفير كلمة المرور")
+
+	invitee := report.Rows[4]
+	require.Equal(t, models.ImportRowInvited, invitee.Status)
+	require.NotEmpty(t, invitee.InviteToken, "يجب إصدار رمز دعوة للصفوف بدون كلمة مرور")
+	assert.Empty(t, invitee.UserID)
+	stored, err := bson.Marshal(invitee)
+	require.NoError(t, err)
+	assert.NotContains(t, string(stored), invitee.InviteToken, "يجب ألا يُخزَّن رمز الدعوة في تقارير المهام")
+
+	pending, err := invitations.ListPending(context.Background(), false)
+	require.NoError(t, err)
+	require.Len(t, pending, 1, "الدعوة تظهر مع بقية الدعوات فيمكن إعادة إرسالها أو إلغاؤها")
+	assert.Equal(t, invitee.InvitationID, pending[0].ID.Hex())
+	assert.Equal(t, "Invitee", pending[0].Name)
+
+	_, user, err := invitations.Accept(context.Background(), models.AcceptInvitationRequest{Token: invitee.InviteToken, Password: "chosen-password"})
+	require.NoError(t, err)
+	assert.Equal(t, "Invitee", user.Name, "يُستخدم الاسم من ملف الاستيراد")
+	assert.Equal(t, "invitee@example.com", user.Email)
+	_, _, err = invitations.Accept(context.Background(), models.AcceptInvitationRequest{Token: invitee.InviteToken, Password: "another-password"})
+	assert.ErrorIs(t, err, services.ErrInvitationInvalid, "يجب ألا يُستخدم رمز الدعوة مرتين")
+
+	again, err := svc.Import(context.Background(), models.ImportFormatCSV, strings.NewReader("name,email\nOther,other@example.com\nOther,other@example.com\n"), services.ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, again.Invited)
+	again, err = svc.Import(context.Background(), models.ImportFormatCSV, strings.NewReader("name,email\nOther,other@example.com\n"), services.ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportRowDuplicate, again.Rows[0].Status, "للبريد دعوة معلّقة بالفعل")
+}
+
+func TestImport_NDJSONReportsBadRows(t *testing.T) {
+	repo := &memoryUserRepo{}
+	input := `{"name":"Sara","email":"sara@example.com","password":"password123"}
+{"name":"Ali","email":"ali@example.com","role":"admin"}
+{"name":"Omar","email":"omar@example.com","password":"password123"}
+`
+	report, err := newImportTestService(repo).Import(context.Background(), models.ImportFormatNDJSON, strings.NewReader(input), services.ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, models.ImportRowInvalid, report.Rows[1].Status, "يجب رفض الحقول غير المعروفة")
+
+	_, err = newImportTestService(repo).Import(context.Background(), "xml", strings.NewReader(""), services.ImportOptions{})
+	assert.ErrorIs(t, err, services.ErrUnsupportedImportFormat)
+}
+
+func TestImport_InvalidFiles(t *testing.T) {
+	svc := newImportTestService(&memoryUserRepo{})
+
+	_, err := svc.Import(context.Background(), models.ImportFormatCSV, strings.NewReader(""), services.ImportOptions{})
+	assert.ErrorIs(t, err, services.ErrInvalidImport, "ملف فارغ خطأ من العميل")
+
+	_, err = svc.Import(context.Background(), models.ImportFormatCSV, strings.NewReader("name,phone\nSara,123\n"), services.ImportOptions{})
+	assert.ErrorIs(t, err, services.ErrInvalidImport)
+
+	_, err = svc.Import(context.Background(), models.ImportFormatNDJSON, strings.NewReader(`{"name":"Sara"`+"\n{"), services.ImportOptions{})
+	assert.ErrorIs(t, err, services.ErrInvalidImport)
+
+	_, err = svc.Import(context.Background(), models.ImportFormatCSV, strings.NewReader(importCSV), services.ImportOptions{InviteTTL: 1000 * time.Hour})
+	assert.ErrorIs(t, err, services.ErrInviteTTL, "مدة الدعوة محدودة كما في نقطة الدعوات")
+}
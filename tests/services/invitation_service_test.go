@@ -0,0 +1,66 @@
+package services_test
+
+import (
+	"context"
+	"project/internal/authctx"
+	"project/internal/config"
+	"project/internal/models"
+	"project/internal/services"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvitations_AcceptCreatesUserOnce(t *testing.T) {
+	users := &memoryUserRepo{}
+	svc := services.NewInvitationService(&memoryInvitationRepo{}, users, nil, config.LoadConfig().JWT)
+	ctx := context.Background()
+
+	res, err := svc.Create(ctx, models.InvitationRequest{Email: "New@Example.com", Role: models.RoleAdmin})
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", res.Invitation.Email)
+	require.NotEmpty(t, res.Token)
+	_, err = svc.Create(ctx, models.InvitationRequest{Email: "new@example.com"})
+	assert.Error(t, err, "يجب ألا تتكرر الدعوة المعلقة لنفس البريد")
+
+	pending, err := svc.ListPending(ctx, false)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	resent, err := svc.Resend(ctx, res.Invitation.ID.Hex(), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resent.Invitation.SendCount)
+	_, _, err = svc.Accept(ctx, models.AcceptInvitationRequest{Token: res.Token, Name: "Newcomer", Password: "password123"})
+	assert.ErrorIs(t, err, services.ErrInvitationInvalid, "يجب إبطال الرمز القديم بعد إعادة الإرسال")
+
+	token, user, err := svc.Accept(ctx, models.AcceptInvitationRequest{Token: resent.Token, Name: "Newcomer", Password: "password123"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, "new@example.com", user.Email)
+	assert.Equal(t, models.RoleAdmin, user.Role, "يجب منح الدور المحدد في الدعوة")
+
+	_, _, err = svc.Accept(ctx, models.AcceptInvitationRequest{Token: resent.Token, Name: "Again", Password: "password123"})
+	assert.Error(t, err, "يجب ألا تُقبل الدعوة مرتين")
+	pending, _ = svc.ListPending(ctx, true)
+	assert.Empty(t, pending)
+}
+
+func TestInvitations_RevokeAndTenantScope(t *testing.T) {
+	users := &memoryUserRepo{}
+	svc := services.NewInvitationService(&memoryInvitationRepo{}, users, nil, config.LoadConfig().JWT)
+	acme := authctx.WithTenant(context.Background(), "acme-id")
+
+	users.ForTenant("acme-id").Create(models.User{Name: "Member", Email: "member@example.com"})
+	_, err := svc.Create(acme, models.InvitationRequest{Email: "member@example.com"})
+	assert.ErrorIs(t, err, services.ErrInvitedUserExists)
+
+	res, err := svc.Create(acme, models.InvitationRequest{Email: "guest@example.com"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, svc.Revoke(context.Background(), res.Invitation.ID.Hex()), services.ErrInvitationNotFound, "يجب ألا يرى مستأجر دعوات مستأجر آخر")
+	require.NoError(t, svc.Revoke(acme, res.Invitation.ID.Hex()))
+	assert.ErrorIs(t, svc.Revoke(acme, res.Invitation.ID.Hex()), services.ErrInvitationUsed)
+
+	_, _, err = svc.Accept(context.Background(), models.AcceptInvitationRequest{Token: res.Token, Name: "Guest", Password: "password123"})
+	assert.ErrorIs(t, err, services.ErrInvitationInvalid, "يجب رفض الدعوة الملغاة")
+}
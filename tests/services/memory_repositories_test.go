@@ -0,0 +1,725 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/pkg/utils"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memoryAPIKeyRepo is an in-memory APIKeyRepositoryInterface for tests.
+type memoryAPIKeyRepo struct {
+	keys []*models.APIKey
+}
+
+func (m *memoryAPIKeyRepo) Create(key models.APIKey) (*models.APIKey, error) {
+	key.ID = primitive.NewObjectID()
+	key.CreatedAt = time.Now()
+	m.keys = append(m.keys, &key)
+	return &key, nil
+}
+
+func (m *memoryAPIKeyRepo) FindByUser(userIDStr string) ([]models.APIKey, error) {
+	var res []models.APIKey
+	for _, k := range m.keys {
+		if k.UserID.Hex() == userIDStr && k.RevokedAt == nil {
+			res = append(res, *k)
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryAPIKeyRepo) FindByPrefix(prefix string) (*models.APIKey, error) {
+	for _, k := range m.keys {
+		if k.Prefix == prefix && k.RevokedAt == nil {
+			return k, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryAPIKeyRepo) Revoke(userIDStr string, idStr string) error {
+	for _, k := range m.keys {
+		if k.ID.Hex() == idStr && k.UserID.Hex() == userIDStr && k.RevokedAt == nil {
+			now := time.Now()
+			k.RevokedAt = &now
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (m *memoryAPIKeyRepo) TouchLastUsed(idStr string) error {
+	for _, k := range m.keys {
+		if k.ID.Hex() == idStr {
+			now := time.Now()
+			k.LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+// memoryUserRepo is an in-memory UserRepositoryInterface for tests. Views
+// returned by ForTenant share its users but only see their own tenant's.
+type memoryUserRepo struct {
+	users  []*models.User
+	root   *memoryUserRepo
+	tenant string
+}
+
+func (m *memoryUserRepo) ForTenant(tenantID string) repositories.UserRepositoryInterface {
+	return &memoryUserRepo{root: m.store(), tenant: tenantID}
+}
+
+func (m *memoryUserRepo) store() *memoryUserRepo {
+	if m.root != nil {
+		return m.root
+	}
+	return m
+}
+
+// visible returns the users of this view's tenant, deleted ones included.
+func (m *memoryUserRepo) visible() []*models.User {
+	var res []*models.User
+	for _, u := range m.store().users {
+		if u.TenantID == m.tenant {
+			res = append(res, u)
+		}
+	}
+	return res
+}
+
+func (m *memoryUserRepo) find(match func(u *models.User) bool) *models.User {
+	for _, u := range m.visible() {
+		if u.DeletedAt == nil && match(u) {
+			return u
+		}
+	}
+	return nil
+}
+
+func (m *memoryUserRepo) FindAll(filter models.UserFilter) ([]models.UserResponse, error) {
+	var res []models.UserResponse
+	err := m.Stream(context.Background(), filter, func(u models.User) error {
+		res = append(res, *u.ToResponse())
+		return nil
+	})
+	return res, err
+}
+
+// Stream supports the exact-match filters used in tests.
+func (m *memoryUserRepo) Stream(ctx context.Context, filter models.UserFilter, fn func(user models.User) error) error {
+	for _, u := range m.visible() {
+		if u.DeletedAt != nil || filter.Email != "" && u.Email != filter.Email || filter.Role != "" && u.Role != filter.Role {
+			continue
+		}
+		if err := fn(*u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryUserRepo) FindByID(idStr string) (*models.UserResponse, error) {
+	if u := m.find(func(u *models.User) bool { return u.ID.Hex() == idStr }); u != nil {
+		return u.ToResponse(), nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryUserRepo) FindByEmail(email string) (*models.User, error) {
+	if u := m.find(func(u *models.User) bool { return u.Email == email }); u != nil {
+		return u, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryUserRepo) FindUserByID(idStr string) (*models.User, error) {
+	if u := m.find(func(u *models.User) bool { return u.ID.Hex() == idStr }); u != nil {
+		return u, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryUserRepo) Create(user models.User) (*models.UserResponse, error) {
+	user.ID = primitive.NewObjectID()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	user.Version = 1
+	user.TenantID = m.tenant
+	m.store().users = append(m.store().users, &user)
+	return user.ToResponse(), nil
+}
+
+func (m *memoryUserRepo) CreateMany(users []models.User) (map[int]error, error) {
+	failed := map[int]error{}
+	for i := range users {
+		if u, _ := m.FindByEmail(users[i].Email); u != nil {
+			failed[i] = errors.New("email already exists")
+			continue
+		}
+		created, _ := m.Create(users[i])
+		users[i].ID, _ = primitive.ObjectIDFromHex(created.ID)
+	}
+	return failed, nil
+}
+
+func (m *memoryUserRepo) ExistingEmails(emails []string) (map[string]bool, error) {
+	found := map[string]bool{}
+	for _, email := range emails {
+		if u, _ := m.FindByEmail(email); u != nil {
+			found[email] = true
+		}
+	}
+	return found, nil
+}
+
+// SearchText scores one point per normalized name word equal to a term.
+func (m *memoryUserRepo) SearchText(terms string, limit int64) ([]models.ScoredUser, error) {
+	res := []models.ScoredUser{}
+	for _, u := range m.visible() {
+		score := 0.0
+		for _, word := range strings.Fields(utils.NormalizeSearchText(u.Name)) {
+			for _, term := range strings.Fields(terms) {
+				if word == term {
+					score++
+				}
+			}
+		}
+		if u.DeletedAt == nil && score > 0 {
+			res = append(res, models.ScoredUser{User: *u, Score: score})
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryUserRepo) SearchPrefix(prefix string, limit int64) ([]models.User, error) {
+	res := []models.User{}
+	p := utils.NormalizeSearchText(prefix)
+	for _, u := range m.visible() {
+		name := " " + utils.NormalizeSearchText(u.Name)
+		if u.DeletedAt == nil && (strings.HasPrefix(u.Email, strings.ToLower(prefix)) || strings.Contains(name, " "+p)) {
+			res = append(res, *u)
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryUserRepo) Update(idStr string, user models.User) (*models.UserResponse, error) {
+	u := m.find(func(u *models.User) bool { return u.ID.Hex() == idStr })
+	if u == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	if user.Name != "" {
+		u.Name = user.Name
+	}
+	if user.Email != "" {
+		u.Email = user.Email
+	}
+	if user.Password != "" {
+		u.Password = user.Password
+	}
+	u.UpdatedAt = time.Now()
+	u.Version++
+	return u.ToResponse(), nil
+}
+
+func (m *memoryUserRepo) Replace(idStr string, user models.User, expectedVersion int64) (*models.UserResponse, error) {
+	u := m.find(func(u *models.User) bool { return u.ID.Hex() == idStr })
+	if u == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	if expectedVersion != repositories.AnyVersion && u.Version != expectedVersion {
+		return nil, repositories.ErrVersionConflict
+	}
+	u.Name, u.Email, u.Password = user.Name, user.Email, user.Password
+	u.UpdatedAt = time.Now()
+	u.Version++
+	return u.ToResponse(), nil
+}
+
+func (m *memoryUserRepo) SetRole(idStr string, role string) error {
+	u := m.find(func(u *models.User) bool { return u.ID.Hex() == idStr })
+	if u == nil {
+		return mongo.ErrNoDocuments
+	}
+	u.Role = role
+	u.Version++
+	return nil
+}
+
+func (m *memoryUserRepo) SetAvatar(idStr string, avatar *models.Avatar) error {
+	u := m.find(func(u *models.User) bool { return u.ID.Hex() == idStr && u.DeletedAt == nil })
+	if u == nil {
+		return mongo.ErrNoDocuments
+	}
+	u.Avatar = avatar
+	u.Version++
+	return nil
+}
+
+func (m *memoryUserRepo) SetTenant(idStr string, tenantID string) error {
+	u := m.find(func(u *models.User) bool { return u.ID.Hex() == idStr })
+	if u == nil {
+		return mongo.ErrNoDocuments
+	}
+	u.TenantID = tenantID
+	u.Version++
+	return nil
+}
+
+func (m *memoryUserRepo) Delete(idStr string, expectedVersion int64) error {
+	u := m.find(func(u *models.User) bool { return u.ID.Hex() == idStr })
+	if u == nil {
+		return mongo.ErrNoDocuments
+	}
+	if expectedVersion != repositories.AnyVersion && u.Version != expectedVersion {
+		return repositories.ErrVersionConflict
+	}
+	u.Version++
+	now := time.Now()
+	u.DeletedAt = &now
+	return nil
+}
+
+func (m *memoryUserRepo) FindByIdentity(provider, subject string) (*models.User, error) {
+	u := m.find(func(u *models.User) bool {
+		for _, id := range u.Identities {
+			if id.Provider == provider && id.Subject == subject {
+				return true
+			}
+		}
+		return false
+	})
+	if u == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	return u, nil
+}
+
+func (m *memoryUserRepo) LinkIdentity(idStr string, identity models.ExternalIdentity) error {
+	u := m.find(func(u *models.User) bool { return u.ID.Hex() == idStr })
+	if u == nil {
+		return mongo.ErrNoDocuments
+	}
+	u.Identities = append(u.Identities, identity)
+	u.Version++
+	return nil
+}
+
+// memoryOAuthServerRepo is an in-memory OAuthServerRepositoryInterface for tests.
+type memoryOAuthServerRepo struct {
+	clients       []*models.OAuthClient
+	codes         []*models.OAuthAuthorizationCode
+	refreshTokens []*models.OAuthRefreshToken
+}
+
+func (m *memoryOAuthServerRepo) CreateClient(client models.OAuthClient) (*models.OAuthClient, error) {
+	client.ID = primitive.NewObjectID()
+	client.CreatedAt = time.Now()
+	m.clients = append(m.clients, &client)
+	return &client, nil
+}
+
+func (m *memoryOAuthServerRepo) FindClientByClientID(clientID string) (*models.OAuthClient, error) {
+	for _, c := range m.clients {
+		if c.ClientID == clientID {
+			return c, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryOAuthServerRepo) FindClientsByOwner(ownerIDStr string) ([]models.OAuthClient, error) {
+	var res []models.OAuthClient
+	for _, c := range m.clients {
+		if c.OwnerID.Hex() == ownerIDStr {
+			res = append(res, *c)
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryOAuthServerRepo) CreateCode(code models.OAuthAuthorizationCode) error {
+	code.ID = primitive.NewObjectID()
+	m.codes = append(m.codes, &code)
+	return nil
+}
+
+func (m *memoryOAuthServerRepo) ConsumeCode(codeHash string) (*models.OAuthAuthorizationCode, error) {
+	for _, c := range m.codes {
+		if c.CodeHash == codeHash && c.UsedAt == nil {
+			now := time.Now()
+			c.UsedAt = &now
+			return c, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryOAuthServerRepo) CreateRefreshToken(token models.OAuthRefreshToken) error {
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now()
+	m.refreshTokens = append(m.refreshTokens, &token)
+	return nil
+}
+
+func (m *memoryOAuthServerRepo) FindRefreshToken(tokenHash string) (*models.OAuthRefreshToken, error) {
+	for _, t := range m.refreshTokens {
+		if t.TokenHash == tokenHash {
+			return t, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryOAuthServerRepo) RevokeRefreshToken(idStr string) error {
+	for _, t := range m.refreshTokens {
+		if t.ID.Hex() == idStr && t.RevokedAt == nil {
+			now := time.Now()
+			t.RevokedAt = &now
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// memoryAuditRepo is an in-memory AuditRepositoryInterface for tests.
+type memoryAuditRepo struct {
+	events []models.AuditEvent
+}
+
+func (m *memoryAuditRepo) Insert(event models.AuditEvent) error {
+	event.ID = primitive.NewObjectID()
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *memoryAuditRepo) Find(filter models.AuditFilter) ([]models.AuditEvent, error) {
+	var out []models.AuditEvent
+	for i := len(m.events) - 1; i >= 0; i-- {
+		if e := m.events[i]; filter.Action == "" || e.Action == filter.Action {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryAuditRepo) Stream(ctx context.Context, filter models.AuditFilter, fn func(event models.AuditEvent) error) error {
+	for _, e := range m.events {
+		if filter.Action == "" || e.Action == filter.Action {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// memoryOrganizationRepo is an in-memory OrganizationRepositoryInterface for tests.
+type memoryOrganizationRepo struct {
+	orgs []*models.Organization
+}
+
+func (m *memoryOrganizationRepo) Create(org models.Organization) (*models.Organization, error) {
+	if o, _ := m.FindBySlug(org.Slug); o != nil {
+		return nil, repositories.ErrSlugTaken
+	}
+	org.ID = primitive.NewObjectID()
+	org.CreatedAt = time.Now()
+	m.orgs = append(m.orgs, &org)
+	return &org, nil
+}
+
+func (m *memoryOrganizationRepo) FindAll() ([]models.Organization, error) {
+	res := []models.Organization{}
+	for _, o := range m.orgs {
+		res = append(res, *o)
+	}
+	return res, nil
+}
+
+func (m *memoryOrganizationRepo) FindByID(idStr string) (*models.Organization, error) {
+	for _, o := range m.orgs {
+		if o.ID.Hex() == idStr {
+			return o, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryOrganizationRepo) FindBySlug(slug string) (*models.Organization, error) {
+	for _, o := range m.orgs {
+		if o.Slug == slug {
+			return o, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryOrganizationRepo) Update(idStr string, name, slug string) (*models.Organization, error) {
+	o, err := m.FindByID(idStr)
+	if err != nil {
+		return nil, err
+	}
+	if other, _ := m.FindBySlug(slug); other != nil && other != o {
+		return nil, repositories.ErrSlugTaken
+	}
+	o.Name, o.Slug = name, slug
+	return o, nil
+}
+
+func (m *memoryOrganizationRepo) Delete(idStr string) error {
+	for i, o := range m.orgs {
+		if o.ID.Hex() == idStr {
+			m.orgs = append(m.orgs[:i], m.orgs[i+1:]...)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// memoryGroupRepo is an in-memory GroupRepositoryInterface for tests. Views
+// returned by ForTenant share the groups of their root.
+type memoryGroupRepo struct {
+	groups []*models.Group
+	root   *memoryGroupRepo
+	tenant string
+}
+
+func (m *memoryGroupRepo) ForTenant(tenantID string) repositories.GroupRepositoryInterface {
+	return &memoryGroupRepo{root: m.store(), tenant: tenantID}
+}
+
+func (m *memoryGroupRepo) store() *memoryGroupRepo {
+	if m.root != nil {
+		return m.root
+	}
+	return m
+}
+
+func (m *memoryGroupRepo) Create(group models.Group) (*models.Group, error) {
+	for _, g := range m.store().groups {
+		if g.TenantID == m.tenant && g.Name == group.Name {
+			return nil, repositories.ErrGroupNameTaken
+		}
+	}
+	group.ID = primitive.NewObjectID()
+	group.TenantID = m.tenant
+	group.CreatedAt = time.Now()
+	m.store().groups = append(m.store().groups, &group)
+	return &group, nil
+}
+
+func (m *memoryGroupRepo) find(match func(g *models.Group) bool) []models.Group {
+	res := []models.Group{}
+	for _, g := range m.store().groups {
+		if g.TenantID == m.tenant && match(g) {
+			c := *g
+			c.Members = append([]models.GroupMember(nil), g.Members...)
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func (m *memoryGroupRepo) FindAll() ([]models.Group, error) {
+	return m.find(func(*models.Group) bool { return true }), nil
+}
+
+func (m *memoryGroupRepo) FindByMember(userID string) ([]models.Group, error) {
+	return m.find(func(g *models.Group) bool { return g.RoleOf(userID) != "" }), nil
+}
+
+func (m *memoryGroupRepo) get(idStr string) (*models.Group, error) {
+	for _, g := range m.store().groups {
+		if g.TenantID == m.tenant && g.ID.Hex() == idStr {
+			return g, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryGroupRepo) FindByID(idStr string) (*models.Group, error) {
+	res := m.find(func(g *models.Group) bool { return g.ID.Hex() == idStr })
+	if len(res) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &res[0], nil
+}
+
+func (m *memoryGroupRepo) Update(idStr string, name, description string, permissions []string) (*models.Group, error) {
+	g, err := m.get(idStr)
+	if err != nil {
+		return nil, err
+	}
+	g.Name, g.Description, g.Permissions = name, description, permissions
+	return m.FindByID(idStr)
+}
+
+func (m *memoryGroupRepo) Delete(idStr string) error {
+	groups := m.store().groups
+	for i, g := range groups {
+		if g.TenantID == m.tenant && g.ID.Hex() == idStr {
+			m.store().groups = append(groups[:i], groups[i+1:]...)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (m *memoryGroupRepo) SetMember(idStr string, member models.GroupMember) error {
+	g, err := m.get(idStr)
+	if err != nil {
+		return err
+	}
+	for i := range g.Members {
+		if g.Members[i].UserID == member.UserID {
+			g.Members[i].Role = member.Role
+			return nil
+		}
+	}
+	g.Members = append(g.Members, member)
+	return nil
+}
+
+func (m *memoryGroupRepo) RemoveMember(idStr string, userID string) error {
+	g, err := m.get(idStr)
+	if err != nil {
+		return err
+	}
+	for i := range g.Members {
+		if g.Members[i].UserID == userID {
+			g.Members = append(g.Members[:i], g.Members[i+1:]...)
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+// memoryInvitationRepo is an in-memory InvitationRepositoryInterface for tests.
+type memoryInvitationRepo struct {
+	invs   []*models.Invitation
+	root   *memoryInvitationRepo
+	tenant string
+}
+
+func (m *memoryInvitationRepo) ForTenant(tenantID string) repositories.InvitationRepositoryInterface {
+	return &memoryInvitationRepo{root: m.store(), tenant: tenantID}
+}
+
+func (m *memoryInvitationRepo) store() *memoryInvitationRepo {
+	if m.root != nil {
+		return m.root
+	}
+	return m
+}
+
+func (m *memoryInvitationRepo) Create(inv models.Invitation) (*models.Invitation, error) {
+	for _, i := range m.store().invs {
+		if i.TenantID == m.tenant && i.Email == inv.Email && i.Status == models.InvitationPending {
+			return nil, repositories.ErrInvitationPending
+		}
+	}
+	inv.ID = primitive.NewObjectID()
+	inv.TenantID = m.tenant
+	m.store().invs = append(m.store().invs, &inv)
+	c := inv
+	return &c, nil
+}
+
+func (m *memoryInvitationRepo) get(idStr string) (*models.Invitation, error) {
+	for _, i := range m.store().invs {
+		if i.TenantID == m.tenant && i.ID.Hex() == idStr {
+			return i, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (m *memoryInvitationRepo) FindByID(idStr string) (*models.Invitation, error) {
+	i, err := m.get(idStr)
+	if err != nil {
+		return nil, err
+	}
+	c := *i
+	return &c, nil
+}
+
+func (m *memoryInvitationRepo) FindPending() ([]models.Invitation, error) {
+	res := []models.Invitation{}
+	for _, i := range m.store().invs {
+		if i.TenantID == m.tenant && i.Status == models.InvitationPending {
+			res = append(res, *i)
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryInvitationRepo) Resend(idStr string, tokenHash string, expiresAt time.Time) (*models.Invitation, error) {
+	i, err := m.get(idStr)
+	if err != nil || i.Status != models.InvitationPending {
+		return nil, mongo.ErrNoDocuments
+	}
+	i.TokenHash, i.ExpiresAt, i.SentAt = tokenHash, expiresAt, time.Now()
+	i.SendCount++
+	return m.FindByID(idStr)
+}
+
+func (m *memoryInvitationRepo) SetStatus(idStr string, from, to string, userID string) error {
+	i, err := m.get(idStr)
+	if err != nil || i.Status != from {
+		return mongo.ErrNoDocuments
+	}
+	i.Status, i.UserID = to, userID
+	return nil
+}
+
+// memoryFileRepo is an in-memory FileRepositoryInterface for tests.
+type memoryFileRepo struct {
+	files map[string]*models.StoredFile
+	data  map[string][]byte
+}
+
+func (m *memoryFileRepo) Save(name, contentType string, data io.Reader) (string, error) {
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return "", err
+	}
+	if m.files == nil {
+		m.files, m.data = map[string]*models.StoredFile{}, map[string][]byte{}
+	}
+	id := primitive.NewObjectID().Hex()
+	m.files[id] = &models.StoredFile{ID: id, Name: name, ContentType: contentType, Length: int64(len(content)), UploadDate: time.Now()}
+	m.data[id] = content
+	return id, nil
+}
+
+type nopSeekCloser struct{ *bytes.Reader }
+
+func (nopSeekCloser) Close() error { return nil }
+
+func (m *memoryFileRepo) Open(idStr string) (*models.StoredFile, io.ReadSeekCloser, error) {
+	f, ok := m.files[idStr]
+	if !ok {
+		return nil, nil, mongo.ErrNoDocuments
+	}
+	return f, nopSeekCloser{bytes.NewReader(m.data[idStr])}, nil
+}
+
+func (m *memoryFileRepo) Delete(idStr string) error {
+	if _, ok := m.files[idStr]; !ok {
+		return mongo.ErrNoDocuments
+	}
+	delete(m.files, idStr)
+	delete(m.data, idStr)
+	return nil
+}
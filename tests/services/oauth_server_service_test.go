@@ -0,0 +1,151 @@
+package services_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"project/internal/authctx"
+	"project/internal/config"
+	"project/internal/models"
+	"project/internal/services"
+	"project/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOAuthServerTestService() (*services.OAuthServerService, *memoryUserRepo) {
+	users := &memoryUserRepo{}
+	svc := services.NewOAuthServerService(&memoryOAuthServerRepo{}, users,
+		config.OAuthServerConfig{Issuer: "http://localhost:8090", AccessTokenMinutes: 60, RefreshTokenDays: 30},
+		config.JWTConfig{Secret: "test-secret", Expiry: 1})
+	return svc, users
+}
+
+func TestOAuthServer_AuthorizationCodeWithPKCE(t *testing.T) {
+	svc, users := newOAuthServerTestService()
+	user, _ := users.Create(models.User{Name: "Omar", Email: "omar@example.com"})
+	client, err := svc.RegisterClient(context.Background(), user.ID, "Dashboard", []string{"https://dash.example.com/cb"}, nil, nil, false)
+	require.NoError(t, err)
+	require.NotEmpty(t, client.ClientSecret)
+
+	verifier := "a-long-random-code-verifier-value-for-the-test"
+	sum := sha256.Sum256([]byte(verifier))
+	req := services.AuthorizeRequest{
+		ResponseType: "code", ClientID: client.ClientID, RedirectURI: "https://dash.example.com/cb",
+		Scope: "openid email offline_access", State: "xyz", Nonce: "n-1",
+		CodeChallenge: base64.RawURLEncoding.EncodeToString(sum[:]), CodeChallengeMethod: "S256",
+	}
+	consent, err := svc.ValidateAuthorize(req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"openid", "email", "offline_access"}, consent.Scopes)
+
+	redirect, err := svc.Decide(context.Background(), user.ID, req, true)
+	require.NoError(t, err)
+	u, _ := url.Parse(redirect)
+	assert.Equal(t, "xyz", u.Query().Get("state"))
+	code := u.Query().Get("code")
+
+	tokenReq := services.TokenRequest{
+		GrantType: models.GrantAuthorizationCode, ClientID: client.ClientID, ClientSecret: client.ClientSecret,
+		Code: code, RedirectURI: "https://dash.example.com/cb", CodeVerifier: "wrong",
+	}
+	_, err = svc.Token(tokenReq)
+	assert.Error(t, err, "يجب رفض code_verifier الخاطئ")
+
+	// The code was consumed by the failed attempt as well
+	tokenReq.CodeVerifier = verifier
+	_, err = svc.Token(tokenReq)
+	assert.Error(t, err, "الكود يُستخدم مرة واحدة فقط")
+
+	redirect, _ = svc.Decide(context.Background(), user.ID, req, true)
+	u, _ = url.Parse(redirect)
+	tokenReq.Code = u.Query().Get("code")
+	tokens, err := svc.Token(tokenReq)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.IDToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	claims, err := utils.ValidateJWTWithSecret(tokens.AccessToken, "test-secret")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, client.ClientID, claims.ClientID)
+
+	info, err := svc.UserInfo(context.Background(), user.ID, []string{"openid", "email"})
+	require.NoError(t, err)
+	assert.Equal(t, "omar@example.com", info["email"])
+	assert.Nil(t, info["name"], "الاسم يتطلب نطاق profile")
+
+	refreshed, err := svc.Token(services.TokenRequest{
+		GrantType: models.GrantRefreshToken, ClientID: client.ClientID, ClientSecret: client.ClientSecret, RefreshToken: tokens.RefreshToken,
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+	_, err = svc.Token(services.TokenRequest{
+		GrantType: models.GrantRefreshToken, ClientID: client.ClientID, ClientSecret: client.ClientSecret, RefreshToken: tokens.RefreshToken,
+	})
+	assert.Error(t, err, "رمز التحديث القديم يجب أن يُرفض بعد التدوير")
+
+	req.Scope = "openid email"
+	redirect, _ = svc.Decide(context.Background(), user.ID, req, true)
+	u, _ = url.Parse(redirect)
+	tokenReq.Code = u.Query().Get("code")
+	tokens, err = svc.Token(tokenReq)
+	require.NoError(t, err)
+	assert.Empty(t, tokens.RefreshToken, "رمز التحديث يتطلب نطاق offline_access")
+}
+
+func TestOAuthServer_ClientRules(t *testing.T) {
+	svc, users := newOAuthServerTestService()
+	user, _ := users.Create(models.User{Name: "Omar", Email: "omar@example.com"})
+
+	public, err := svc.RegisterClient(context.Background(), user.ID, "Mobile", []string{"https://app.example.com/cb"}, nil, nil, true)
+	require.NoError(t, err)
+	assert.Empty(t, public.ClientSecret)
+	_, err = svc.ValidateAuthorize(services.AuthorizeRequest{ResponseType: "code", ClientID: public.ClientID})
+	assert.Error(t, err, "العملاء العامّون يجب أن يستخدموا PKCE")
+
+	_, err = svc.RegisterClient(context.Background(), user.ID, "Bad", nil, []string{models.GrantClientCredentials}, nil, true)
+	assert.ErrorIs(t, err, services.ErrPublicClientCredentials)
+	_, err = svc.RegisterClient(context.Background(), user.ID, "Web", nil, nil, nil, false)
+	assert.ErrorIs(t, err, services.ErrRedirectURIRequired, "رمز التفويض يتطلب عنوان إعادة توجيه")
+	_, err = svc.RegisterClient(context.Background(), user.ID, "Web", []string{"/relative"}, nil, nil, false)
+	assert.ErrorIs(t, err, services.ErrInvalidRedirectURI)
+	_, err = svc.RegisterClient(context.Background(), user.ID, "Web", []string{"https://app.example.com/cb"}, []string{"password"}, nil, false)
+	assert.ErrorIs(t, err, services.ErrUnsupportedGrantType)
+	_, err = svc.RegisterClient(context.Background(), user.ID, "Web", []string{"https://app.example.com/cb"}, nil, []string{"root"}, false)
+	assert.ErrorIs(t, err, services.ErrUnknownScope)
+
+	_, err = svc.RegisterClient(asUser(user.ID), user.ID, "Batch", nil, []string{models.GrantClientCredentials}, []string{models.ScopeUsersRead}, false)
+	assert.ErrorIs(t, err, services.ErrPrivilegedClient, "client_credentials للمشرفين فقط")
+	_, err = svc.RegisterClient(asUser(user.ID), user.ID, "Web", []string{"https://app.example.com/cb"}, nil, []string{models.ScopeUsersWrite}, false)
+	assert.ErrorIs(t, err, services.ErrPrivilegedClient, "نطاقات API للمشرفين فقط")
+
+	admin, _ := users.Create(models.User{Name: "Admin", Email: "admin@example.com", Role: models.RoleAdmin})
+	service, err := svc.RegisterClient(asUser(admin.ID), admin.ID, "Batch", nil, []string{models.GrantClientCredentials}, []string{models.ScopeUsersRead}, false)
+	require.NoError(t, err)
+	tokens, err := svc.Token(services.TokenRequest{GrantType: models.GrantClientCredentials, ClientID: service.ClientID, ClientSecret: service.ClientSecret})
+	require.NoError(t, err)
+	assert.Equal(t, models.ScopeUsersRead, tokens.Scope)
+	assert.Empty(t, tokens.RefreshToken)
+
+	_, err = svc.Token(services.TokenRequest{GrantType: models.GrantClientCredentials, ClientID: service.ClientID, ClientSecret: "nope"})
+	var oerr *services.OAuthError
+	require.ErrorAs(t, err, &oerr)
+	assert.Equal(t, "invalid_client", oerr.Code)
+}
+
+func TestOAuthServer_DiscoveryFollowsVersionTree(t *testing.T) {
+	svc, _ := newOAuthServerTestService()
+
+	doc := svc.Discovery(authctx.WithAPIVersion(context.Background(), authctx.APIVersion{Version: "v2", BasePath: "/api/v2"}))
+	assert.Equal(t, "http://localhost:8090", doc["issuer"], "المُصدِر لا يتغير بين الإصدارات")
+	assert.Equal(t, "http://localhost:8090/api/v2/oauth/authorize", doc["authorization_endpoint"])
+	assert.Equal(t, "http://localhost:8090/api/v2/oauth/token", doc["token_endpoint"])
+	assert.Equal(t, "http://localhost:8090/api/v2/userinfo", doc["userinfo_endpoint"])
+
+	doc = svc.Discovery(context.Background())
+	assert.Equal(t, "http://localhost:8090/oauth/token", doc["token_endpoint"], "المسارات القديمة تبقى بدون بادئة")
+}
@@ -0,0 +1,159 @@
+package services_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"project/internal/authctx"
+	"project/internal/config"
+	"project/internal/models"
+	"project/internal/services"
+	"project/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockOIDCServer serves discovery, token and userinfo endpoints and checks
+// the PKCE verifier against the challenge sent to the authorization endpoint.
+func newMockOIDCServer(t *testing.T, challenge *string, email string, verified bool) *httptest.Server {
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 srv.URL,
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"userinfo_endpoint":      srv.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != *challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "mock-access", "token_type": "Bearer"})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mock-access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"sub": "oidc-subject-1", "email": email, "email_verified": verified, "name": "سارة أحمد",
+		})
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newOAuthTestService(issuer string, repo *memoryUserRepo) *services.OAuthService {
+	cfg := config.OAuthConfig{
+		RedirectBaseURL: "http://localhost:8090",
+		Providers: map[string]config.OAuthProviderConfig{
+			"mock": {Kind: "oidc", ClientID: "client", ClientSecret: "secret", Issuer: issuer, Scopes: []string{"openid", "email"}},
+		},
+	}
+	return services.NewOAuthService(repo, nil, cfg, config.JWTConfig{Secret: "test-secret", Expiry: 1})
+}
+
+func startFlow(t *testing.T, svc *services.OAuthService, challenge *string) (string, string) {
+	authURL, stateToken, err := svc.StartAuth(context.Background(), "mock")
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	*challenge = u.Query().Get("code_challenge")
+	return u.Query().Get("state"), stateToken
+}
+
+func TestOAuthCallback_LinksExistingUserByVerifiedEmail(t *testing.T) {
+	var challenge string
+	srv := newMockOIDCServer(t, &challenge, "sara@example.com", true)
+	repo := &memoryUserRepo{}
+	existing, _ := repo.Create(models.User{Name: "Sara", Email: "sara@example.com", Password: "bcrypt$x"})
+	svc := newOAuthTestService(srv.URL, repo)
+
+	state, stateToken := startFlow(t, svc, &challenge)
+	token, user, err := svc.HandleCallback(context.Background(), "mock", "good-code", state, stateToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, existing.ID, user.ID, "يجب ربط الهوية الخارجية بالمستخدم الموجود")
+	assert.Len(t, repo.users, 1)
+	assert.Len(t, repo.users[0].Identities, 1)
+
+	// A second sign-in resolves through the linked identity
+	state, stateToken = startFlow(t, svc, &challenge)
+	_, user, err = svc.HandleCallback(context.Background(), "mock", "good-code", state, stateToken)
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, user.ID)
+	assert.Len(t, repo.users[0].Identities, 1, "يجب عدم تكرار ربط الهوية")
+}
+
+func TestOAuthCallback_CreatesUserWithoutPassword(t *testing.T) {
+	var challenge string
+	srv := newMockOIDCServer(t, &challenge, "new@example.com", true)
+	repo := &memoryUserRepo{}
+	svc := newOAuthTestService(srv.URL, repo)
+
+	state, stateToken := startFlow(t, svc, &challenge)
+	_, user, err := svc.HandleCallback(context.Background(), "mock", "good-code", state, stateToken)
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", user.Email)
+	assert.Empty(t, repo.users[0].Password)
+}
+
+func TestOAuthCallback_Rejections(t *testing.T) {
+	var challenge string
+	srv := newMockOIDCServer(t, &challenge, "sara@example.com", false)
+	repo := &memoryUserRepo{}
+	repo.Create(models.User{Name: "Sara", Email: "sara@example.com", Password: "bcrypt$x"})
+	svc := newOAuthTestService(srv.URL, repo)
+
+	state, stateToken := startFlow(t, svc, &challenge)
+	_, _, err := svc.HandleCallback(context.Background(), "mock", "good-code", "tampered", stateToken)
+	assert.Error(t, err, "يجب رفض state غير مطابق")
+
+	_, _, err = svc.HandleCallback(context.Background(), "mock", "bad-code", state, stateToken)
+	assert.Error(t, err, "يجب رفض الكود غير الصالح")
+
+	_, _, err = svc.HandleCallback(context.Background(), "mock", "good-code", state, stateToken)
+	assert.Error(t, err, "يجب رفض البريد غير المُتحقق منه")
+	assert.Empty(t, repo.users[0].Identities)
+}
+
+func TestOAuthStateToken_IsNotASession(t *testing.T) {
+	var challenge string
+	svc := newOAuthTestService(newMockOIDCServer(t, &challenge, "sara@example.com", true).URL, &memoryUserRepo{})
+	_, stateToken := startFlow(t, svc, &challenge)
+
+	claims, err := utils.ValidateJWTWithSecret(stateToken, "test-secret")
+	require.NoError(t, err)
+	assert.Equal(t, utils.PurposeOAuthState, claims.Purpose, "يجب أن يحمل رمز الحالة غرضاً حتى لا يُقبل كجلسة")
+}
+
+func TestOAuthStart_RedirectFollowsVersionTree(t *testing.T) {
+	var challenge string
+	idp := newMockOIDCServer(t, &challenge, "a@example.com", true)
+	svc := newOAuthTestService(idp.URL, &memoryUserRepo{})
+
+	ctx := authctx.WithAPIVersion(context.Background(), authctx.APIVersion{Version: "v1", BasePath: "/api/v1"})
+	authURL, _, err := svc.StartAuth(ctx, "mock")
+	require.NoError(t, err)
+	u, _ := url.Parse(authURL)
+	assert.Equal(t, "http://localhost:8090/api/v1/auth/oauth/mock/callback", u.Query().Get("redirect_uri"), "يجب أن يعود المزوّد إلى مسار الإصدار نفسه")
+
+	authURL, _, err = svc.StartAuth(context.Background(), "mock")
+	require.NoError(t, err)
+	u, _ = url.Parse(authURL)
+	assert.Equal(t, "http://localhost:8090/auth/oauth/mock/callback", u.Query().Get("redirect_uri"), "المسارات القديمة تبقى بدون بادئة")
+}
@@ -0,0 +1,82 @@
+package services_test
+
+import (
+	"context"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/services"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantScoping_UsersAreIsolatedPerOrganization(t *testing.T) {
+	repo := &memoryUserRepo{}
+	svc := services.NewUserService(repo, nil)
+	acme := authctx.WithTenant(context.Background(), "acme-id")
+	globex := authctx.WithTenant(context.Background(), "globex-id")
+
+	a, err := svc.CreateUser(acme, models.CreateUserRequest{Name: "Sara", Email: "sara@example.com", Password: "password123"})
+	require.NoError(t, err)
+	_, err = svc.CreateUser(globex, models.CreateUserRequest{Name: "Sara", Email: "sara@example.com", Password: "password123"})
+	require.NoError(t, err, "يجب السماح بنفس البريد في منظمتين مختلفتين")
+	_, err = svc.CreateUser(acme, models.CreateUserRequest{Name: "Sara", Email: "sara@example.com", Password: "password123"})
+	assert.Error(t, err, "يجب رفض البريد المكرر داخل المنظمة نفسها")
+
+	users, err := svc.GetAllUsers(acme, models.UserFilter{})
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "acme-id", users[0].TenantID)
+
+	_, err = svc.GetUserByID(globex, a.ID)
+	assert.Error(t, err, "يجب ألا يرى مستأجر مستخدمي مستأجر آخر")
+	assert.Error(t, svc.DeleteUser(context.Background(), a.ID, -1), "يجب ألا يصل المستأجر الافتراضي إلى مستخدمي المنظمات")
+}
+
+func TestOrganizations_CRUDAndMembership(t *testing.T) {
+	users := &memoryUserRepo{}
+	svc := services.NewOrganizationService(&memoryOrganizationRepo{}, users, nil)
+	platform := context.Background()
+
+	_, err := svc.Create(platform, models.OrganizationRequest{Name: "Acme", Slug: "Not A Slug"})
+	assert.Error(t, err, "يجب رفض المعرّف النصي غير الصالح")
+	org, err := svc.Create(platform, models.OrganizationRequest{Name: "Acme", Slug: "Acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", org.Slug)
+	_, err = svc.Create(platform, models.OrganizationRequest{Name: "Acme 2", Slug: "acme"})
+	assert.Error(t, err, "يجب أن يكون المعرّف النصي فريداً")
+
+	resolved, err := svc.ResolveTenant("ACME")
+	require.NoError(t, err)
+	assert.Equal(t, org.ID, resolved.ID)
+
+	admin, _ := users.Create(models.User{Name: "Platform Admin", Email: "root@example.com", Role: models.RoleAdmin})
+	member, err := svc.AddMember(platform, org.ID.Hex(), admin.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, org.ID.Hex(), member.TenantID)
+	assert.Empty(t, member.Role, "يجب ألا ينتقل دور مدير المنصة إلى المنظمة")
+
+	orgCtx := authctx.WithTenant(context.Background(), org.ID.Hex())
+	members, err := svc.ListMembers(orgCtx, org.ID.Hex(), models.UserFilter{})
+	require.NoError(t, err)
+	assert.Len(t, members, 1)
+	_, err = svc.ListMembers(authctx.WithTenant(context.Background(), "other"), org.ID.Hex(), models.UserFilter{})
+	assert.ErrorIs(t, err, services.ErrOrganizationForbidden)
+	_, err = svc.Create(orgCtx, models.OrganizationRequest{Name: "Nested", Slug: "nested"})
+	assert.ErrorIs(t, err, services.ErrOrganizationForbidden, "فقط مديرو المنصة ينشئون المنظمات")
+
+	updated, err := svc.SetMemberRole(orgCtx, org.ID.Hex(), member.ID, models.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, updated.Role)
+
+	assert.ErrorIs(t, svc.Delete(platform, org.ID.Hex()), services.ErrOrganizationNotEmpty)
+	require.NoError(t, svc.RemoveMember(orgCtx, org.ID.Hex(), member.ID))
+	back, err := users.FindUserByID(member.ID)
+	require.NoError(t, err, "يجب إعادة العضو إلى المستأجر الافتراضي")
+	assert.Empty(t, back.Role, "يجب ألا يصبح مدير المنظمة مديراً للمنصة")
+
+	require.NoError(t, svc.Delete(platform, org.ID.Hex()))
+	_, err = svc.Get(platform, org.ID.Hex())
+	assert.ErrorIs(t, err, services.ErrOrganizationNotFound)
+}
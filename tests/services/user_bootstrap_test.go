@@ -0,0 +1,35 @@
+package services_test
+
+import (
+	"context"
+	"project/internal/authctx"
+	"project/internal/models"
+	"project/internal/services"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapAdmin(t *testing.T) {
+	repo := &memoryUserRepo{}
+	svc := services.NewUserService(repo, nil)
+	ctx := context.Background()
+	_, err := svc.CreateUser(ctx, models.CreateUserRequest{Name: "Sara", Email: "sara@example.com", Password: "password123"})
+	require.NoError(t, err)
+	_, err = svc.CreateUser(authctx.WithTenant(ctx, "org-1"), models.CreateUserRequest{Name: "Omar", Email: "omar@example.com", Password: "password123"})
+	require.NoError(t, err)
+
+	promoted, err := svc.BootstrapAdmin(ctx, "sara@example.com")
+	require.NoError(t, err)
+	assert.True(t, promoted)
+	assert.Equal(t, models.RoleAdmin, repo.users[0].Role)
+
+	promoted, err = svc.BootstrapAdmin(ctx, "sara@example.com")
+	require.NoError(t, err)
+	assert.False(t, promoted, "المشرف الحالي يبقى كما هو")
+
+	_, err = svc.BootstrapAdmin(ctx, "omar@example.com")
+	assert.Error(t, err, "يقتصر التمهيد على المستأجر الافتراضي")
+	assert.Empty(t, repo.users[1].Role)
+}
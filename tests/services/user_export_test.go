@@ -0,0 +1,77 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"project/internal/models"
+	"project/internal/services"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func newExportTestService() *services.UserService {
+	repo := &memoryUserRepo{}
+	repo.Create(models.User{Name: "Sara", Email: "sara@example.com", Password: "bcrypt$hash"})
+	repo.Create(models.User{Name: "=HYPERLINK(\"x\")", Email: "admin@example.com", Role: models.RoleAdmin})
+	return services.NewUserService(repo, nil)
+}
+
+func TestExportUsers_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := newExportTestService().ExportUsers(context.Background(), models.UserFilter{}, services.ExportFormatCSV, []string{"email", "name", "role"}, &buf)
+	require.NoError(t, err)
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"email", "name", "role"}, rows[0])
+	assert.Equal(t, []string{"sara@example.com", "Sara", "user"}, rows[1])
+	assert.True(t, strings.HasPrefix(rows[2][1], "'="), "يجب تعطيل الصيغ في ملفات CSV")
+	assert.NotContains(t, buf.String(), "bcrypt")
+}
+
+func TestExportUsers_NDJSONWithFilter(t *testing.T) {
+	var buf bytes.Buffer
+	err := newExportTestService().ExportUsers(context.Background(), models.UserFilter{Role: models.RoleAdmin}, services.ExportFormatNDJSON, nil, &buf)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1, "يجب تطبيق نفس فلاتر القائمة")
+	var row map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &row))
+	assert.Equal(t, "admin@example.com", row["email"])
+	assert.NotContains(t, row, "password")
+}
+
+func TestExportUsers_NDJSONKeepsColumnOrder(t *testing.T) {
+	var buf bytes.Buffer
+	err := newExportTestService().ExportUsers(context.Background(), models.UserFilter{Role: models.RoleAdmin}, services.ExportFormatNDJSON,
+		[]string{"role", "email", "name", "email"}, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, `{"role":"admin","email":"admin@example.com","name":"=HYPERLINK(\"x\")"}`+"\n", buf.String(),
+		"يجب أن تتبع المفاتيح ترتيب الأعمدة المطلوبة")
+}
+
+func TestExportUsers_XLSX(t *testing.T) {
+	var buf bytes.Buffer
+	err := newExportTestService().ExportUsers(context.Background(), models.UserFilter{}, services.ExportFormatXLSX, []string{"id", "email"}, &buf)
+	require.NoError(t, err)
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	rows, err := f.GetRows(f.GetSheetName(0))
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"id", "email"}, rows[0])
+	assert.Equal(t, "sara@example.com", rows[1][1])
+}
+
+func TestExportUsers_RejectsUnknownColumnsAndFormats(t *testing.T) {
+	_, err := services.ResolveExportColumns(services.ExportFormatCSV, []string{"password"})
+	assert.ErrorIs(t, err, services.ErrUnknownExportColumn, "يجب عدم السماح بتصدير كلمات المرور")
+	_, err = services.ResolveExportColumns("pdf", nil)
+	assert.ErrorIs(t, err, services.ErrUnsupportedExportFormat)
+}
@@ -0,0 +1,26 @@
+package services_test
+
+import (
+	"context"
+	"project/internal/models"
+	"project/internal/services"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangePassword_Errors(t *testing.T) {
+	svc := services.NewUserService(&memoryUserRepo{}, nil)
+	ctx := context.Background()
+	created, err := svc.CreateUser(ctx, models.CreateUserRequest{Name: "Sara", Email: "sara@example.com", Password: "old-password"})
+	require.NoError(t, err)
+
+	err = svc.ChangePassword(ctx, "64b000000000000000000009", "old-password", "new-password")
+	assert.ErrorIs(t, err, services.ErrUserNotFound)
+	err = svc.ChangePassword(ctx, created.ID, "wrong-password", "new-password")
+	assert.ErrorIs(t, err, services.ErrCurrentPasswordIncorrect)
+	err = svc.ChangePassword(ctx, created.ID, "old-password", "short")
+	assert.ErrorIs(t, err, services.ErrPasswordTooShort)
+	assert.NoError(t, svc.ChangePassword(ctx, created.ID, "old-password", "new-password"))
+}
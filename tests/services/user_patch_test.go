@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"context"
+	"project/internal/models"
+	"project/internal/repositories"
+	"project/internal/services"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPatchTestUser(t *testing.T) (*services.UserService, *memoryUserRepo, string) {
+	repo := &memoryUserRepo{}
+	svc := services.NewUserService(repo, nil)
+	created, err := svc.CreateUser(context.Background(), models.CreateUserRequest{Name: "Sara", Email: "sara@example.com", Password: "old-password"})
+	require.NoError(t, err)
+	return svc, repo, created.ID
+}
+
+func TestPatchUser_MergePatch(t *testing.T) {
+	svc, repo, id := newPatchTestUser(t)
+	hash := repo.users[0].Password
+
+	user, err := svc.PatchUser(context.Background(), id, services.MergePatchMediaType, []byte(`{"email":"Sara.New@Example.com"}`), repositories.AnyVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "sara.new@example.com", user.Email)
+	assert.Equal(t, "Sara", user.Name, "يجب الإبقاء على الحقول غير المذكورة")
+	assert.Equal(t, hash, repo.users[0].Password, "يجب عدم تغيير كلمة المرور")
+
+	// null removes the member, which violates the required rule
+	_, err = svc.PatchUser(context.Background(), id, services.MergePatchMediaType, []byte(`{"name":null}`), repositories.AnyVersion)
+	var verrs *services.ValidationErrors
+	require.ErrorAs(t, err, &verrs, "يجب رفض حذف حقل مطلوب")
+	assert.Equal(t, "Name", verrs.Errors[0].Field)
+
+	_, err = svc.PatchUser(context.Background(), id, services.MergePatchMediaType, []byte(`{"role":"admin"}`), repositories.AnyVersion)
+	assert.ErrorIs(t, err, services.ErrInvalidPatch, "يجب رفض الحقول غير القابلة للتعديل")
+	assert.Empty(t, repo.users[0].Role)
+}
+
+func TestPatchUser_JSONPatch(t *testing.T) {
+	svc, _, id := newPatchTestUser(t)
+
+	user, err := svc.PatchUser(context.Background(), id, services.JSONPatchMediaType,
+		[]byte(`[{"op":"test","path":"/name","value":"Sara"},{"op":"replace","path":"/name","value":"Sara Ahmed"}]`), repositories.AnyVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "Sara Ahmed", user.Name)
+
+	_, err = svc.PatchUser(context.Background(), id, services.JSONPatchMediaType,
+		[]byte(`[{"op":"test","path":"/name","value":"Sara"},{"op":"replace","path":"/name","value":"Other"}]`), repositories.AnyVersion)
+	assert.ErrorIs(t, err, services.ErrPatchConflict, "يجب فشل العملية عند عدم تطابق test")
+
+	_, err = svc.PatchUser(context.Background(), id, services.JSONPatchMediaType, []byte(`{"op":"replace"}`), repositories.AnyVersion)
+	assert.ErrorIs(t, err, services.ErrInvalidPatch)
+
+	_, err = svc.PatchUser(context.Background(), id, "application/json", []byte(`{}`), repositories.AnyVersion)
+	assert.ErrorIs(t, err, services.ErrUnsupportedPatchType)
+}
+
+func TestReplaceUser_IsFullReplacement(t *testing.T) {
+	svc, repo, id := newPatchTestUser(t)
+	hash := repo.users[0].Password
+
+	_, err := svc.ReplaceUser(context.Background(), id, models.UserReplacement{Email: "sara@example.com"}, repositories.AnyVersion)
+	var verrs *services.ValidationErrors
+	require.ErrorAs(t, err, &verrs, "يجب رفض الاستبدال بدون اسم بدلاً من تجاهله")
+
+	user, err := svc.ReplaceUser(context.Background(), id, models.UserReplacement{Name: "Sara", Email: "other@example.com"}, repositories.AnyVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "other@example.com", user.Email)
+	assert.Equal(t, hash, repo.users[0].Password, "يجب الإبقاء على كلمة المرور عند عدم إرسالها")
+}
+
+func TestWrites_RejectStaleVersion(t *testing.T) {
+	svc, repo, id := newPatchTestUser(t)
+	assert.Equal(t, int64(1), repo.users[0].Version)
+
+	user, err := svc.PatchUser(context.Background(), id, services.MergePatchMediaType, []byte(`{"name":"First"}`), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), user.Version, "يجب زيادة الإصدار بعد كل تعديل")
+
+	// A second editor still holding version 1 must not overwrite the change
+	_, err = svc.ReplaceUser(context.Background(), id, models.UserReplacement{Name: "Second", Email: "sara@example.com"}, 1)
+	assert.ErrorIs(t, err, repositories.ErrVersionConflict)
+	assert.Equal(t, "First", repo.users[0].Name)
+
+	err = svc.DeleteUser(context.Background(), id, 1)
+	assert.ErrorIs(t, err, repositories.ErrVersionConflict)
+	require.NoError(t, svc.DeleteUser(context.Background(), id, 2))
+}
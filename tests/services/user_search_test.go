@@ -0,0 +1,52 @@
+package services_test
+
+import (
+	"context"
+	"project/internal/models"
+	"project/internal/services"
+	"project/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSearchText(t *testing.T) {
+	assert.Equal(t, "jose muller", utils.NormalizeSearchText("  José  Müller "))
+	assert.Equal(t, utils.NormalizeSearchText("احمد"), utils.NormalizeSearchText("أَحْمَد"), "يجب تجاهل التشكيل وأشكال الألف")
+	assert.Equal(t, utils.NormalizeSearchText("فاطمه"), utils.NormalizeSearchText("فاطمة"))
+}
+
+func TestSearchUsers(t *testing.T) {
+	repo := &memoryUserRepo{}
+	repo.Create(models.User{Name: "أحمد علي", Email: "ahmed@example.com"})
+	repo.Create(models.User{Name: "José Pérez", Email: "jose@example.com"})
+	repo.Create(models.User{Name: "Sara Ahmed", Email: "sara@example.com"})
+	svc := services.NewUserService(repo, nil)
+	ctx := context.Background()
+
+	results, err := svc.SearchUsers(ctx, "احمد", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "<mark>أحمد</mark> علي", results[0].Highlights["name"], "يجب البحث بدون حساسية للهمزة")
+
+	results, err = svc.SearchUsers(ctx, "jose", 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "jose@example.com", results[0].User.Email)
+	assert.Equal(t, "<mark>José</mark> Pérez", results[0].Highlights["name"])
+
+	// Typeahead on email prefix
+	results, err = svc.SearchUsers(ctx, "sar", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "<mark>sar</mark>a@example.com", results[0].Highlights["email"])
+
+	_, err = svc.SearchUsers(ctx, "  ", 0)
+	assert.Error(t, err)
+}
+
+func TestHighlightTermsEscapesHTML(t *testing.T) {
+	assert.Equal(t, "&lt;b&gt; <mark>Sara</mark>", utils.HighlightTerms("<b> Sara", []string{"sara"}))
+	assert.Empty(t, utils.HighlightTerms("Sara", []string{"ara"}), "يجب أن تبدأ المطابقة من بداية الكلمة")
+}
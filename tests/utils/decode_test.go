@@ -0,0 +1,108 @@
+package utils_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"project/internal/models"
+	"project/pkg/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memberInput struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type teamInput struct {
+	Name    string        `json:"name"`
+	Size    int           `json:"size"`
+	Members []memberInput `json:"members"`
+}
+
+func decode(body, contentType string, dst interface{}, limit int64) *utils.DecodeError {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	err := utils.DecodeJSON(httptest.NewRecorder(), req, dst, limit)
+	if err == nil {
+		return nil
+	}
+	var derr *utils.DecodeError
+	if !errors.As(err, &derr) {
+		panic(err)
+	}
+	return derr
+}
+
+func TestDecodeJSON_Accepts(t *testing.T) {
+	var in teamInput
+	err := decode(`{"name":"core","size":3,"members":[{"email":"a@example.com","role":"admin"}]}`+"\n", "application/json; charset=utf-8", &in, utils.MaxJSONBodyBytes)
+	require.Nil(t, err)
+	assert.Equal(t, "core", in.Name)
+	assert.Equal(t, "admin", in.Members[0].Role)
+}
+
+func TestDecodeJSON_RejectsServerManagedUserFields(t *testing.T) {
+	var in models.CreateUserRequest
+	err := decode(`{"name":"Sara","email":"sara@example.com","password":"password123","created_at":"2020-01-01T00:00:00Z"}`, "application/json", &in, utils.MaxJSONBodyBytes)
+	require.NotNil(t, err, "يجب رفض الحقول التي يديرها الخادم")
+	assert.Equal(t, http.StatusBadRequest, err.Status)
+	assert.Equal(t, "created_at", err.Field)
+	assert.Equal(t, int64(67), err.Offset, "يجب أن يشير الموضع إلى بداية المفتاح")
+}
+
+func TestDecodeJSON_UnknownNestedFieldHasPath(t *testing.T) {
+	var in teamInput
+	err := decode(`{"name":"core","members":[{"email":"a@example.com"},{"email":"b@example.com","id":"x"}]}`, "application/json", &in, utils.MaxJSONBodyBytes)
+	require.NotNil(t, err)
+	assert.Equal(t, "members.1.id", err.Field, "يجب أن يتضمن المسار فهرس العنصر")
+	assert.Contains(t, err.Message, `"members.1.id"`)
+}
+
+func TestDecodeJSON_TypeErrorHasPathAndOffset(t *testing.T) {
+	var in teamInput
+	err := decode(`{"name":"core","members":[{"role":7}]}`, "application/json", &in, utils.MaxJSONBodyBytes)
+	require.NotNil(t, err)
+	assert.Equal(t, "members.0.role", err.Field)
+	assert.Equal(t, int64(35), err.Offset)
+	assert.Contains(t, err.Message, "must be a string, got number")
+
+	err = decode(`{"size":"three"}`, "application/json", &in, utils.MaxJSONBodyBytes)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Message, `Field "size" must be an integer, got string`)
+}
+
+func TestDecodeJSON_Malformed(t *testing.T) {
+	var in teamInput
+	cases := map[string]string{
+		`{"name":"core",}`:      "Malformed JSON at offset 16",
+		`{"name":"core"`:        "body ends unexpectedly",
+		``:                      "must not be empty",
+		`{"name":"core"} {}`:    "single JSON value",
+		`{"name":"core"}garbage`: "found more data at offset 15",
+		`[1]`:                   "Request body must be an object, got array",
+	}
+	for body, want := range cases {
+		err := decode(body, "application/json", &in, utils.MaxJSONBodyBytes)
+		require.NotNil(t, err, body)
+		assert.Equal(t, http.StatusBadRequest, err.Status, body)
+		assert.Contains(t, err.Message, want, body)
+	}
+}
+
+func TestDecodeJSON_LimitsAndContentType(t *testing.T) {
+	var in teamInput
+	err := decode(`{"name":"`+strings.Repeat("x", 100)+`"}`, "application/json", &in, 64)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, err.Status, "يجب رفض الأجسام الأكبر من الحد")
+
+	err = decode(`{"name":"core"}`, "text/plain", &in, utils.MaxJSONBodyBytes)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusUnsupportedMediaType, err.Status)
+
+	assert.Nil(t, decode(`{"name":"core"}`, "application/merge-patch+json", &in, utils.MaxJSONBodyBytes))
+}